@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	YAMLFormat = "yaml"
+	JSONFormat = "json"
+	TOMLFormat = "toml"
+)
+
+// Codec encodes and decodes workload assignations on a concrete format
+type Codec interface {
+	Encode(w *Workloads) ([]byte, error)
+	Decode(data []byte) (*Workloads, error)
+	Format() string
+}
+
+// NewCodec instantiates codec from format name
+func NewCodec(format string) (Codec, error) {
+	switch format {
+	case YAMLFormat, "yml":
+		return NewYAMLCodec(), nil
+	case JSONFormat:
+		return NewJSONCodec(), nil
+	case TOMLFormat:
+		return NewTOMLCodec(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported codec format %s", format)
+}
+
+type yamlCodec struct{}
+
+// NewYAMLCodec instantiates yaml codec
+func NewYAMLCodec() Codec {
+	return &yamlCodec{}
+}
+
+// Encode marshals workloads as yaml
+func (c *yamlCodec) Encode(w *Workloads) ([]byte, error) {
+	var buffer bytes.Buffer
+	yamlEncoder := yaml.NewEncoder(&buffer)
+	yamlEncoder.SetIndent(2)
+	if err := yamlEncoder.Encode(w); err != nil {
+		return nil, fmt.Errorf("unable to marshall yaml, error %v", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Decode unmarshals workloads from yaml
+func (c *yamlCodec) Decode(data []byte) (*Workloads, error) {
+	raw := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to unmarshall yaml, error %v", err)
+	}
+
+	return decodeMap(raw)
+}
+
+// Format returns codec format name
+func (c *yamlCodec) Format() string {
+	return YAMLFormat
+}
+
+type jsonCodec struct{}
+
+// NewJSONCodec instantiates json codec
+func NewJSONCodec() Codec {
+	return &jsonCodec{}
+}
+
+// Encode marshals workloads as json
+func (c *jsonCodec) Encode(w *Workloads) ([]byte, error) {
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshall json, error %v", err)
+	}
+
+	return data, nil
+}
+
+// Decode unmarshals workloads from json
+func (c *jsonCodec) Decode(data []byte) (*Workloads, error) {
+	raw := make(map[string]interface{})
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to unmarshall json, error %v", err)
+	}
+
+	return decodeMap(raw)
+}
+
+// Format returns codec format name
+func (c *jsonCodec) Format() string {
+	return JSONFormat
+}
+
+type tomlCodec struct{}
+
+// NewTOMLCodec instantiates toml codec
+func NewTOMLCodec() Codec {
+	return &tomlCodec{}
+}
+
+// Encode marshals workloads as toml
+func (c *tomlCodec) Encode(w *Workloads) ([]byte, error) {
+	data, err := toml.Marshal(w)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshall toml, error %v", err)
+	}
+
+	return data, nil
+}
+
+// Decode unmarshals workloads from toml
+func (c *tomlCodec) Decode(data []byte) (*Workloads, error) {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshall toml, error %v", err)
+	}
+
+	return decodeMap(tree.ToMap())
+}
+
+// Format returns codec format name
+func (c *tomlCodec) Format() string {
+	return TOMLFormat
+}
+
+func decodeMap(raw map[string]interface{}) (*Workloads, error) {
+	w := &Workloads{}
+	if err := mapstructure.Decode(raw, w); err != nil {
+		return nil, fmt.Errorf("unable to decode, error %v", err)
+	}
+
+	return w, nil
+}
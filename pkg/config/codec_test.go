@@ -0,0 +1,75 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCodec_ItEncodesAndDecodesWorkloadsOnAllFormats(t *testing.T) {
+	w := &Workloads{
+		Version: 3,
+		Workloads: map[string]*Workload{
+			"swarm-worker-0": {Jobs: []Job{"stream:foo", "stream:bar"}},
+			"swarm-worker-1": {Jobs: []Job{"stream:zoom"}},
+		},
+	}
+
+	for _, format := range []string{YAMLFormat, JSONFormat, TOMLFormat} {
+		c, err := NewCodec(format)
+		if err != nil {
+			t.Fatalf("unexpected error building codec %s, error %v", format, err)
+		}
+		if expected, got := format, c.Format(); expected != got {
+			t.Errorf("format does not match, expected %s got %s", expected, got)
+		}
+
+		data, err := c.Encode(w)
+		if err != nil {
+			t.Fatalf("unable to encode %s, error %v", format, err)
+		}
+
+		res, err := c.Decode(data)
+		if err != nil {
+			t.Fatalf("unable to decode %s, error %v", format, err)
+		}
+
+		if !w.Equals(res) {
+			t.Errorf("%s decoded workloads do not match, expected %v got %v", format, w, res)
+		}
+	}
+}
+
+func TestCodec_ItEncodesWeightOnlyOnWeightedWorkloads(t *testing.T) {
+	w := &Workloads{
+		Version: 3,
+		Workloads: map[string]*Workload{
+			"swarm-worker-0": {Jobs: []Job{"stream:foo"}},
+			"swarm-worker-1": {Jobs: []Job{"stream:zoom"}, Weight: 4},
+		},
+	}
+
+	for _, format := range []string{YAMLFormat, JSONFormat, TOMLFormat} {
+		c, _ := NewCodec(format)
+		data, err := c.Encode(w)
+		if err != nil {
+			t.Fatalf("unable to encode %s, error %v", format, err)
+		}
+		if expected, got := 1, bytes.Count(data, []byte("weight")); expected != got {
+			t.Errorf("%s encoded weights do not match, expected %d got %d: %s", format, expected, got, data)
+		}
+
+		res, err := c.Decode(data)
+		if err != nil {
+			t.Fatalf("unable to decode %s, error %v", format, err)
+		}
+		if expected, got := int64(4), res.Workloads["swarm-worker-1"].Weight; expected != got {
+			t.Errorf("%s decoded weight does not match, expected %d got %d", format, expected, got)
+		}
+	}
+}
+
+func TestCodec_ItFailsOnUnknownFormat(t *testing.T) {
+	if _, err := NewCodec("xml"); err == nil {
+		t.Fatal("expected error building unknown codec")
+	}
+}
@@ -5,7 +5,7 @@ import (
 	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"os"
+	"time"
 )
 
 var (
@@ -14,55 +14,78 @@ var (
 
 	// Date on current release build
 	Date string
-
-	LogLevel       string
-	Env            string
-	ConfigFile     string
-	ConfigFilePath string
-	HttpPort       string
 )
 
-func BuildLogger(appID string) error {
-	level, err := log.ParseLevel(LogLevel)
+// BuildLogger setups global and component loggers from core config, klog output gets routed to logrus
+func (c *Core) BuildLogger(appID string) error {
+	level, err := log.ParseLevel(c.LogLevel)
 	if err != nil {
 		return fmt.Errorf("unexpected error parsing level, error %v", err)
 	}
-	log.SetLevel(level)
-	log.SetReportCaller(true)
-	log.SetFormatter(logger.PrettifiedFormatter())
-	log.AddHook(logger.NewGlobalFieldHook(appID, Env))
 
-	return nil
+	f, err := logger.NewFormatter(c.LogFormat)
+	if err != nil {
+		return err
+	}
+
+	logger.Setup(level, f, logger.NewGlobalFieldHook(appID, c.Env))
+	if err := logger.InstallKlogBridge(); err != nil {
+		return err
+	}
+
+	return c.ApplyLevels()
 }
 
-func SetCoreFlags(cmd *cobra.Command, service string) {
-	cmd.PersistentFlags().StringVar(&LogLevel, "log-level", "info", "logging level")
-	cmd.PersistentFlags().StringVar(&Env, "env", "dev", "environment where the application is running")
-	cmd.PersistentFlags().StringVar(&ConfigFile, "config", "config.yml", "config file source")
-	cmd.PersistentFlags().StringVar(&ConfigFilePath, "config-path", fmt.Sprintf("services/%s/config", service), "config file path")
-	if p := os.Getenv("CONFIG_PATH"); p != "" {
-		ConfigFilePath = p
+// ApplyLevels updates global and component levels, safe to be called at runtime
+func (c *Core) ApplyLevels() error {
+	level, err := log.ParseLevel(c.LogLevel)
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing level, error %v", err)
 	}
-	cmd.PersistentFlags().StringVar(&HttpPort, "http-port", "9090", "http server port")
-	if p := os.Getenv("HTTP_PORT"); p != "" {
-		HttpPort = p
+
+	levels, err := logger.ParseLevels(c.LogComponents)
+	if err != nil {
+		return err
 	}
+
+	logger.SetLevel(logger.Global, level)
+	logger.ApplyLevels(levels)
+
+	return nil
+}
+
+// SetCoreFlags defines core config flags, values get loaded on Core through Loader
+func SetCoreFlags(cmd *cobra.Command, service string) {
+	cmd.PersistentFlags().String("log-level", "info", "logging level")
+	cmd.PersistentFlags().String("log-format", logger.JSONFormat, "logging format (json, text, logfmt)")
+	cmd.PersistentFlags().String("log-components", "", "component level overrides as component=level pairs (runner=debug,swarm=warn)")
+	cmd.PersistentFlags().String("env", "dev", "environment where the application is running")
+	cmd.PersistentFlags().String("config", "config.yml", "config file source")
+	cmd.PersistentFlags().String("config-path", fmt.Sprintf("services/%s/config", service), "config file path")
+	cmd.PersistentFlags().String("http-port", "9090", "http server port")
+	cmd.PersistentFlags().Duration("http-shutdown-timeout", 10*time.Second, "http server graceful shutdown timeout")
+	cmd.PersistentFlags().String("tls-cert", "", "http server tls certificate file, reloaded on changes")
+	cmd.PersistentFlags().String("tls-key", "", "http server tls key file, reloaded on changes")
+	cmd.PersistentFlags().Bool("pprof", false, "enable pprof routes on http server")
+	cmd.PersistentFlags().Bool("log-level-updates", false, "enable runtime log level updates on http server")
 }
 
 // Job defines task assignation
 type Job string
 
-// Workload definitions from config
+// Workload definitions from config, weight reports assigned jobs total weight
 type Workload struct {
-	Jobs []Job `mapstructure:"jobs" json:"jobs"`
+	Jobs   []Job `mapstructure:"jobs" json:"jobs" toml:"jobs"`
+	Weight int64 `mapstructure:"weight" json:"weight,omitempty" toml:"weight,omitempty" yaml:"weight,omitempty"`
 }
 
 // Workloads defines all workload assignations to workers
 type Workloads struct {
-	Workloads map[string]*Workload `mapstructure:"workloads" json:"workloads"`
-	Version   int64                `mapstructure:"version" json:"version"`
+	Workloads map[string]*Workload `mapstructure:"workloads" json:"workloads" toml:"workloads"`
+	Version   int64                `mapstructure:"version" json:"version" toml:"version"`
 }
 
+// Equals compares canonical workloads, comparison is symmetric
 func (a *Workloads) Equals(asg *Workloads) bool {
 	if asg == nil {
 		return false
@@ -73,12 +96,16 @@ func (a *Workloads) Equals(asg *Workloads) bool {
 		return false
 	}
 
+	if len(a.Workloads) != len(asg.Workloads) {
+		return false
+	}
+
 	for s, w := range a.Workloads {
 		v, ok := asg.Workloads[s]
 		if !ok {
 			return false
 		}
-		if !v.Equals(w) {
+		if !v.Normalize().Equals(w.Normalize()) {
 			return false
 		}
 	}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWorkerFrequency = time.Second
+	defaultHandleTimeout   = time.Second
+	defaultMaxRetries      = 5
+)
+
+// Validatable defines self validated service config
+type Validatable interface {
+	Validate() error
+}
+
+// Core defines config shared by all services
+type Core struct {
+	LogLevel       string `mapstructure:"log-level"`
+	LogFormat      string `mapstructure:"log-format"`
+	LogComponents  string `mapstructure:"log-components"`
+	Env            string `mapstructure:"env"`
+	ConfigFile     string `mapstructure:"config"`
+	ConfigFilePath string `mapstructure:"config-path"`
+	HttpPort       string `mapstructure:"http-port"`
+
+	HttpShutdownTimeout time.Duration `mapstructure:"http-shutdown-timeout"`
+	TLSCert             string        `mapstructure:"tls-cert"`
+	TLSKey              string        `mapstructure:"tls-key"`
+	Pprof               bool          `mapstructure:"pprof"`
+	LogLevelUpdates     bool          `mapstructure:"log-level-updates"`
+}
+
+// Validate checks core config values
+func (c *Core) Validate() error {
+	if _, err := log.ParseLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("invalid log level %s", c.LogLevel)
+	}
+	if _, err := logger.NewFormatter(c.LogFormat); err != nil {
+		return err
+	}
+	if _, err := logger.ParseLevels(c.LogComponents); err != nil {
+		return err
+	}
+	if c.Env == "" {
+		return errors.New("empty env")
+	}
+	if p, err := strconv.Atoi(c.HttpPort); err != nil || p <= 0 || p > 65535 {
+		return fmt.Errorf("invalid http port %s", c.HttpPort)
+	}
+	if c.HttpShutdownTimeout <= 0 {
+		return fmt.Errorf("invalid http shutdown timeout %s", c.HttpShutdownTimeout)
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return errors.New("tls requires both cert and key files")
+	}
+
+	return nil
+}
+
+// Runner defines controller runner tuning, safe to be updated at runtime
+type Runner struct {
+	WorkerFrequency time.Duration `mapstructure:"runner-frequency"`
+	HandleTimeout   time.Duration `mapstructure:"runner-timeout"`
+	MaxRetries      int           `mapstructure:"runner-max-retries"`
+}
+
+// DefaultRunner returns default runner tuning
+func DefaultRunner() Runner {
+	return Runner{
+		WorkerFrequency: defaultWorkerFrequency,
+		HandleTimeout:   defaultHandleTimeout,
+		MaxRetries:      defaultMaxRetries,
+	}
+}
+
+// Validate checks runner tuning values
+func (r *Runner) Validate() error {
+	if r.WorkerFrequency <= 0 {
+		return fmt.Errorf("invalid runner frequency %s", r.WorkerFrequency)
+	}
+	if r.HandleTimeout <= 0 {
+		return fmt.Errorf("invalid runner timeout %s", r.HandleTimeout)
+	}
+	if r.MaxRetries < 0 {
+		return fmt.Errorf("invalid runner max retries %d", r.MaxRetries)
+	}
+
+	return nil
+}
+
+// SetRunnerFlags defines runner tuning flags
+func SetRunnerFlags(cmd *cobra.Command) {
+	d := DefaultRunner()
+	cmd.PersistentFlags().Duration("runner-frequency", d.WorkerFrequency, "runner worker restart frequency")
+	cmd.PersistentFlags().Duration("runner-timeout", d.HandleTimeout, "runner timeout handling each entry")
+	cmd.PersistentFlags().Int("runner-max-retries", d.MaxRetries, "runner max retries on entry handling errors")
+}
+
+// Loader loads typed service config, precedence from highest to lowest is: explicit flags,
+// env vars, config file and flag defaults. Env vars are named as its flag uppercased with
+// underscores, aliases allow keeping legacy env names.
+type Loader struct {
+	viper *viper.Viper
+	file  bool
+	mutex sync.Mutex
+}
+
+// NewLoader binds command persistent flags, env vars and optional config file from config-path and config flags
+func NewLoader(cmd *cobra.Command, aliases map[string]string) (*Loader, error) {
+	v := viper.New()
+	if err := v.BindPFlags(cmd.PersistentFlags()); err != nil {
+		return nil, fmt.Errorf("unable to bind flags, error %v", err)
+	}
+
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+	for key, env := range aliases {
+		if err := v.BindEnv(key, env, strings.ToUpper(strings.ReplaceAll(key, "-", "_"))); err != nil {
+			return nil, fmt.Errorf("unable to bind env %s, error %v", env, err)
+		}
+	}
+
+	l := &Loader{viper: v}
+	path := filepath.Join(v.GetString("config-path"), v.GetString("config"))
+	v.SetConfigFile(path)
+	err := v.ReadInConfig()
+	if errors.Is(err, os.ErrNotExist) {
+		log.Debugf("config file %s not found, using flags and env", path)
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file %s, error %v", path, err)
+	}
+
+	l.file = true
+	log.Infof("Using config file: %s", v.ConfigFileUsed())
+
+	return l, nil
+}
+
+// Load unmarshalls and validates service config
+func (l *Loader) Load(c Validatable) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.viper.Unmarshal(c); err != nil {
+		return fmt.Errorf("unable to unMarshall config, error %v", err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid config, error %v", err)
+	}
+
+	return nil
+}
+
+// Watch reloads config on file changes, build returns an empty service config and onChange gets called
+// with each valid reloaded one, invalid configs get discarded
+func (l *Loader) Watch(build func() Validatable, onChange func(Validatable)) {
+	if !l.file {
+		return
+	}
+
+	l.viper.OnConfigChange(func(e fsnotify.Event) {
+		log.Infof("Config file changed %s", e.Name)
+		c := build()
+		if err := l.Load(c); err != nil {
+			log.Errorf("discarding config reload, error %v", err)
+			return
+		}
+		onChange(c)
+	})
+	l.viper.WatchConfig()
+}
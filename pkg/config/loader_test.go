@@ -0,0 +1,169 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoader_ItAppliesFlagsOverEnvOverFileOverDefaults(t *testing.T) {
+	dir := t.TempDir()
+	data := []byte("log-level: debug\nenv: staging\nhttp-port: \"8080\"\nrunner-timeout: 3s\nrunner-max-retries: 2\n")
+	if err := os.WriteFile(filepath.Join(dir, "config.yml"), data, 0644); err != nil {
+		t.Fatalf("unable to write config file, error %v", err)
+	}
+
+	t.Setenv("ENV", "prod")
+	t.Setenv("LEGACY_RETRIES", "7")
+	t.Setenv("RUNNER_TIMEOUT", "4s")
+
+	cmd := newFakeCommand(t, "--config-path", dir, "--runner-timeout", "5s")
+	l, err := NewLoader(cmd, map[string]string{"runner-max-retries": "LEGACY_RETRIES"})
+	if err != nil {
+		t.Fatalf("unexpected error building loader, error %v", err)
+	}
+
+	c := &fakeConfig{}
+	if err := l.Load(c); err != nil {
+		t.Fatalf("unexpected error loading config, error %v", err)
+	}
+
+	if expected, got := "debug", c.LogLevel; expected != got {
+		t.Errorf("file value does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := "prod", c.Env; expected != got {
+		t.Errorf("env value does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := 7, c.MaxRetries; expected != got {
+		t.Errorf("env alias value does not match, expected %d got %d", expected, got)
+	}
+	if expected, got := time.Second*5, c.HandleTimeout; expected != got {
+		t.Errorf("flag value does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := time.Second, c.WorkerFrequency; expected != got {
+		t.Errorf("default value does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestLoader_ItWorksWithoutConfigFile(t *testing.T) {
+	cmd := newFakeCommand(t, "--config-path", t.TempDir())
+	l, err := NewLoader(cmd, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building loader, error %v", err)
+	}
+
+	c := &fakeConfig{}
+	if err := l.Load(c); err != nil {
+		t.Fatalf("unexpected error loading config, error %v", err)
+	}
+
+	if expected, got := "info", c.LogLevel; expected != got {
+		t.Errorf("default value does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestLoader_ItReadsConfigFileFromConfigPathEnv(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yml"), []byte("log-level: debug\n"), 0644); err != nil {
+		t.Fatalf("unable to write config file, error %v", err)
+	}
+	t.Setenv("CONFIG_PATH", dir)
+	t.Setenv("HTTP_PORT", "8081")
+
+	l, err := NewLoader(newFakeCommand(t), nil)
+	if err != nil {
+		t.Fatalf("unexpected error building loader, error %v", err)
+	}
+
+	c := &fakeConfig{}
+	if err := l.Load(c); err != nil {
+		t.Fatalf("unexpected error loading config, error %v", err)
+	}
+
+	if expected, got := dir, c.ConfigFilePath; expected != got {
+		t.Errorf("config path does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := "debug", c.LogLevel; expected != got {
+		t.Errorf("file value does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := "8081", c.HttpPort; expected != got {
+		t.Errorf("http port does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestLoader_ItRejectsInvalidConfig(t *testing.T) {
+	cmd := newFakeCommand(t, "--config-path", t.TempDir(), "--log-level", "foo")
+	l, err := NewLoader(cmd, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building loader, error %v", err)
+	}
+
+	if err := l.Load(&fakeConfig{}); err == nil {
+		t.Fatal("expected error on invalid log level")
+	}
+}
+
+type fakeConfig struct {
+	Core   `mapstructure:",squash"`
+	Runner `mapstructure:",squash"`
+}
+
+func (f *fakeConfig) Validate() error {
+	if err := f.Core.Validate(); err != nil {
+		return err
+	}
+
+	return f.Runner.Validate()
+}
+
+func newFakeCommand(t *testing.T, args ...string) *cobra.Command {
+	cmd := &cobra.Command{Use: "fake"}
+	SetCoreFlags(cmd, "fake")
+	SetRunnerFlags(cmd)
+	if err := cmd.PersistentFlags().Parse(args); err != nil {
+		t.Fatalf("unable to parse flags, error %v", err)
+	}
+
+	return cmd
+}
+
+func TestLoader_ItReloadsValidConfigOnFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+	if err := os.WriteFile(path, []byte("log-level: info\n"), 0644); err != nil {
+		t.Fatalf("unable to write config file, error %v", err)
+	}
+
+	l, err := NewLoader(newFakeCommand(t, "--config-path", dir), nil)
+	if err != nil {
+		t.Fatalf("unexpected error building loader, error %v", err)
+	}
+
+	changes := make(chan *fakeConfig, 10)
+	l.Watch(func() Validatable { return &fakeConfig{} }, func(c Validatable) {
+		changes <- c.(*fakeConfig)
+	})
+
+	if err := os.WriteFile(path, []byte("log-level: foo\n"), 0644); err != nil {
+		t.Fatalf("unable to write config file, error %v", err)
+	}
+	time.Sleep(time.Millisecond * 100)
+	if err := os.WriteFile(path, []byte("log-level: debug\n"), 0644); err != nil {
+		t.Fatalf("unable to write config file, error %v", err)
+	}
+
+	// file writes may fire multiple events, wait until last written content gets loaded
+	timeout := time.After(time.Second * 2)
+	for {
+		select {
+		case c := <-changes:
+			if c.LogLevel == "debug" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("config reload timeout")
+		}
+	}
+}
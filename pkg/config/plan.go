@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Move describes a job transition between workers, From is empty on added jobs and To on removed ones
+type Move struct {
+	Job  Job    `json:"job"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// Plan describes job transitions between two workloads versions
+type Plan struct {
+	FromVersion int64  `json:"fromVersion"`
+	ToVersion   int64  `json:"toVersion"`
+	Moves       []Move `json:"moves"`
+	Added       []Move `json:"added"`
+	Removed     []Move `json:"removed"`
+	Stats       Stats  `json:"stats"`
+}
+
+// Stats summarizes plan impact
+type Stats struct {
+	TotalJobs       int     `json:"totalJobs"`
+	Unchanged       int     `json:"unchanged"`
+	Moved           int     `json:"moved"`
+	Added           int     `json:"added"`
+	Removed         int     `json:"removed"`
+	MovedPercentage float64 `json:"movedPercentage"`
+}
+
+// NewPlan computes job transitions from previous to current workloads, nil workloads are taken as empty
+func NewPlan(previous, current *Workloads) *Plan {
+	p := &Plan{Moves: []Move{}, Added: []Move{}, Removed: []Move{}}
+	if previous != nil {
+		p.FromVersion = previous.Version
+	}
+	if current != nil {
+		p.ToVersion = current.Version
+	}
+
+	from := owners(previous)
+	to := owners(current)
+
+	for _, job := range sortedJobs(to) {
+		src, ok := from[job]
+		dst := to[job]
+		switch {
+		case !ok:
+			p.Added = append(p.Added, Move{Job: job, To: dst})
+		case src != dst:
+			p.Moves = append(p.Moves, Move{Job: job, From: src, To: dst})
+		default:
+			p.Stats.Unchanged++
+		}
+	}
+
+	for _, job := range sortedJobs(from) {
+		if _, ok := to[job]; !ok {
+			p.Removed = append(p.Removed, Move{Job: job, From: from[job]})
+		}
+	}
+
+	p.Stats.TotalJobs = len(to)
+	p.Stats.Moved = len(p.Moves)
+	p.Stats.Added = len(p.Added)
+	p.Stats.Removed = len(p.Removed)
+	if len(from) > 0 {
+		p.Stats.MovedPercentage = float64(len(p.Moves)) * 100 / float64(len(from))
+	}
+
+	return p
+}
+
+// IsEmpty checks if plan has any job transition
+func (p *Plan) IsEmpty() bool {
+	return len(p.Moves) == 0 && len(p.Added) == 0 && len(p.Removed) == 0
+}
+
+// AffectedWorkers returns sorted worker names whose jobs change, only those need a restart
+func (p *Plan) AffectedWorkers() []string {
+	idx := map[string]struct{}{}
+	for _, moves := range [][]Move{p.Moves, p.Added, p.Removed} {
+		for _, m := range moves {
+			if m.From != "" {
+				idx[m.From] = struct{}{}
+			}
+			if m.To != "" {
+				idx[m.To] = struct{}{}
+			}
+		}
+	}
+
+	res := make([]string, 0, len(idx))
+	for w := range idx {
+		res = append(res, w)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// String summarizes plan
+func (p *Plan) String() string {
+	return fmt.Sprintf("version %d to %d total jobs %d moved %d (%.2f%%) added %d removed %d unchanged %d",
+		p.FromVersion, p.ToVersion, p.Stats.TotalJobs, p.Stats.Moved, p.Stats.MovedPercentage, p.Stats.Added, p.Stats.Removed, p.Stats.Unchanged)
+}
+
+// String describes job transition
+func (m Move) String() string {
+	from, to := m.From, m.To
+	if from == "" {
+		from = "none"
+	}
+	if to == "" {
+		to = "none"
+	}
+
+	return fmt.Sprintf("%s: %s -> %s", m.Job, from, to)
+}
+
+// owners indexes job owner worker, on duplicated jobs first worker in name order wins
+func owners(a *Workloads) map[Job]string {
+	res := map[Job]string{}
+	if a == nil {
+		return res
+	}
+
+	for _, worker := range a.Workers() {
+		w := a.Workloads[worker]
+		if w == nil {
+			continue
+		}
+		for _, job := range w.Jobs {
+			if _, ok := res[job]; !ok {
+				res[job] = worker
+			}
+		}
+	}
+
+	return res
+}
+
+func sortedJobs(idx map[Job]string) []Job {
+	res := make([]Job, 0, len(idx))
+	for job := range idx {
+		res = append(res, job)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+
+	return res
+}
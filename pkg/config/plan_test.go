@@ -0,0 +1,94 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestNewPlan_ItDetectsMovedAddedAndRemovedJobs(t *testing.T) {
+	previous := &Workloads{
+		Version: 1,
+		Workloads: map[string]*Workload{
+			"foo-0": {Jobs: []Job{"a", "b"}},
+			"foo-1": {Jobs: []Job{"c", "d"}},
+		},
+	}
+	current := &Workloads{
+		Version: 2,
+		Workloads: map[string]*Workload{
+			"foo-0": {Jobs: []Job{"a"}},
+			"foo-1": {Jobs: []Job{"b", "c"}},
+			"foo-2": {Jobs: []Job{"e"}},
+		},
+	}
+
+	p := NewPlan(previous, current)
+
+	if expected, got := 1, len(p.Moves); expected != got {
+		t.Fatalf("total moves do not match, expected %d got %d", expected, got)
+	}
+	if expected, got := (Move{Job: "b", From: "foo-0", To: "foo-1"}), p.Moves[0]; expected != got {
+		t.Errorf("move does not match, expected %v got %v", expected, got)
+	}
+	if expected, got := 1, len(p.Added); expected != got {
+		t.Fatalf("total added do not match, expected %d got %d", expected, got)
+	}
+	if expected, got := (Move{Job: "e", To: "foo-2"}), p.Added[0]; expected != got {
+		t.Errorf("added does not match, expected %v got %v", expected, got)
+	}
+	if expected, got := 1, len(p.Removed); expected != got {
+		t.Fatalf("total removed do not match, expected %d got %d", expected, got)
+	}
+	if expected, got := (Move{Job: "d", From: "foo-1"}), p.Removed[0]; expected != got {
+		t.Errorf("removed does not match, expected %v got %v", expected, got)
+	}
+
+	if expected, got := 2, p.Stats.Unchanged; expected != got {
+		t.Errorf("unchanged does not match, expected %d got %d", expected, got)
+	}
+	if expected, got := 25.0, p.Stats.MovedPercentage; expected != got {
+		t.Errorf("moved percentage does not match, expected %f got %f", expected, got)
+	}
+
+	workers := p.AffectedWorkers()
+	if expected, got := 3, len(workers); expected != got {
+		t.Fatalf("affected workers do not match, expected %d got %d", expected, got)
+	}
+	if expected, got := "foo-0", workers[0]; expected != got {
+		t.Errorf("affected worker does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestNewPlan_ItIsEmptyOnEqualWorkloads(t *testing.T) {
+	w := &Workloads{
+		Version:   1,
+		Workloads: map[string]*Workload{"foo-0": {Jobs: []Job{"a", "b"}}},
+	}
+
+	p := NewPlan(w, w)
+	if !p.IsEmpty() {
+		t.Errorf("expected empty plan, got %s", p)
+	}
+	if expected, got := 0, len(p.AffectedWorkers()); expected != got {
+		t.Errorf("affected workers do not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestNewPlan_ItHandlesNilWorkloads(t *testing.T) {
+	w := &Workloads{
+		Version:   3,
+		Workloads: map[string]*Workload{"foo-0": {Jobs: []Job{"a", "b"}}},
+	}
+
+	p := NewPlan(nil, w)
+	if expected, got := 2, p.Stats.Added; expected != got {
+		t.Errorf("added does not match, expected %d got %d", expected, got)
+	}
+	if expected, got := 0.0, p.Stats.MovedPercentage; expected != got {
+		t.Errorf("moved percentage does not match, expected %f got %f", expected, got)
+	}
+
+	p = NewPlan(w, nil)
+	if expected, got := 2, p.Stats.Removed; expected != got {
+		t.Errorf("removed does not match, expected %d got %d", expected, got)
+	}
+}
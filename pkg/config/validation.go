@@ -0,0 +1,161 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrDuplicatedJob     = errors.New("job assigned to multiple workers")
+	ErrEmptyWorkerName   = errors.New("empty worker name")
+	ErrUnknownWorker     = errors.New("unknown worker")
+	ErrMissingWorker     = errors.New("missing worker")
+	ErrVersionRegression = errors.New("version regression")
+)
+
+// ValidationError describes a concrete workloads violation
+type ValidationError struct {
+	Reason error
+	Detail string
+}
+
+// Error describes violation
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Reason, e.Detail)
+}
+
+// Unwrap returns violation reason
+func (e *ValidationError) Unwrap() error {
+	return e.Reason
+}
+
+// ValidationErrors aggregates all violations found on workloads
+type ValidationErrors []*ValidationError
+
+// Error describes all violations
+func (v ValidationErrors) Error() string {
+	res := make([]string, 0, len(v))
+	for _, e := range v {
+		res = append(res, e.Error())
+	}
+
+	return strings.Join(res, ", ")
+}
+
+// Has checks if reason is part of the violations
+func (v ValidationErrors) Has(reason error) bool {
+	for _, e := range v {
+		if errors.Is(e, reason) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks workloads semantic consistency, when workers are defined all of them
+// must be present and no other worker is allowed
+func (a *Workloads) Validate(workers []string) error {
+	var res ValidationErrors
+	owners := map[Job]string{}
+	for _, worker := range a.Workers() {
+		if worker == "" {
+			res = append(res, &ValidationError{Reason: ErrEmptyWorkerName, Detail: "workload without worker name"})
+		}
+
+		w := a.Workloads[worker]
+		if w == nil {
+			continue
+		}
+
+		for _, job := range w.Jobs {
+			owner, ok := owners[job]
+			if ok && owner != worker {
+				res = append(res, &ValidationError{Reason: ErrDuplicatedJob, Detail: fmt.Sprintf("job %s on workers %s and %s", job, owner, worker)})
+				continue
+			}
+			owners[job] = worker
+		}
+	}
+
+	if len(workers) > 0 {
+		expected := map[string]struct{}{}
+		for _, worker := range workers {
+			expected[worker] = struct{}{}
+			if _, ok := a.Workloads[worker]; !ok {
+				res = append(res, &ValidationError{Reason: ErrMissingWorker, Detail: fmt.Sprintf("worker %s", worker)})
+			}
+		}
+
+		for _, worker := range a.Workers() {
+			if _, ok := expected[worker]; !ok {
+				res = append(res, &ValidationError{Reason: ErrUnknownWorker, Detail: fmt.Sprintf("worker %s", worker)})
+			}
+		}
+	}
+
+	if len(res) > 0 {
+		return res
+	}
+
+	return nil
+}
+
+// ValidateUpdate checks workloads consistency and version progression from previous workloads
+func (a *Workloads) ValidateUpdate(previous *Workloads, workers []string) error {
+	var res ValidationErrors
+	if err := a.Validate(workers); err != nil {
+		res = append(res, err.(ValidationErrors)...)
+	}
+
+	if previous != nil && a.Version < previous.Version {
+		res = append(res, &ValidationError{Reason: ErrVersionRegression, Detail: fmt.Sprintf("from %d to %d", previous.Version, a.Version)})
+	}
+
+	if len(res) > 0 {
+		return res
+	}
+
+	return nil
+}
+
+// Workers returns sorted worker names
+func (a *Workloads) Workers() []string {
+	res := make([]string, 0, len(a.Workloads))
+	for worker := range a.Workloads {
+		res = append(res, worker)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// Normalize returns workloads canonical copy, jobs get sorted and deduplicated, nil workloads become empty
+func (a *Workloads) Normalize() *Workloads {
+	res := &Workloads{Version: a.Version, Workloads: make(map[string]*Workload, len(a.Workloads))}
+	for worker, w := range a.Workloads {
+		res.Workloads[worker] = w.Normalize()
+	}
+
+	return res
+}
+
+// Normalize returns workload canonical copy with sorted and deduplicated jobs
+func (a *Workload) Normalize() *Workload {
+	res := &Workload{Jobs: []Job{}}
+	if a == nil {
+		return res
+	}
+	res.Weight = a.Weight
+
+	for k := range toMap(a.Jobs) {
+		res.Jobs = append(res.Jobs, Job(k))
+	}
+	sort.Slice(res.Jobs, func(i, j int) bool {
+		return res.Jobs[i] < res.Jobs[j]
+	})
+
+	return res
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestWorkloads_ValidateDetectsDuplicatedJobsAcrossWorkers(t *testing.T) {
+	w := &Workloads{
+		Version: 1,
+		Workloads: map[string]*Workload{
+			"foo-0": {Jobs: []Job{"foo", "bar"}},
+			"foo-1": {Jobs: []Job{"bar", "zoom"}},
+		},
+	}
+
+	err := w.Validate(nil)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	if !err.(ValidationErrors).Has(ErrDuplicatedJob) {
+		t.Errorf("expected duplicated job violation, got %v", err)
+	}
+}
+
+func TestWorkloads_ValidateDetectsEmptyUnknownAndMissingWorkers(t *testing.T) {
+	w := &Workloads{
+		Version: 1,
+		Workloads: map[string]*Workload{
+			"":      {Jobs: []Job{"foo"}},
+			"foo-0": {Jobs: []Job{"bar"}},
+			"foo-9": {Jobs: []Job{"zoom"}},
+		},
+	}
+
+	err := w.Validate([]string{"foo-0", "foo-1"})
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	verr := err.(ValidationErrors)
+	for _, reason := range []error{ErrEmptyWorkerName, ErrUnknownWorker, ErrMissingWorker} {
+		if !verr.Has(reason) {
+			t.Errorf("expected violation %v, got %v", reason, err)
+		}
+	}
+}
+
+func TestWorkloads_ValidateUpdateDetectsVersionRegression(t *testing.T) {
+	previous := &Workloads{Version: 3}
+	w := &Workloads{Version: 2, Workloads: map[string]*Workload{"foo-0": {Jobs: []Job{"foo"}}}}
+
+	err := w.ValidateUpdate(previous, nil)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+
+	if !err.(ValidationErrors).Has(ErrVersionRegression) {
+		t.Errorf("expected version regression violation, got %v", err)
+	}
+
+	if err := previous.ValidateUpdate(w, nil); err != nil {
+		t.Errorf("unexpected validation error %v", err)
+	}
+}
+
+func TestWorkloads_NormalizeSortsAndDeduplicatesJobs(t *testing.T) {
+	w := &Workloads{
+		Version: 1,
+		Workloads: map[string]*Workload{
+			"foo-1": {Jobs: []Job{"zoom", "bar", "zoom"}},
+			"foo-0": nil,
+		},
+	}
+
+	n := w.Normalize()
+	if expected, got := []string{"foo-0", "foo-1"}, n.Workers(); expected[0] != got[0] || expected[1] != got[1] {
+		t.Fatalf("workers do not match, expected %v got %v", expected, got)
+	}
+
+	jobs := n.Workloads["foo-1"].Jobs
+	if expected, got := 2, len(jobs); expected != got {
+		t.Fatalf("jobs size does not match, expected %d got %d", expected, got)
+	}
+	if jobs[0] != "bar" || jobs[1] != "zoom" {
+		t.Errorf("unexpected job order, got %v", jobs)
+	}
+	if n.Workloads["foo-0"] == nil {
+		t.Error("expected empty workload on nil entries")
+	}
+}
+
+func TestWorkloads_EqualsIsSymmetric(t *testing.T) {
+	w0 := &Workloads{
+		Version:   1,
+		Workloads: map[string]*Workload{"foo-0": {Jobs: []Job{"foo", "bar"}}},
+	}
+	w1 := &Workloads{
+		Version: 1,
+		Workloads: map[string]*Workload{
+			"foo-0": {Jobs: []Job{"bar", "foo"}},
+			"foo-1": {Jobs: []Job{"zoom"}},
+		},
+	}
+
+	if w0.Equals(w1) || w1.Equals(w0) {
+		t.Fatal("expected different workloads")
+	}
+
+	delete(w1.Workloads, "foo-1")
+	if !w0.Equals(w1) || !w1.Equals(w0) {
+		t.Fatal("expected equal workloads")
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// InspectFunc returns json serializable introspection info
+type InspectFunc func() interface{}
+
+// Admin handles runtime introspection of registered controllers
+type Admin struct {
+	sources map[string]InspectFunc
+	mutex   sync.RWMutex
+}
+
+// NewAdmin builds admin introspection handler
+func NewAdmin() *Admin {
+	return &Admin{
+		sources: map[string]InspectFunc{},
+	}
+}
+
+// Add registers named introspection source
+func (a *Admin) Add(name string, f InspectFunc) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.sources[name] = f
+}
+
+// list replies registered source names
+func (a *Admin) list(w http.ResponseWriter, r *http.Request) {
+	a.mutex.RLock()
+	names := make([]string, 0, len(a.sources))
+	for name := range a.sources {
+		names = append(names, name)
+	}
+	a.mutex.RUnlock()
+	sort.Strings(names)
+
+	a.reply(w, map[string]interface{}{"controllers": names})
+}
+
+// all replies all sources introspection info
+func (a *Admin) all(w http.ResponseWriter, r *http.Request) {
+	a.mutex.RLock()
+	res := make(map[string]interface{}, len(a.sources))
+	for name, f := range a.sources {
+		res[name] = f()
+	}
+	a.mutex.RUnlock()
+
+	a.reply(w, res)
+}
+
+// get replies single source introspection info
+func (a *Admin) get(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	a.mutex.RLock()
+	f, ok := a.sources[name]
+	a.mutex.RUnlock()
+	if !ok {
+		http.Error(w, "controller not found", http.StatusNotFound)
+		return
+	}
+
+	a.reply(w, f())
+}
+
+func (a *Admin) reply(w http.ResponseWriter, res interface{}) {
+	w.Header().Set(ContentType, JSONContentType)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Errorf("Unexpected error Marshalling admin info, error %v", err)
+	}
+}
+
+// Routes defines router endpoints
+func (a *Admin) Routes(r *mux.Router) {
+	r.HandleFunc(`/internal/admin/controllers`, a.list).Methods(http.MethodGet)
+	r.HandleFunc(`/internal/admin/controllers/_all`, a.all).Methods(http.MethodGet)
+	r.HandleFunc(`/internal/admin/controllers/{name}`, a.get).Methods(http.MethodGet)
+}
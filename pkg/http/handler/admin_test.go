@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdmin_ItRepliesRegisteredControllerInfo(t *testing.T) {
+	a := NewAdmin()
+	a.Add("swarm", func() interface{} {
+		return map[string]interface{}{"selectors": map[string]string{"swarm/swarm-worker": "app=swarm-worker"}}
+	})
+	router := mux.NewRouter()
+	a.Routes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/admin/controllers/swarm", nil))
+	if expected, got := http.StatusOK, rec.Code; expected != got {
+		t.Fatalf("status code does not match, expected %d got %d", expected, got)
+	}
+
+	res := map[string]map[string]string{}
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("unexpected error decoding reply %v", err)
+	}
+	if expected, got := "app=swarm-worker", res["selectors"]["swarm/swarm-worker"]; expected != got {
+		t.Errorf("selector does not match, expected %s got %s", expected, got)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/admin/controllers", nil))
+	names := map[string][]string{}
+	if err := json.NewDecoder(rec.Body).Decode(&names); err != nil {
+		t.Fatalf("unexpected error decoding reply %v", err)
+	}
+	if expected, got := 1, len(names["controllers"]); expected != got {
+		t.Errorf("total controllers do not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestAdmin_ItRepliesNotFoundOnUnknownController(t *testing.T) {
+	router := mux.NewRouter()
+	NewAdmin().Routes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/admin/controllers/foo", nil))
+	if expected, got := http.StatusNotFound, rec.Code; expected != got {
+		t.Fatalf("status code does not match, expected %d got %d", expected, got)
+	}
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator"
+	"k8s.io/client-go/tools/cache"
+	"sort"
+	"time"
+)
+
+// RunnerStatus defines runner liveness source
+type RunnerStatus interface {
+	Status() operator.Status
+}
+
+// CRDChecker defines CRD established condition source
+type CRDChecker interface {
+	IsAccepted(ctx context.Context, resourceName string) (bool, error)
+}
+
+// InformersSyncedCheck fails until all named informers have synced
+func InformersSyncedCheck(informers map[string]cache.InformerSynced) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		details := map[string]interface{}{}
+		var pending []string
+		for name, synced := range informers {
+			ok := synced()
+			details[name] = ok
+			if !ok {
+				pending = append(pending, name)
+			}
+		}
+		if len(pending) > 0 {
+			sort.Strings(pending)
+			return details, fmt.Errorf("informers %v not synced", pending)
+		}
+
+		return details, nil
+	}
+}
+
+// RunnerHeartbeatCheck fails when runner has pending or in progress entries and its heartbeat is older than timeout,
+// idle runners are always healthy
+func RunnerHeartbeatCheck(r RunnerStatus, timeout time.Duration) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		st := r.Status()
+		age := time.Since(st.Heartbeat)
+		details := map[string]interface{}{
+			"running":    st.Running,
+			"processing": st.Processing,
+			"heartbeat":  st.Heartbeat,
+			"queueDepth": st.QueueDepth,
+		}
+		if !st.Running || (!st.Processing && st.QueueDepth == 0) {
+			return details, nil
+		}
+		if age > timeout {
+			return details, fmt.Errorf("runner heartbeat %s older than %s", age.Round(time.Millisecond), timeout)
+		}
+
+		return details, nil
+	}
+}
+
+// QueueDepthCheck fails when runner queue depth exceeds max
+func QueueDepthCheck(r RunnerStatus, max int) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		st := r.Status()
+		details := map[string]interface{}{"queueDepth": st.QueueDepth, "max": max}
+		if st.QueueDepth > max {
+			return details, fmt.Errorf("queue depth %d exceeds max %d", st.QueueDepth, max)
+		}
+
+		return details, nil
+	}
+}
+
+// LeaderCheck fails while the process does not hold leadership, keeping standby replicas out of service
+func LeaderCheck(identity string, isLeader func() bool) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		leader := isLeader()
+		details := map[string]interface{}{"identity": identity, "leader": leader}
+		if !leader {
+			return details, errors.New("not leader")
+		}
+
+		return details, nil
+	}
+}
+
+// CRDEstablishedCheck fails until CRD gets established
+func CRDEstablishedCheck(c CRDChecker, name string) CheckFunc {
+	return func(ctx context.Context) (map[string]interface{}, error) {
+		details := map[string]interface{}{"crd": name}
+		ok, err := c.IsAccepted(ctx, name)
+		if err != nil {
+			return details, fmt.Errorf("unable to check crd %s, error %v", name, err)
+		}
+		details["established"] = ok
+		if !ok {
+			return details, fmt.Errorf("crd %s not established", name)
+		}
+
+		return details, nil
+	}
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultCheckTimeout = 2 * time.Second
+
+const (
+	// StatusOK reports all checks passing
+	StatusOK = "ok"
+	// StatusFailure reports at least one failed check
+	StatusFailure = "failure"
+)
+
+// CheckFunc reports component health, details get included on the reply, returned error marks check as failed
+type CheckFunc func(ctx context.Context) (details map[string]interface{}, err error)
+
+// CheckResult defines single check reply
+type CheckResult struct {
+	Healthy bool                   `json:"healthy"`
+	Error   string                 `json:"error,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthReport defines liveness and readiness reply
+type HealthReport struct {
+	Status  string                 `json:"status"`
+	Version string                 `json:"version"`
+	Date    string                 `json:"date"`
+	Checks  map[string]CheckResult `json:"checks"`
+}
+
+// Health registers liveness and readiness checks, liveness failures mean the process is wedged and
+// must be restarted, readiness failures take it out of service until they pass again
+type Health struct {
+	version   string
+	date      string
+	timeout   time.Duration
+	liveness  map[string]CheckFunc
+	readiness map[string]CheckFunc
+	mutex     sync.RWMutex
+}
+
+// NewHealth builds health check registry
+func NewHealth(commitVersion, date string) *Health {
+	return &Health{
+		version:   commitVersion,
+		date:      date,
+		timeout:   defaultCheckTimeout,
+		liveness:  map[string]CheckFunc{},
+		readiness: map[string]CheckFunc{},
+	}
+}
+
+// AddLiveness registers liveness check
+func (h *Health) AddLiveness(name string, c CheckFunc) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.liveness[name] = c
+}
+
+// AddReadiness registers readiness check
+func (h *Health) AddReadiness(name string, c CheckFunc) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.readiness[name] = c
+}
+
+// Live runs liveness checks
+func (h *Health) Live(ctx context.Context) *HealthReport {
+	return h.run(ctx, h.checks(h.liveness))
+}
+
+// Ready runs readiness checks
+func (h *Health) Ready(ctx context.Context) *HealthReport {
+	return h.run(ctx, h.checks(h.readiness))
+}
+
+func (h *Health) checks(idx map[string]CheckFunc) map[string]CheckFunc {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	res := make(map[string]CheckFunc, len(idx))
+	for name, c := range idx {
+		res[name] = c
+	}
+
+	return res
+}
+
+func (h *Health) run(ctx context.Context, checks map[string]CheckFunc) *HealthReport {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rep := &HealthReport{Status: StatusOK, Version: h.version, Date: h.date, Checks: map[string]CheckResult{}}
+	for _, name := range names {
+		cctx, cancel := context.WithTimeout(ctx, h.timeout)
+		details, err := checks[name](cctx)
+		cancel()
+
+		res := CheckResult{Healthy: err == nil, Details: details}
+		if err != nil {
+			res.Error = err.Error()
+			rep.Status = StatusFailure
+			log.Warnf("health check %s failed, error %v", name, err)
+		}
+		rep.Checks[name] = res
+	}
+
+	return rep
+}
+
+func (h *Health) liveHandler(w http.ResponseWriter, r *http.Request) {
+	h.reply(w, h.Live(r.Context()))
+}
+
+func (h *Health) readyHandler(w http.ResponseWriter, r *http.Request) {
+	h.reply(w, h.Ready(r.Context()))
+}
+
+func (h *Health) reply(w http.ResponseWriter, rep *HealthReport) {
+	w.Header().Set(ContentType, JSONContentType)
+	if rep.Status != StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(rep); err != nil {
+		log.Errorf("Unexpected error Marshalling health report, error %v", err)
+	}
+}
+
+// Routes defines router endpoints
+func (h *Health) Routes(r *mux.Router) {
+	r.HandleFunc(`/internal/live`, h.liveHandler).Methods(http.MethodGet)
+	r.HandleFunc(`/internal/ready`, h.readyHandler).Methods(http.MethodGet)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator"
+	"k8s.io/client-go/tools/cache"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealth_ItRepliesReadinessChecksDetails(t *testing.T) {
+	h := NewHealth("fooCommit", "fooDate")
+	h.AddReadiness("informers", InformersSyncedCheck(map[string]cache.InformerSynced{
+		"pods":  func() bool { return true },
+		"swarm": func() bool { return false },
+	}))
+	h.AddReadiness("queue", QueueDepthCheck(&fakeRunnerStatus{status: operator.Status{QueueDepth: 1}}, 10))
+	router := mux.NewRouter()
+	h.Routes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/ready", nil))
+
+	if expected, got := http.StatusServiceUnavailable, rec.Code; expected != got {
+		t.Fatalf("status code does not match, expected %d got %d", expected, got)
+	}
+
+	rep := &HealthReport{}
+	if err := json.NewDecoder(rec.Body).Decode(rep); err != nil {
+		t.Fatalf("unexpected error decoding report %v", err)
+	}
+	if expected, got := StatusFailure, rep.Status; expected != got {
+		t.Errorf("status does not match, expected %s got %s", expected, got)
+	}
+	if rep.Checks["informers"].Healthy {
+		t.Error("expected failed informers check")
+	}
+	if expected, got := false, rep.Checks["informers"].Details["swarm"]; expected != got {
+		t.Errorf("informer detail does not match, expected %v got %v", expected, got)
+	}
+	if !rep.Checks["queue"].Healthy {
+		t.Errorf("expected healthy queue check, got %s", rep.Checks["queue"].Error)
+	}
+}
+
+func TestHealth_ItRepliesLivenessWithSuccessWithoutChecks(t *testing.T) {
+	router := mux.NewRouter()
+	NewHealth("fooCommit", "fooDate").Routes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/live", nil))
+
+	if expected, got := http.StatusOK, rec.Code; expected != got {
+		t.Fatalf("status code does not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestRunnerHeartbeatCheck_ItFailsOnStaleBusyRunner(t *testing.T) {
+	stale := time.Now().Add(-time.Minute)
+	r := &fakeRunnerStatus{status: operator.Status{Running: true, Heartbeat: stale}}
+	c := RunnerHeartbeatCheck(r, time.Second)
+
+	if _, err := c(context.Background()); err != nil {
+		t.Fatalf("unexpected error on idle runner %v", err)
+	}
+
+	r.status.QueueDepth = 3
+	if _, err := c(context.Background()); err == nil {
+		t.Fatal("expected error on stale runner with pending entries")
+	}
+
+	r.status.Heartbeat = time.Now()
+	if _, err := c(context.Background()); err != nil {
+		t.Fatalf("unexpected error on fresh heartbeat %v", err)
+	}
+}
+
+func TestCRDEstablishedCheck_ItFailsUntilCRDGetsEstablished(t *testing.T) {
+	c := &fakeCRDChecker{}
+	check := CRDEstablishedCheck(c, "swarms.k8slab.info")
+
+	if _, err := check(context.Background()); err == nil {
+		t.Fatal("expected error on not established crd")
+	}
+
+	c.err = errors.New("foo error")
+	if _, err := check(context.Background()); err == nil {
+		t.Fatal("expected error on crd check error")
+	}
+
+	c.err, c.accepted = nil, true
+	if _, err := check(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+type fakeRunnerStatus struct {
+	status operator.Status
+}
+
+func (f *fakeRunnerStatus) Status() operator.Status {
+	return f.status
+}
+
+type fakeCRDChecker struct {
+	accepted bool
+	err      error
+}
+
+func (f *fakeCRDChecker) IsAccepted(ctx context.Context, resourceName string) (bool, error) {
+	return f.accepted, f.err
+}
@@ -1,3 +1,100 @@
 package handler
 
-// @TODO: Add basic coverage
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChecker_ItRepliesVersionAndDate(t *testing.T) {
+	router := mux.NewRouter()
+	NewChecker("fooCommit", "fooDate").Routes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/health", nil))
+
+	if expected, got := http.StatusOK, rec.Code; expected != got {
+		t.Fatalf("status code does not match, expected %d got %d", expected, got)
+	}
+	res := map[string]string{}
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("unexpected error decoding reply %v", err)
+	}
+	if expected, got := "fooCommit", res["version"]; expected != got {
+		t.Errorf("version does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := "fooDate", res["date"]; expected != got {
+		t.Errorf("date does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestHealth_ItRepliesUnavailableOnFailedLivenessCheck(t *testing.T) {
+	h := NewHealth("fooCommit", "fooDate")
+	h.AddLiveness("runner", func(ctx context.Context) (map[string]interface{}, error) {
+		return nil, errors.New("runner stalled")
+	})
+	h.AddReadiness("ready", func(ctx context.Context) (map[string]interface{}, error) {
+		return nil, nil
+	})
+	router := mux.NewRouter()
+	h.Routes(router)
+
+	live := request(t, router, "/internal/live", http.StatusServiceUnavailable)
+	if expected, got := "runner stalled", live.Checks["runner"].Error; expected != got {
+		t.Errorf("check error does not match, expected %s got %s", expected, got)
+	}
+	if _, ok := live.Checks["ready"]; ok {
+		t.Error("unexpected readiness check on liveness reply")
+	}
+
+	ready := request(t, router, "/internal/ready", http.StatusOK)
+	if expected, got := StatusOK, ready.Status; expected != got {
+		t.Errorf("status does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestHealth_ItRepliesReadinessFromLeaderCheck(t *testing.T) {
+	leader := false
+	h := NewHealth("fooCommit", "fooDate")
+	h.AddReadiness("leader", LeaderCheck("foo-0", func() bool { return leader }))
+	router := mux.NewRouter()
+	h.Routes(router)
+
+	rep := request(t, router, "/internal/ready", http.StatusServiceUnavailable)
+	if expected, got := "not leader", rep.Checks["leader"].Error; expected != got {
+		t.Errorf("check error does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := "foo-0", rep.Checks["leader"].Details["identity"]; expected != got {
+		t.Errorf("identity does not match, expected %s got %v", expected, got)
+	}
+
+	leader = true
+	rep = request(t, router, "/internal/ready", http.StatusOK)
+	if expected, got := true, rep.Checks["leader"].Details["leader"]; expected != got {
+		t.Errorf("leader detail does not match, expected %t got %v", expected, got)
+	}
+	if expected, got := "fooCommit", rep.Version; expected != got {
+		t.Errorf("version does not match, expected %s got %s", expected, got)
+	}
+}
+
+func request(t *testing.T, router *mux.Router, path string, code int) *HealthReport {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if expected, got := code, rec.Code; expected != got {
+		t.Fatalf("%s status code does not match, expected %d got %d", path, expected, got)
+	}
+
+	rep := &HealthReport{}
+	if err := json.NewDecoder(rec.Body).Decode(rep); err != nil {
+		t.Fatalf("unexpected error decoding report %v", err)
+	}
+
+	return rep
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+)
+
+// LogLevelRequest updates component level, empty component targets global level and empty level removes component override
+type LogLevelRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+}
+
+// LogLevel handles runtime log level introspection and updates
+type LogLevel struct {
+	updates bool
+}
+
+// NewLogLevel builds log level handler, updates are opt-in as routes are served without auth
+func NewLogLevel(updates bool) *LogLevel {
+	return &LogLevel{
+		updates: updates,
+	}
+}
+
+// getLevels replies global and overridden component levels
+func (a *LogLevel) getLevels(w http.ResponseWriter, r *http.Request) {
+	a.reply(w, http.StatusOK)
+}
+
+// setLevel updates global or component level
+func (a *LogLevel) setLevel(w http.ResponseWriter, r *http.Request) {
+	req := &LogLevelRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Level == "" {
+		if req.Component == "" || req.Component == logger.Global {
+			http.Error(w, "global level can not be removed", http.StatusBadRequest)
+			return
+		}
+		logger.ResetLevel(req.Component)
+		log.Infof("Log level override removed from component %s", req.Component)
+		a.reply(w, http.StatusOK)
+		return
+	}
+
+	level, err := log.ParseLevel(req.Level)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.SetLevel(req.Component, level)
+	log.Infof("Log level updated to %s on component %s", level, req.Component)
+	a.reply(w, http.StatusOK)
+}
+
+func (a *LogLevel) reply(w http.ResponseWriter, status int) {
+	w.Header().Set(ContentType, JSONContentType)
+	w.WriteHeader(status)
+	res := map[string]interface{}{"levels": logger.Levels(), "components": logger.ComponentNames()}
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Errorf("Unexpected error Marshalling log levels, error %v", err)
+	}
+}
+
+// Routes defines router endpoints, level updates only when enabled
+func (a *LogLevel) Routes(r *mux.Router) {
+	r.HandleFunc(`/internal/log/level`, a.getLevels).Methods(http.MethodGet)
+	if !a.updates {
+		return
+	}
+
+	log.Warn("log level updates enabled")
+	r.HandleFunc(`/internal/log/level`, a.setLevel).Methods(http.MethodPut)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLogLevel_ItUpdatesComponentLevels(t *testing.T) {
+	defer logger.ApplyLevels(nil)
+	router := mux.NewRouter()
+	NewLogLevel(true).Routes(router)
+
+	body, _ := json.Marshal(&LogLevelRequest{Component: logger.Runner, Level: "debug"})
+	req := httptest.NewRequest(http.MethodPut, "/internal/log/level", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if expected, got := http.StatusOK, rec.Code; expected != got {
+		t.Fatalf("status code does not match, expected %d got %d", expected, got)
+	}
+	if expected, got := log.DebugLevel.String(), logger.Levels()[logger.Runner]; expected != got {
+		t.Errorf("level does not match, expected %s got %s", expected, got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/internal/log/level", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	res := map[string]interface{}{}
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("unexpected error decoding response, error %v", err)
+	}
+	levels := res["levels"].(map[string]interface{})
+	if expected, got := "debug", levels[logger.Runner]; expected != got {
+		t.Errorf("level does not match, expected %s got %v", expected, got)
+	}
+}
+
+func TestLogLevel_ItRejectsInvalidLevels(t *testing.T) {
+	router := mux.NewRouter()
+	NewLogLevel(true).Routes(router)
+
+	for _, r := range []*LogLevelRequest{{Level: "foo"}, {Component: logger.Global}} {
+		body, _ := json.Marshal(r)
+		req := httptest.NewRequest(http.MethodPut, "/internal/log/level", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if expected, got := http.StatusBadRequest, rec.Code; expected != got {
+			t.Errorf("status code does not match, expected %d got %d", expected, got)
+		}
+	}
+}
+
+func TestLogLevel_ItDoesNotServeUpdatesUnlessEnabled(t *testing.T) {
+	defer logger.ApplyLevels(nil)
+	router := mux.NewRouter()
+	NewLogLevel(false).Routes(router)
+
+	body, _ := json.Marshal(&LogLevelRequest{Component: logger.Runner, Level: "debug"})
+	req := httptest.NewRequest(http.MethodPut, "/internal/log/level", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if expected, got := http.StatusMethodNotAllowed, rec.Code; expected != got {
+		t.Fatalf("status code does not match, expected %d got %d", expected, got)
+	}
+	if _, ok := logger.Levels()[logger.Runner]; ok {
+		t.Error("unexpected runner level override")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/internal/log/level", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if expected, got := http.StatusOK, rec.Code; expected != got {
+		t.Errorf("status code does not match, expected %d got %d", expected, got)
+	}
+}
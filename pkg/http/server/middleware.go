@@ -0,0 +1,129 @@
+package server
+
+import (
+	"github.com/gorilla/mux"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// RequestIDHeader carries request id, incoming ones are kept
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDField identifies request on log entries
+const RequestIDField = "requestId"
+
+const unmatchedRoute = "unmatched"
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total http requests by route, method and status code",
+	}, []string{"route", "method", "code"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Http request latencies by route and method",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+)
+
+func init() {
+	prometheus.MustRegister(requestsTotal, requestDuration)
+}
+
+// RequestID ensures request id header, request context gets a logger carrying it
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = logger.NewCorrelationID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		l := logger.FromContext(r.Context()).WithField(RequestIDField, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithLogger(r.Context(), l)))
+	})
+}
+
+// Logging logs each request with its status code and duration, internal probes and metrics get logged on debug
+func Logging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		e := logger.FromContext(r.Context()).WithFields(log.Fields{
+			"method":   r.Method,
+			"path":     r.URL.Path,
+			"status":   rec.status,
+			"duration": time.Since(start).String(),
+		})
+		if r.URL.Path == MetricsPath || r.URL.Path == "/internal/live" || r.URL.Path == "/internal/ready" {
+			e.Debug("http request")
+			return
+		}
+		e.Info("http request")
+	})
+}
+
+// Recovery replies internal server error on handler panics
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				logger.FromContext(r.Context()).WithField("stack", string(debug.Stack())).Errorf("panic handling %s %s, %v", r.Method, r.URL.Path, p)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Metrics records requests count and latencies by route template, it must be used as router middleware
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := newStatusRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		route := unmatchedRoute
+		if cr := mux.CurrentRoute(r); cr != nil {
+			if tpl, err := cr.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		requestsTotal.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Inc()
+		requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// WriteHeader records replied status code
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps streaming handlers working
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
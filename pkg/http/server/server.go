@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+const (
+	defaultReadTimeout     = 10 * time.Second
+	defaultWriteTimeout    = 10 * time.Second
+	defaultShutdownTimeout = 10 * time.Second
+
+	// pprofWriteTimeout covers profile and trace default 30s duration, pprof rejects longer ?seconds= values
+	pprofWriteTimeout = 60 * time.Second
+)
+
+// MetricsPath exposes prometheus metrics
+const MetricsPath = "/metrics"
+
+// Config defines http server config, TLS gets enabled when cert and key files are defined
+type Config struct {
+	Port            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration
+	TLSCert         string
+	TLSKey          string
+	Pprof           bool
+}
+
+// DefaultConfig returns plain http server config on port
+func DefaultConfig(port string) Config {
+	return Config{
+		Port:            port,
+		ReadTimeout:     defaultReadTimeout,
+		WriteTimeout:    defaultWriteTimeout,
+		ShutdownTimeout: defaultShutdownTimeout,
+	}
+}
+
+// Server wraps http server lifecycle, all routes get request id, logging, panic recovery and metrics middlewares
+type Server struct {
+	config Config
+	srv    *http.Server
+	certs  *certReloader
+}
+
+// New builds server on top of router, metrics and optional pprof routes get registered on it
+func New(c Config, r *mux.Router) (*Server, error) {
+	if c.ShutdownTimeout <= 0 {
+		c.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	r.Use(Metrics)
+	r.Handle(MetricsPath, promhttp.Handler()).Methods(http.MethodGet)
+	if c.Pprof {
+		log.Warn("pprof routes enabled")
+		Pprof(r)
+		if c.WriteTimeout > 0 && c.WriteTimeout < pprofWriteTimeout {
+			log.Warnf("write timeout raised from %s to %s to serve pprof profiles", c.WriteTimeout, pprofWriteTimeout)
+			c.WriteTimeout = pprofWriteTimeout
+		}
+	}
+
+	s := &Server{
+		config: c,
+		srv: &http.Server{
+			Addr:         fmt.Sprintf(":%s", c.Port),
+			Handler:      RequestID(Logging(Recovery(r))),
+			ReadTimeout:  c.ReadTimeout,
+			WriteTimeout: c.WriteTimeout,
+		},
+	}
+
+	if c.TLSCert == "" && c.TLSKey == "" {
+		return s, nil
+	}
+	if c.TLSCert == "" || c.TLSKey == "" {
+		return nil, errors.New("tls requires both cert and key files")
+	}
+
+	cr, err := newCertReloader(c.TLSCert, c.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	s.certs = cr
+	s.srv.TLSConfig = &tls.Config{GetCertificate: cr.GetCertificate, MinVersion: tls.VersionTLS12}
+
+	return s, nil
+}
+
+// Run serves until context gets cancelled, then shutdowns gracefully waiting in flight requests up to shutdown timeout
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("unable to listen on %s, error %v", s.srv.Addr, err)
+	}
+
+	return s.serve(ctx, ln)
+}
+
+func (s *Server) serve(ctx context.Context, ln net.Listener) error {
+	errCh := make(chan error, 1)
+	go func() {
+		log.Infof("starting server on %s tls %t", ln.Addr(), s.certs != nil)
+		var err error
+		if s.certs != nil {
+			err = s.srv.ServeTLS(ln, "", "")
+		} else {
+			err = s.srv.Serve(ln)
+		}
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil && err != http.ErrServerClosed {
+			return fmt.Errorf("unable to serve, error %v", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	return s.Shutdown()
+}
+
+// Shutdown stops accepting connections and waits in flight requests up to shutdown timeout
+func (s *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	log.Infof("shutting down server, timeout %s", s.config.ShutdownTimeout)
+	if err := s.srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("unable to shutdown server gracefully, error %v", err)
+	}
+
+	return nil
+}
+
+// Pprof registers pprof routes
+func Pprof(r *mux.Router) {
+	r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	r.PathPrefix("/debug/pprof/").HandlerFunc(pprof.Index)
+}
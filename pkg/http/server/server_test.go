@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServer_ItRecoversFromHandlerPanicsWithRequestID(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/foo", func(w http.ResponseWriter, r *http.Request) {
+		panic("foo panic")
+	})
+	s, err := New(DefaultConfig("0"), r)
+	if err != nil {
+		t.Fatalf("unexpected error building server %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/foo", nil))
+
+	if expected, got := http.StatusInternalServerError, rec.Code; expected != got {
+		t.Fatalf("status code does not match, expected %d got %d", expected, got)
+	}
+	if rec.Header().Get(RequestIDHeader) == "" {
+		t.Error("expected request id header")
+	}
+}
+
+func TestServer_ItRecordsMetricsByRouteTemplate(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/bar/{name}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+	s, err := New(DefaultConfig("0"), r)
+	if err != nil {
+		t.Fatalf("unexpected error building server %v", err)
+	}
+
+	for _, name := range []string{"a", "b"} {
+		s.srv.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/bar/"+name, nil))
+	}
+
+	if expected, got := 2.0, testutil.ToFloat64(requestsTotal.WithLabelValues("/bar/{name}", http.MethodPost, "202")); expected != got {
+		t.Errorf("total requests do not match, expected %f got %f", expected, got)
+	}
+}
+
+func TestServer_ItRaisesWriteTimeoutToServePprofProfiles(t *testing.T) {
+	c := DefaultConfig("0")
+	c.Pprof = true
+	s, err := New(c, mux.NewRouter())
+	if err != nil {
+		t.Fatalf("unexpected error building server %v", err)
+	}
+
+	if expected, got := pprofWriteTimeout, s.srv.WriteTimeout; expected != got {
+		t.Errorf("write timeout does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestServer_ItWaitsInFlightRequestsOnShutdown(t *testing.T) {
+	started := make(chan struct{})
+	r := mux.NewRouter()
+	r.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(time.Millisecond * 200)
+		_, _ = w.Write([]byte("done"))
+	})
+	s, err := New(DefaultConfig("0"), r)
+	if err != nil {
+		t.Fatalf("unexpected error building server %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.serve(ctx, ln) }()
+
+	res := make(chan int, 1)
+	go func() {
+		rsp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			res <- 0
+			return
+		}
+		_ = rsp.Body.Close()
+		res <- rsp.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	if expected, got := http.StatusOK, <-res; expected != got {
+		t.Fatalf("in flight request status does not match, expected %d got %d", expected, got)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error on shutdown %v", err)
+	}
+}
+
+func TestCertReloader_ItReloadsRotatedCertificates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatalf("unable to create temp dir %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCert(t, certFile, keyFile, "foo")
+	c, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("unexpected error loading certs %v", err)
+	}
+	if expected, got := "foo", commonName(t, c); expected != got {
+		t.Fatalf("common name does not match, expected %s got %s", expected, got)
+	}
+
+	writeCert(t, certFile, keyFile, "bar")
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+	if expected, got := "bar", commonName(t, c); expected != got {
+		t.Fatalf("common name does not match, expected %s got %s", expected, got)
+	}
+}
+
+func commonName(t *testing.T, c *certReloader) string {
+	cert, err := c.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("unexpected error getting certificate %v", err)
+	}
+	x, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("unable to parse certificate %v", err)
+	}
+
+	return x.Subject.CommonName
+}
+
+func writeCert(t *testing.T, certFile, keyFile, cn string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key %v", err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unable to create certificate %v", err)
+	}
+	rawKey, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unable to marshall key %v", err)
+	}
+
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("unable to write cert %v", err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: rawKey}), 0600); err != nil {
+		t.Fatalf("unable to write key %v", err)
+	}
+}
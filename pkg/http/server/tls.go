@@ -0,0 +1,88 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloader serves TLS certificate, cert and key files get reloaded on modification so that
+// rotated certificates (as mounted secrets) apply without restart
+type certReloader struct {
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modTime  time.Time
+	mutex    sync.Mutex
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// GetCertificate returns current certificate, reloading it if files changed, previous one is kept on reload errors
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	mt, err := c.lastModification()
+	if err != nil {
+		log.Errorf("unable to check tls files, error %v", err)
+		return c.cert, nil
+	}
+	if mt.After(c.modTime) {
+		if err := c.load(mt); err != nil {
+			log.Errorf("unable to reload tls certificate, error %v", err)
+		}
+	}
+
+	return c.cert, nil
+}
+
+func (c *certReloader) reload() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	mt, err := c.lastModification()
+	if err != nil {
+		return err
+	}
+
+	return c.load(mt)
+}
+
+func (c *certReloader) load(mt time.Time) error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load key pair, error %v", err)
+	}
+
+	c.cert = &cert
+	c.modTime = mt
+	log.Infof("tls certificate loaded from %s", c.certFile)
+
+	return nil
+}
+
+func (c *certReloader) lastModification() (time.Time, error) {
+	var last time.Time
+	for _, f := range []string{c.certFile, c.keyFile} {
+		st, err := os.Stat(f)
+		if err != nil {
+			return last, fmt.Errorf("unable to stat %s, error %v", f, err)
+		}
+		if st.ModTime().After(last) {
+			last = st.ModTime()
+		}
+	}
+
+	return last, nil
+}
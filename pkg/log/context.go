@@ -0,0 +1,60 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	CorrelationIDField = "correlationId"
+	KeyField           = "key"
+	ActionField        = "action"
+	AttemptField       = "attempt"
+)
+
+type loggerKey struct{}
+
+// WithLogger returns context carrying logger entry
+func WithLogger(ctx context.Context, e *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey{}, e)
+}
+
+// FromContext returns context logger entry, global logger one when context has none
+func FromContext(ctx context.Context) *logrus.Entry {
+	if e, ok := ctx.Value(loggerKey{}).(*logrus.Entry); ok {
+		return e
+	}
+
+	return logrus.NewEntry(logrus.StandardLogger())
+}
+
+// ComponentFromContext returns component logger entry with context logger fields, component level applies
+func ComponentFromContext(ctx context.Context, name string) *logrus.Entry {
+	e, ok := ctx.Value(loggerKey{}).(*logrus.Entry)
+	if !ok {
+		return Component(name)
+	}
+
+	f := logrus.Fields{}
+	for k, v := range e.Data {
+		if k == componentField {
+			continue
+		}
+		f[k] = v
+	}
+
+	return Component(name).WithFields(f)
+}
+
+// NewCorrelationID generates random event correlation id
+func NewCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(b)
+}
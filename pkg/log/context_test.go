@@ -0,0 +1,37 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestFromContext_ItReturnsContextLoggerFields(t *testing.T) {
+	e := Component(Runner).WithFields(logrus.Fields{CorrelationIDField: "foo", KeyField: "swarm/bar"})
+	ctx := WithLogger(context.Background(), e)
+
+	if expected, got := "foo", FromContext(ctx).Data[CorrelationIDField]; expected != got {
+		t.Errorf("correlation id does not match, expected %s got %v", expected, got)
+	}
+
+	c := ComponentFromContext(ctx, Swarm)
+	if expected, got := "foo", c.Data[CorrelationIDField]; expected != got {
+		t.Errorf("correlation id does not match, expected %s got %v", expected, got)
+	}
+	if expected, got := Swarm, c.Data[componentField]; expected != got {
+		t.Errorf("component does not match, expected %s got %v", expected, got)
+	}
+}
+
+func TestFromContext_ItFallbacksToGlobalLogger(t *testing.T) {
+	if e := FromContext(context.Background()); e == nil || len(e.Data) != 0 {
+		t.Errorf("unexpected context logger %v", e)
+	}
+}
+
+func TestNewCorrelationID_ItGeneratesUniqueIDs(t *testing.T) {
+	if NewCorrelationID() == NewCorrelationID() {
+		t.Error("expected different correlation ids")
+	}
+}
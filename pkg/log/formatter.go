@@ -1,17 +1,51 @@
 package log
 
 import (
-	"bytes"
 	"fmt"
+	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	TextFormat   = "text"
+	JSONFormat   = "json"
+	LogfmtFormat = "logfmt"
+)
+
+const timestampFormat = "2006-01-02T15:04:05.999999"
+
+// NewFormatter builds log formatter from format name
+func NewFormatter(format string) (logrus.Formatter, error) {
+	switch strings.ToLower(format) {
+	case JSONFormat, "":
+		return PrettifiedFormatter(), nil
+	case TextFormat:
+		return &logrus.TextFormatter{
+			FullTimestamp:    true,
+			TimestampFormat:  timestampFormat,
+			CallerPrettyfier: callerPrettyfier,
+		}, nil
+	case LogfmtFormat:
+		// strict logfmt parsers get all values quoted and colors never show up, even on terminals
+		return &logrus.TextFormatter{
+			DisableColors:    true,
+			ForceQuote:       true,
+			FullTimestamp:    true,
+			TimestampFormat:  timestampFormat,
+			CallerPrettyfier: callerPrettyfier,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported log format %s", format)
+}
+
 // PrettifiedFormatter populates mandatory log fields
 func PrettifiedFormatter() logrus.Formatter {
 	return &logrus.JSONFormatter{
-		TimestampFormat: "2006-01-02T15:04:05.999999",
+		TimestampFormat: timestampFormat,
 		FieldMap: logrus.FieldMap{
 			logrus.FieldKeyTime:  "@timestamp",
 			logrus.FieldKeyLevel: "@level",
@@ -19,19 +53,17 @@ func PrettifiedFormatter() logrus.Formatter {
 			logrus.FieldKeyFunc:  "@caller",
 			logrus.FieldKeyFile:  "@file",
 		},
-		CallerPrettyfier: func(frame *runtime.Frame) (function string, file string) {
-			pc, file, line, _ := runtime.Caller(9)
-			name := runtime.FuncForPC(pc).Name()
-			if i := bytes.LastIndexAny([]byte(name), "."); i != -1 {
-				name = name[i+1:]
-			}
-
-			if i := bytes.LastIndexAny([]byte(file), "/"); i != -1 {
-				file = file[i+1:]
-			}
-
-			return name, fmt.Sprintf("%s:%d", file, line)
-		},
-		PrettyPrint: false,
+		CallerPrettyfier: callerPrettyfier,
+		PrettyPrint:      false,
+	}
+}
+
+// callerPrettyfier shortens caller frame resolved by logrus, function without package path and file base name
+func callerPrettyfier(frame *runtime.Frame) (function string, file string) {
+	function = frame.Function
+	if i := strings.LastIndex(function, "."); i != -1 {
+		function = function[i+1:]
 	}
+
+	return function, fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line)
 }
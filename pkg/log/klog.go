@@ -0,0 +1,159 @@
+package log
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/sirupsen/logrus"
+	"k8s.io/klog/v2"
+)
+
+// Klog component gets all klog and client-go output
+const Klog = "klog"
+
+// maxVerbosity lets all klog verbosity levels through on trace level
+const maxVerbosity = 10
+
+// klogFlags holds installed bridge klog flags, klog verbosity follows klog component level once installed.
+// Guarded by components registry lock
+var klogFlags *flag.FlagSet
+
+// InstallKlogBridge redirects klog output, including client-go one, to klog component logger, klog verbosity
+// gets derived from klog component level so that client-go skips disabled verbose logging
+func InstallKlogBridge() error {
+	fs := flag.NewFlagSet(Klog, flag.ContinueOnError)
+	klog.InitFlags(fs)
+
+	e := Component(Klog)
+
+	components.mutex.Lock()
+	klogFlags = fs
+	err := setKlogVerbosity(e.Logger.GetLevel())
+	components.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	klog.SetLogger(logr.New(newLogrusSink(e)))
+
+	return nil
+}
+
+// setKlogVerbosity updates klog verbosity from klog component level, must be called with registry lock held
+func setKlogVerbosity(level logrus.Level) error {
+	if klogFlags == nil {
+		return nil
+	}
+
+	if err := klogFlags.Set("v", strconv.Itoa(LevelVerbosity(level))); err != nil {
+		return fmt.Errorf("unable to set klog verbosity, error %v", err)
+	}
+
+	return nil
+}
+
+// logrusSink implements logr sink in top of logrus entry
+type logrusSink struct {
+	entry *logrus.Entry
+	name  string
+	depth int
+}
+
+func newLogrusSink(e *logrus.Entry) *logrusSink {
+	return &logrusSink{entry: e}
+}
+
+// Init receives logr runtime info
+func (s *logrusSink) Init(info logr.RuntimeInfo) {
+	s.depth = info.CallDepth
+}
+
+// Enabled checks mapped verbosity level against logrus level
+func (s *logrusSink) Enabled(level int) bool {
+	return s.entry.Logger.IsLevelEnabled(VerbosityLevel(level))
+}
+
+// Info logs message on mapped verbosity level
+func (s *logrusSink) Info(level int, msg string, keysAndValues ...interface{}) {
+	s.fields(keysAndValues).Log(VerbosityLevel(level), strings.TrimSuffix(msg, "\n"))
+}
+
+// Error logs message on error level
+func (s *logrusSink) Error(err error, msg string, keysAndValues ...interface{}) {
+	e := s.fields(keysAndValues)
+	if err != nil {
+		e = e.WithError(err)
+	}
+	e.Error(strings.TrimSuffix(msg, "\n"))
+}
+
+// WithValues returns sink with added fields
+func (s *logrusSink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	return &logrusSink{entry: s.fields(keysAndValues), name: s.name, depth: s.depth}
+}
+
+// WithName returns sink with appended logger name
+func (s *logrusSink) WithName(name string) logr.LogSink {
+	if s.name != "" {
+		name = s.name + "." + name
+	}
+
+	return &logrusSink{entry: s.entry, name: name, depth: s.depth}
+}
+
+// WithCallDepth returns sink that skips additional call frames on source reporting
+func (s *logrusSink) WithCallDepth(depth int) logr.LogSink {
+	return &logrusSink{entry: s.entry, name: s.name, depth: s.depth + depth}
+}
+
+func (s *logrusSink) fields(keysAndValues []interface{}) *logrus.Entry {
+	f := logrus.Fields{}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var v interface{} = "(missing)"
+		if i+1 < len(keysAndValues) {
+			v = keysAndValues[i+1]
+		}
+		f[fmt.Sprint(keysAndValues[i])] = v
+	}
+	if s.name != "" {
+		f["logger"] = s.name
+	}
+
+	// skips this func and sink method, depth already accounts logr Logger frames
+	if _, file, line, ok := runtime.Caller(s.depth + 2); ok {
+		f["source"] = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	return s.entry.WithFields(f)
+}
+
+// VerbosityLevel maps klog verbosity to logrus level, 0-1 info, 2-3 debug and trace from there
+func VerbosityLevel(v int) logrus.Level {
+	switch {
+	case v <= 1:
+		return logrus.InfoLevel
+	case v <= 3:
+		return logrus.DebugLevel
+	}
+
+	return logrus.TraceLevel
+}
+
+// LevelVerbosity maps logrus level to the highest klog verbosity mapped on it, inverse of VerbosityLevel
+func LevelVerbosity(level logrus.Level) int {
+	switch {
+	case level >= logrus.TraceLevel:
+		return maxVerbosity
+	case level == logrus.DebugLevel:
+		return 3
+	case level == logrus.InfoLevel:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,106 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/klog/v2"
+)
+
+func TestInstallKlogBridge_ItRoutesKlogOutputWithGlobalFields(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logrus.SetOutput(buf)
+	defer logrus.SetOutput(os.Stderr)
+	Setup(logrus.InfoLevel, &logrus.JSONFormatter{}, NewGlobalFieldHook("foo", "test"))
+	defer Setup(logrus.InfoLevel, &logrus.TextFormatter{})
+
+	if err := InstallKlogBridge(); err != nil {
+		t.Fatalf("unexpected error installing bridge, error %v", err)
+	}
+
+	klog.InfoS("hello", "pod", "swarm-worker-0")
+	klog.V(4).Info("hidden")
+	klog.ErrorS(errors.New("foo error"), "failed")
+	klog.Flush()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if expected, got := 2, len(lines); expected != got {
+		t.Fatalf("total lines do not match, expected %d got %d: %s", expected, got, buf.String())
+	}
+
+	e := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil {
+		t.Fatalf("unexpected error decoding line, error %v", err)
+	}
+	for k, v := range map[string]string{"msg": "hello", "pod": "swarm-worker-0", "service": "foo", "env": "test", "component": Klog, "level": "info"} {
+		if got := e[k]; got != v {
+			t.Errorf("field %s does not match, expected %s got %v", k, v, got)
+		}
+	}
+	if !strings.HasPrefix(e["source"].(string), "klog_test.go") {
+		t.Errorf("unexpected source, got %v", e["source"])
+	}
+
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil {
+		t.Fatalf("unexpected error decoding line, error %v", err)
+	}
+	if expected, got := "error", e["level"]; expected != got {
+		t.Errorf("level does not match, expected %s got %v", expected, got)
+	}
+	if expected, got := "foo error", e["error"]; expected != got {
+		t.Errorf("error does not match, expected %s got %v", expected, got)
+	}
+}
+
+func TestVerbosityLevel_ItMapsKlogVerbosity(t *testing.T) {
+	for v, expected := range map[int]logrus.Level{0: logrus.InfoLevel, 2: logrus.DebugLevel, 5: logrus.TraceLevel} {
+		if got := VerbosityLevel(v); expected != got {
+			t.Errorf("level does not match on verbosity %d, expected %s got %s", v, expected, got)
+		}
+	}
+}
+
+func TestLevelVerbosity_ItInvertsVerbosityLevel(t *testing.T) {
+	for level, expected := range map[logrus.Level]int{logrus.WarnLevel: 0, logrus.InfoLevel: 1, logrus.DebugLevel: 3, logrus.TraceLevel: 10} {
+		got := LevelVerbosity(level)
+		if expected != got {
+			t.Errorf("verbosity does not match on level %s, expected %d got %d", level, expected, got)
+		}
+		if level >= logrus.InfoLevel && VerbosityLevel(got) != level {
+			t.Errorf("verbosity %d does not map back to level %s", got, level)
+		}
+	}
+}
+
+func TestInstallKlogBridge_ItDerivesKlogVerbosityFromKlogLevel(t *testing.T) {
+	Setup(logrus.InfoLevel, &logrus.TextFormatter{})
+	defer Setup(logrus.InfoLevel, &logrus.TextFormatter{})
+	defer ApplyLevels(nil)
+
+	if err := InstallKlogBridge(); err != nil {
+		t.Fatalf("unexpected error installing bridge, error %v", err)
+	}
+	if klog.V(2).Enabled() {
+		t.Error("unexpected klog verbosity 2 enabled on info level")
+	}
+
+	SetLevel(Klog, logrus.DebugLevel)
+	if !klog.V(3).Enabled() || klog.V(4).Enabled() {
+		t.Error("expected klog verbosity 3 on klog debug level")
+	}
+
+	SetLevel(Global, logrus.TraceLevel)
+	if klog.V(4).Enabled() {
+		t.Error("unexpected klog verbosity 4 enabled on overridden klog debug level")
+	}
+
+	ApplyLevels(map[string]logrus.Level{})
+	if !klog.V(10).Enabled() {
+		t.Error("expected all klog verbosity levels enabled following global trace level")
+	}
+}
@@ -0,0 +1,178 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	Global   = "global"
+	Runner   = "runner"
+	Swarm    = "swarm"
+	Informer = "informer"
+)
+
+const componentField = "component"
+
+var components = &registry{
+	loggers:   map[string]*logrus.Logger{},
+	overrides: map[string]logrus.Level{},
+}
+
+// registry keeps component loggers, all of them share global logger setup with its own level
+type registry struct {
+	loggers   map[string]*logrus.Logger
+	overrides map[string]logrus.Level
+	mutex     sync.RWMutex
+}
+
+// Component returns named logger, its level follows global level unless overridden
+func Component(name string) *logrus.Entry {
+	components.mutex.Lock()
+	defer components.mutex.Unlock()
+
+	l, ok := components.loggers[name]
+	if !ok {
+		l = logrus.New()
+		components.loggers[name] = l
+		components.sync(name, l)
+	}
+
+	return l.WithField(componentField, name)
+}
+
+// Setup configures global and component loggers
+func Setup(level logrus.Level, formatter logrus.Formatter, hooks ...logrus.Hook) {
+	std := logrus.StandardLogger()
+	std.SetLevel(level)
+	std.SetReportCaller(true)
+	std.SetFormatter(formatter)
+	std.ReplaceHooks(logrus.LevelHooks{})
+	for _, h := range hooks {
+		std.AddHook(h)
+	}
+
+	components.mutex.Lock()
+	defer components.mutex.Unlock()
+	for name, l := range components.loggers {
+		components.sync(name, l)
+	}
+}
+
+// SetLevel updates component level, global component updates global logger level and all non overridden components
+func SetLevel(component string, level logrus.Level) {
+	components.mutex.Lock()
+	defer components.mutex.Unlock()
+
+	if component == Global || component == "" {
+		logrus.SetLevel(level)
+	} else {
+		components.overrides[component] = level
+	}
+
+	for name, l := range components.loggers {
+		components.sync(name, l)
+	}
+}
+
+// ResetLevel removes component level override, it will follow global level again
+func ResetLevel(component string) {
+	components.mutex.Lock()
+	defer components.mutex.Unlock()
+
+	delete(components.overrides, component)
+	if l, ok := components.loggers[component]; ok {
+		components.sync(component, l)
+	}
+}
+
+// Levels returns global and overridden component levels
+func Levels() map[string]string {
+	components.mutex.RLock()
+	defer components.mutex.RUnlock()
+
+	res := map[string]string{Global: logrus.GetLevel().String()}
+	for name, level := range components.overrides {
+		res[name] = level.String()
+	}
+
+	return res
+}
+
+// ParseLevels parses component level overrides as comma separated component=level pairs
+func ParseLevels(s string) (map[string]logrus.Level, error) {
+	res := map[string]logrus.Level{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid component level %s", pair)
+		}
+
+		level, err := logrus.ParseLevel(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid component %s level, error %v", parts[0], err)
+		}
+		res[parts[0]] = level
+	}
+
+	return res, nil
+}
+
+// ApplyLevels replaces all component overrides
+func ApplyLevels(levels map[string]logrus.Level) {
+	components.mutex.Lock()
+	defer components.mutex.Unlock()
+
+	components.overrides = map[string]logrus.Level{}
+	for name, level := range levels {
+		components.overrides[name] = level
+	}
+
+	for name, l := range components.loggers {
+		components.sync(name, l)
+	}
+}
+
+// ComponentNames returns sorted registered component names
+func ComponentNames() []string {
+	components.mutex.RLock()
+	defer components.mutex.RUnlock()
+
+	res := make([]string, 0, len(components.loggers))
+	for name := range components.loggers {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// sync copies global logger setup to component logger, must be called with the lock held
+func (r *registry) sync(name string, l *logrus.Logger) {
+	std := logrus.StandardLogger()
+	l.SetOutput(std.Out)
+	l.SetFormatter(std.Formatter)
+	l.SetReportCaller(std.ReportCaller)
+	l.ReplaceHooks(std.Hooks)
+
+	level, ok := r.overrides[name]
+	if !ok {
+		level = std.GetLevel()
+	}
+	l.SetLevel(level)
+
+	if name == Klog {
+		if err := setKlogVerbosity(level); err != nil {
+			std.Errorf("unable to sync klog verbosity, error %v", err)
+		}
+	}
+}
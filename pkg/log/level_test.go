@@ -0,0 +1,100 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestComponent_ItFollowsGlobalLevelUnlessOverridden(t *testing.T) {
+	defer ApplyLevels(nil)
+	defer SetLevel(Global, logrus.GetLevel())
+
+	l := Component("foo")
+	SetLevel(Global, logrus.WarnLevel)
+	if expected, got := logrus.WarnLevel, l.Logger.GetLevel(); expected != got {
+		t.Errorf("level does not match, expected %s got %s", expected, got)
+	}
+
+	SetLevel("foo", logrus.DebugLevel)
+	SetLevel(Global, logrus.ErrorLevel)
+	if expected, got := logrus.DebugLevel, l.Logger.GetLevel(); expected != got {
+		t.Errorf("level does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := "debug", Levels()["foo"]; expected != got {
+		t.Errorf("level does not match, expected %s got %s", expected, got)
+	}
+
+	ResetLevel("foo")
+	if expected, got := logrus.ErrorLevel, l.Logger.GetLevel(); expected != got {
+		t.Errorf("level does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestParseLevels_ItParsesComponentPairs(t *testing.T) {
+	levels, err := ParseLevels("runner=debug, swarm=warn")
+	if err != nil {
+		t.Fatalf("unexpected error parsing levels, error %v", err)
+	}
+
+	if expected, got := logrus.DebugLevel, levels[Runner]; expected != got {
+		t.Errorf("level does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := logrus.WarnLevel, levels[Swarm]; expected != got {
+		t.Errorf("level does not match, expected %s got %s", expected, got)
+	}
+
+	for _, s := range []string{"runner", "runner=foo", "=debug"} {
+		if _, err := ParseLevels(s); err == nil {
+			t.Errorf("expected error parsing %s", s)
+		}
+	}
+}
+
+func TestNewFormatter_ItQuotesAllValuesOnlyOnLogfmt(t *testing.T) {
+	for format, expected := range map[string]string{TextFormat: "level=info msg=hello foo=bar", LogfmtFormat: `level="info" msg="hello" foo="bar"`} {
+		f, err := NewFormatter(format)
+		if err != nil {
+			t.Fatalf("unexpected error building formatter %s, error %v", format, err)
+		}
+
+		buf := &bytes.Buffer{}
+		l := logrus.New()
+		l.SetOutput(buf)
+		l.SetFormatter(f)
+		l.WithField("foo", "bar").Info("hello")
+
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("%s output does not match, expected %s got %s", format, expected, buf.String())
+		}
+	}
+}
+
+func TestNewFormatter_ItReportsRealCaller(t *testing.T) {
+	for _, format := range []string{JSONFormat, TextFormat, LogfmtFormat} {
+		f, err := NewFormatter(format)
+		if err != nil {
+			t.Fatalf("unexpected error building formatter %s, error %v", format, err)
+		}
+
+		buf := &bytes.Buffer{}
+		l := logrus.New()
+		l.SetOutput(buf)
+		l.SetFormatter(f)
+		l.SetReportCaller(true)
+		l.WithField("foo", "bar").Info("hello")
+
+		if !strings.Contains(buf.String(), "level_test.go") {
+			t.Errorf("expected caller file on %s output, got %s", format, buf.String())
+		}
+		if !strings.Contains(buf.String(), "TestNewFormatter_ItReportsRealCaller") {
+			t.Errorf("expected caller function on %s output, got %s", format, buf.String())
+		}
+	}
+
+	if _, err := NewFormatter("xml"); err == nil {
+		t.Error("expected error on unsupported format")
+	}
+}
@@ -0,0 +1,30 @@
+package log
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	KindField            = "kind"
+	NamespaceField       = "namespace"
+	NameField            = "name"
+	ResourceVersionField = "resourceVersion"
+)
+
+// ObjectFromContext returns context logger entry with k8s object kind and metadata fields, extra fields get added on top
+func ObjectFromContext(ctx context.Context, kind string, obj metav1.Object, extra logrus.Fields) *logrus.Entry {
+	f := logrus.Fields{
+		KindField:            kind,
+		NamespaceField:       obj.GetNamespace(),
+		NameField:            obj.GetName(),
+		ResourceVersionField: obj.GetResourceVersion(),
+	}
+	for k, v := range extra {
+		f[k] = v
+	}
+
+	return FromContext(ctx).WithFields(f)
+}
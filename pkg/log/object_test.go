@@ -0,0 +1,28 @@
+package log
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestObjectFromContext_ItAddsObjectMetadataFields(t *testing.T) {
+	ctx := WithLogger(context.Background(), logrus.WithField(CorrelationIDField, "foo"))
+	obj := &metav1.ObjectMeta{Namespace: "swarm", Name: "bar", ResourceVersion: "7"}
+
+	e := ObjectFromContext(ctx, "ConfigMap", obj, logrus.Fields{"keys": 2})
+	expected := logrus.Fields{
+		CorrelationIDField:   "foo",
+		KindField:            "ConfigMap",
+		NamespaceField:       "swarm",
+		NameField:            "bar",
+		ResourceVersionField: "7",
+		"keys":               2,
+	}
+	if !reflect.DeepEqual(expected, e.Data) {
+		t.Errorf("fields do not match, expected %v got %v", expected, e.Data)
+	}
+}
@@ -0,0 +1,142 @@
+package configmap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"os"
+)
+
+// FileSync mirrors a configmap key on a local file, file only gets rewritten when its content changes,
+// so file watchers see one event per workload change
+type FileSync struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	key       string
+	worker    string
+	path      string
+}
+
+// NewFileSync instantiates configmap key file mirror, empty key defaults to config.yml
+func NewFileSync(cl kubernetes.Interface, namespace, name, key, path string) *FileSync {
+	if key == "" {
+		key = defaultConfigKey
+	}
+
+	return &FileSync{
+		client:    cl,
+		namespace: namespace,
+		name:      name,
+		key:       key,
+		path:      path,
+	}
+}
+
+// NewShardedFileSync instantiates sharded configmap file mirror, it follows index manifest to the shard
+// holding worker workload, index key content gets mirrored while index does not hold any manifest
+func NewShardedFileSync(cl kubernetes.Interface, namespace, index, worker, key, path string) *FileSync {
+	s := NewFileSync(cl, namespace, index, key, path)
+	s.worker = worker
+
+	return s
+}
+
+// Fetch writes current configmap key content on file
+func (s *FileSync) Fetch(ctx context.Context) error {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get config map %s error %v", s.name, err)
+	}
+
+	return s.apply(ctx, cm)
+}
+
+// Run keeps file in sync watching configmap until context gets cancelled, sharded mirrors only watch
+// the index as shards never change once referenced by its manifest
+func (s *FileSync) Run(ctx context.Context) {
+	f := informers.NewSharedInformerFactoryWithOptions(s.client, 0, informers.WithNamespace(s.namespace), informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+		o.FieldSelector = fields.OneTermEqualSelector("metadata.name", s.name).String()
+	}))
+	inf := f.Core().V1().ConfigMaps().Informer()
+	inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			s.sync(ctx, obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			s.sync(ctx, obj)
+		},
+	})
+
+	f.Start(ctx.Done())
+	<-ctx.Done()
+}
+
+func (s *FileSync) sync(ctx context.Context, obj interface{}) {
+	cm, ok := obj.(*v1.ConfigMap)
+	if !ok || cm.Name != s.name {
+		return
+	}
+
+	if err := s.apply(ctx, cm); err != nil {
+		log.Errorf("unable to sync config map %s file, error %v", s.name, err)
+	}
+}
+
+// apply resolves worker shard from index manifest on sharded mirrors, writing its content on file
+func (s *FileSync) apply(ctx context.Context, cm *v1.ConfigMap) error {
+	if s.worker == "" {
+		return s.write(cm)
+	}
+
+	m, ok, err := DecodeManifest(cm)
+	if err != nil {
+		return fmt.Errorf("unable to decode manifest from %s error %v", cm.Name, err)
+	}
+	if !ok {
+		return s.write(cm)
+	}
+
+	name, ok := m.ShardOf(s.worker)
+	if !ok {
+		return fmt.Errorf("worker %s not found on %s manifest version %d", s.worker, cm.Name, m.Version)
+	}
+
+	shard, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get shard config map %s error %v", name, err)
+	}
+
+	return s.write(shard)
+}
+
+// write replaces file atomically, renamed files get picked up by directory watchers
+func (s *FileSync) write(cm *v1.ConfigMap) error {
+	data, err := readKey(cm, s.key)
+	if err != nil {
+		return fmt.Errorf("config map %s error %v", cm.Name, err)
+	}
+
+	if current, err := ioutil.ReadFile(s.path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("unable to write file %s error %v", tmp, err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("unable to rename file %s error %v", tmp, err)
+	}
+
+	return nil
+}
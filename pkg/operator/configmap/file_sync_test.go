@@ -0,0 +1,110 @@
+package configmap
+
+import (
+	"context"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"io/ioutil"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSync_ItWritesConfigMapKeyOnlyOnChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-sync")
+	if err != nil {
+		t.Fatalf("unable to create temporary dir, error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cm := &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "swarm-worker-config-0", Namespace: namespace},
+		Data:       map[string]string{defaultConfigKey: "version: 1"},
+	}
+	clientset := fake.NewSimpleClientset(cm)
+	path := filepath.Join(dir, defaultConfigKey)
+	s := NewFileSync(clientset, namespace, cm.Name, "", path)
+
+	if err := s.Fetch(context.Background()); err != nil {
+		t.Fatalf("unexpected error fetching config map, error %v", err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error getting file info, error %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := s.Fetch(context.Background()); err != nil {
+		t.Fatalf("unexpected error fetching config map, error %v", err)
+	}
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error getting file info, error %v", err)
+	}
+	if !before.ModTime().Equal(after.ModTime()) {
+		t.Error("expected unchanged file not rewritten")
+	}
+
+	cm.Data[defaultConfigKey] = "version: 2"
+	if _, err := clientset.CoreV1().ConfigMaps(namespace).Update(context.Background(), cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("unexpected error updating config map, error %v", err)
+	}
+	if err := s.Fetch(context.Background()); err != nil {
+		t.Fatalf("unexpected error fetching config map, error %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error reading file, error %v", err)
+	}
+	if expected, got := "version: 2", string(data); expected != got {
+		t.Errorf("file content does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestFileSync_ItFollowsManifestToWorkerShard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-sync")
+	if err != nil {
+		t.Fatalf("unable to create temporary dir, error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	clientset := fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName))
+	p, err := NewShardedProvider(clientset, config.NewYAMLCodec(), "", true, ShardBySize, 256)
+	if err != nil {
+		t.Fatalf("unexpected error building provider, error %v", err)
+	}
+	w := getFakeWorkloads()
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	path := filepath.Join(dir, defaultConfigKey)
+	s := NewShardedFileSync(clientset, namespace, configMapName, "swarm-worker-2", "", path)
+	if err := s.Fetch(context.Background()); err != nil {
+		t.Fatalf("unexpected error fetching config map, error %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error reading file, error %v", err)
+	}
+	res, err := config.NewYAMLCodec().Decode(data)
+	if err != nil {
+		t.Fatalf("unexpected error decoding file, error %v", err)
+	}
+	if expected, got := w.Version, res.Version; expected != got {
+		t.Errorf("version does not match, expected %d got %d", expected, got)
+	}
+	if _, ok := res.Workloads["swarm-worker-2"]; !ok {
+		t.Errorf("expected worker workload on file, got %v", res.Workloads)
+	}
+
+	s = NewShardedFileSync(clientset, namespace, configMapName, "swarm-worker-9", "", path)
+	if err := s.Fetch(context.Background()); err == nil {
+		t.Error("expected error on worker without shard")
+	}
+}
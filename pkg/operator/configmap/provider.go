@@ -1,13 +1,13 @@
 package configmap
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
-	"github.com/mitchellh/mapstructure"
-	"gopkg.in/yaml.v3"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
 	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -17,40 +17,63 @@ const defaultConfigKey = "config.yml"
 // Provider implements config repository in top of configmap
 type Provider struct {
 	client kubernetes.Interface
+	codec  cfg.Codec
+	key    string
+	binary bool
 }
 
-// NewProvider instantiate configmap provider
+// NewProvider instantiate configmap provider, workloads are written as yaml on config.yml key
 func NewProvider(cl kubernetes.Interface) *Provider {
+	return NewCodecProvider(cl, cfg.NewYAMLCodec(), defaultConfigKey, false)
+}
+
+// NewCodecProvider instantiate configmap provider encoding workloads with codec on the configured key,
+// binary flag stores encoded workloads on BinaryData instead of Data
+func NewCodecProvider(cl kubernetes.Interface, c cfg.Codec, key string, binary bool) *Provider {
+	if key == "" {
+		key = defaultConfigKey
+	}
+
 	return &Provider{
 		client: cl,
+		codec:  c,
+		key:    key,
+		binary: binary,
 	}
 }
 
-// Set updates workload assignation to configmap
+// Set updates workload assignation to configmap, other keys and metadata remain untouched
 func (p *Provider) Set(ctx context.Context, namespace, name string, a *cfg.Workloads) error {
 	cm, err := p.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("unable to get config map %v", err)
 	}
-	var buffer bytes.Buffer
-	yamlEncoder := yaml.NewEncoder(&buffer)
-	yamlEncoder.SetIndent(2)
-	if err := yamlEncoder.Encode(a); err != nil {
+
+	data, err := p.codec.Encode(a)
+	if err != nil {
 		return fmt.Errorf("unable to Marshall config map, error %v", err)
 	}
 
-	cm.Data[defaultConfigKey] = buffer.String()
+	p.write(cm, data)
+	if size := configMapSize(cm); size > MaxConfigMapSize {
+		return fmt.Errorf("config map %s size %d exceeds max size %d", name, size, MaxConfigMapSize)
+	}
+
 	_, err = p.client.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{})
 	if err != nil {
 		return fmt.Errorf("unable to update config map %v", err)
 	}
 
+	logger.FromContext(ctx).Debugf("config map %s updated on namespace %s version %d", name, namespace, a.Version)
 	return nil
 }
 
 // Get returns workload assignation from configmap
 func (p *Provider) Get(ctx context.Context, namespace, name string) (*cfg.Workloads, error) {
 	cm, err := p.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		return nil, storage.ErrNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("unable to get config map %v", err)
 	}
@@ -58,20 +81,50 @@ func (p *Provider) Get(ctx context.Context, namespace, name string) (*cfg.Worklo
 	if err != nil {
 		return nil, fmt.Errorf("unable to decode workloads from config map %v", err)
 	}
-	context.Background()
+
 	return w, nil
 }
 
+// write places encoded data on the configured key, a key can only live in Data or BinaryData
+func (p *Provider) write(cm *v1.ConfigMap, data []byte) {
+	writeKey(cm, p.key, data, p.binary)
+}
+
 func (p *Provider) decode(cm *v1.ConfigMap) (*cfg.Workloads, error) {
-	data := make(map[interface{}]interface{})
-	if err := yaml.Unmarshal([]byte(cm.Data[defaultConfigKey]), &data); err != nil {
-		return nil, fmt.Errorf("unable to unmarshall, error %v", err)
+	data, err := readKey(cm, p.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.codec.Decode(data)
+}
+
+func writeKey(cm *v1.ConfigMap, key string, data []byte, binary bool) {
+	if binary {
+		if cm.BinaryData == nil {
+			cm.BinaryData = map[string][]byte{}
+		}
+		cm.BinaryData[key] = data
+		delete(cm.Data, key)
+		return
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[key] = string(data)
+	delete(cm.BinaryData, key)
+}
+
+func readKey(cm *v1.ConfigMap, key string) ([]byte, error) {
+	if data, ok := cm.BinaryData[key]; ok {
+		return data, nil
 	}
 
-	c := &cfg.Workloads{}
-	if err := mapstructure.Decode(data, c); err != nil {
-		return nil, fmt.Errorf("unable to decode, error %v", err)
+	v, ok := cm.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found", key)
 	}
 
-	return c, nil
+	return []byte(v), nil
 }
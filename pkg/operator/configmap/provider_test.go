@@ -2,61 +2,122 @@ package configmap
 
 import (
 	"context"
-	"github.com/davecgh/go-spew/spew"
 	"github.com/marcosQuesada/k8s-lab/pkg/config"
-	"github.com/marcosQuesada/k8s-lab/pkg/operator"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage/storagetest"
+	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 	"testing"
 )
 
-// @TODO: Use k8s client mock
+var namespace = "swarm"
+var configMapName = "swarm-worker-config"
+
 func TestNewProvider_ItUpdatesConfigMapOnAssignWorkload(t *testing.T) {
-	t.Skip()
-	var namespace = "swarm"
-	var configMapName = "swarm-worker-config"
-	//var deploymentName = "swarm-worker"
+	clientset := fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName))
+	p := NewProvider(clientset)
+	w := getFakeWorkloads()
+
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
 
-	clientset := operator.BuildExternalClient()
 	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), configMapName, metav1.GetOptions{})
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("unexpected error getting configmap, error %v", err)
 	}
-	spew.Dump(cm.Data)
 
-	p := NewProvider(clientset, namespace, configMapName)
-	w := &config.Workloads{
-		Version: 1,
-		Workloads: map[string]*config.Workload{
-			"swarm-worker-0": {Jobs: []config.Job{"rtve1", "cctv1", "euktv", "ccmeg01"}},
-			"swarm-worker-1": {Jobs: []config.Job{"zoom0", "zrtve1", "zcctv1", "zeuktv"}},
-			"swarm-worker-2": {Jobs: []config.Job{"xfoo", "xrtve1", "xcctv1", "xeuktv"}},
-		},
+	if _, ok := cm.Data[defaultConfigKey]; !ok {
+		t.Fatalf("expected key %s on configmap data", defaultConfigKey)
 	}
+}
 
-	if err := p.Set(context.Background(), w); err != nil {
+func TestNewProvider_ItGetsWorkloadsFromConfigMap(t *testing.T) {
+	clientset := fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName))
+	p := NewProvider(clientset)
+	w := getFakeWorkloads()
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
 		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
 	}
+
+	res, err := p.Get(context.Background(), namespace, configMapName)
+	if err != nil {
+		t.Fatalf("unexepcted error getting workload, got %v", err)
+	}
+
+	if !w.Equals(res) {
+		t.Errorf("workloads do not match, expected %v got %v", w, res)
+	}
 }
 
-// @TODO: Use k8s client mock
-func TestNewProvider_ItGetsWorkloadsFromConfigMap(t *testing.T) {
-	t.Skip()
-	var namespace = "swarm"
-	var configMapName = "swarm-worker-config"
-	//var deploymentName = "swarm-worker"
+func TestNewCodecProvider_ItWritesBinaryDataPreservingUserKeysAndMetadata(t *testing.T) {
+	key := "assignations.json"
+	cm := getFakeConfigMap(namespace, configMapName)
+	cm.Data[key] = "stale"
+	clientset := fake.NewSimpleClientset(cm)
+	p := NewCodecProvider(clientset, config.NewJSONCodec(), key, true)
+	w := getFakeWorkloads()
 
-	clientset := operator.BuildExternalClient()
-	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), configMapName, metav1.GetOptions{})
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	res, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), configMapName, metav1.GetOptions{})
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("unexpected error getting configmap, error %v", err)
+	}
+
+	if _, ok := res.BinaryData[key]; !ok {
+		t.Fatalf("expected key %s on configmap binary data", key)
+	}
+	if _, ok := res.Data[key]; ok {
+		t.Errorf("unexpected key %s on configmap data", key)
+	}
+	if expected, got := "bar", res.Data["foo"]; expected != got {
+		t.Errorf("user key does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := "swarm-worker", res.Labels["app"]; expected != got {
+		t.Errorf("label does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := "owner", res.Annotations["k8slab.info/note"]; expected != got {
+		t.Errorf("annotation does not match, expected %s got %s", expected, got)
 	}
-	spew.Dump(cm.Data)
 
-	p := NewProvider(clientset, namespace, configMapName)
-	w, err := p.Get(context.Background())
+	wl, err := p.Get(context.Background(), namespace, configMapName)
 	if err != nil {
-		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+		t.Fatalf("unexepcted error getting workload, got %v", err)
 	}
 
-	spew.Dump(w)
+	if !w.Equals(wl) {
+		t.Errorf("workloads do not match, expected %v got %v", w, wl)
+	}
+}
+
+func getFakeWorkloads() *config.Workloads {
+	return &config.Workloads{
+		Version: 1,
+		Workloads: map[string]*config.Workload{
+			"swarm-worker-0": {Jobs: []config.Job{"rtve1", "cctv1", "euktv", "ccmeg01"}},
+			"swarm-worker-1": {Jobs: []config.Job{"zoom0", "zrtve1", "zcctv1", "zeuktv"}},
+			"swarm-worker-2": {Jobs: []config.Job{"xfoo", "xrtve1", "xcctv1", "xeuktv"}},
+		},
+	}
+}
+
+func getFakeConfigMap(namespace, name string) *apiv1.ConfigMap {
+	return &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Labels:      map[string]string{"app": "swarm-worker"},
+			Annotations: map[string]string{"k8slab.info/note": "owner"},
+		},
+		Data: map[string]string{"foo": "bar"},
+	}
+}
+
+func TestProvider_Conformance(t *testing.T) {
+	p := NewProvider(fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName)))
+
+	storagetest.Run(t, p, namespace, configMapName)
 }
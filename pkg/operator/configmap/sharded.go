@@ -0,0 +1,383 @@
+package configmap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
+	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"sort"
+	"strconv"
+)
+
+// MaxConfigMapSize defines k8s configmap size limit
+const MaxConfigMapSize = 1024 * 1024
+
+// shardEntryOverhead covers separators each worker entry adds once encoded along others on a shard
+const shardEntryOverhead = 8
+
+// ManifestKey defines index configmap key where shard manifest is stored
+const ManifestKey = "manifest.json"
+
+// ShardLabel marks shard configmaps with its index configmap name
+const ShardLabel = "k8slab.info/shard-of"
+
+type ShardMode string
+
+const (
+	ShardByWorker = ShardMode("worker")
+	ShardBySize   = ShardMode("size")
+)
+
+// Manifest ties together all shards from a workload assignation
+type Manifest struct {
+	Version int64   `json:"version"`
+	Shards  []Shard `json:"shards"`
+}
+
+// Shard defines configmap name and its assigned workers
+type Shard struct {
+	Name    string   `json:"name"`
+	Workers []string `json:"workers"`
+}
+
+// ShardedProvider splits workload assignations between multiple configmaps, index configmap
+// keeps the manifest that allows reassembling them. Shard names carry the assignation version,
+// so shards never change once referenced and updating the manifest switches all of them at once
+type ShardedProvider struct {
+	client  kubernetes.Interface
+	codec   cfg.Codec
+	key     string
+	binary  bool
+	mode    ShardMode
+	maxSize int
+}
+
+// NewShardedProvider instantiates sharded configmap provider, maxSize bounds each configmap size,
+// binary flag stores encoded shards on BinaryData instead of Data
+func NewShardedProvider(cl kubernetes.Interface, c cfg.Codec, key string, binary bool, mode ShardMode, maxSize int) (*ShardedProvider, error) {
+	if mode != ShardByWorker && mode != ShardBySize {
+		return nil, fmt.Errorf("unsupported shard mode %s", mode)
+	}
+	if key == "" {
+		key = defaultConfigKey
+	}
+	if maxSize <= 0 || maxSize > MaxConfigMapSize {
+		maxSize = MaxConfigMapSize
+	}
+
+	return &ShardedProvider{
+		client:  cl,
+		codec:   c,
+		key:     key,
+		binary:  binary,
+		mode:    mode,
+		maxSize: maxSize,
+	}, nil
+}
+
+// Set splits workloads in shards, writes them and updates index manifest, stale shards get removed
+// once the manifest no longer references them
+func (p *ShardedProvider) Set(ctx context.Context, namespace, name string, a *cfg.Workloads) error {
+	index, err := p.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get index config map %v", err)
+	}
+
+	shards, err := p.split(name, a)
+	if err != nil {
+		return fmt.Errorf("unable to split workloads, error %v", err)
+	}
+
+	m := &Manifest{Version: a.Version}
+	for _, s := range shards {
+		if err := p.writeShard(ctx, namespace, name, s.name, s.data); err != nil {
+			return err
+		}
+		m.Shards = append(m.Shards, Shard{Name: s.name, Workers: s.workers})
+	}
+
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("unable to marshall manifest, error %v", err)
+	}
+	if index.Data == nil {
+		index.Data = map[string]string{}
+	}
+	index.Data[ManifestKey] = string(raw)
+	if size := configMapSize(index); size > p.maxSize {
+		return fmt.Errorf("index config map %s size %d exceeds max size %d", name, size, p.maxSize)
+	}
+
+	if _, err := p.client.CoreV1().ConfigMaps(namespace).Update(ctx, index, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update index config map %v", err)
+	}
+
+	p.removeStale(ctx, namespace, name, m)
+	logger.FromContext(ctx).Debugf("config map %s updated on namespace %s version %d total shards %d", name, namespace, a.Version, len(m.Shards))
+
+	return nil
+}
+
+// Get reassembles workloads from index manifest shards
+func (p *ShardedProvider) Get(ctx context.Context, namespace, name string) (*cfg.Workloads, error) {
+	index, err := p.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get index config map %v", err)
+	}
+
+	m, err := p.manifest(index)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode manifest, error %v", err)
+	}
+
+	res := &cfg.Workloads{Version: m.Version, Workloads: map[string]*cfg.Workload{}}
+	for _, s := range m.Shards {
+		cm, err := p.client.CoreV1().ConfigMaps(namespace).Get(ctx, s.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get shard config map %s error %v", s.Name, err)
+		}
+
+		v, err := readKey(cm, p.key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read shard %s error %v", s.Name, err)
+		}
+
+		w, err := p.codec.Decode(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode shard %s error %v", s.Name, err)
+		}
+
+		if w.Version != m.Version {
+			return nil, fmt.Errorf("shard %s version %d does not match manifest version %d", s.Name, w.Version, m.Version)
+		}
+
+		for worker, wl := range w.Workloads {
+			res.Workloads[worker] = wl
+		}
+	}
+
+	return res, nil
+}
+
+type shard struct {
+	name    string
+	workers []string
+	data    []byte
+}
+
+func (p *ShardedProvider) split(name string, a *cfg.Workloads) ([]shard, error) {
+	workers := make([]string, 0, len(a.Workloads))
+	for w := range a.Workloads {
+		workers = append(workers, w)
+	}
+	sort.Strings(workers)
+
+	if p.mode == ShardByWorker {
+		var res []shard
+		for _, w := range workers {
+			s, err := p.encodeShard(ShardName(name, a.Version, w), []string{w}, a)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, s)
+		}
+		return res, nil
+	}
+
+	// each worker gets encoded once to estimate shard sizes, real size gets checked closing each shard
+	env, err := p.codec.Encode(&cfg.Workloads{Version: a.Version, Workloads: map[string]*cfg.Workload{}})
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode shard envelope error %v", err)
+	}
+	base := len(ShardName(name, a.Version, strconv.Itoa(len(workers)))) + len(p.key) + len(env)
+	entries := make(map[string]int, len(workers))
+	for _, w := range workers {
+		data, err := p.codec.Encode(&cfg.Workloads{Version: a.Version, Workloads: map[string]*cfg.Workload{w: a.Workloads[w]}})
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode worker %s workload error %v", w, err)
+		}
+		entries[w] = len(data) - len(env) + shardEntryOverhead
+	}
+
+	var res []shard
+	pending := workers
+	for len(pending) > 0 {
+		var bucket []string
+		size := base
+		for len(pending) > 0 && (len(bucket) == 0 || size+entries[pending[0]] <= p.maxSize) {
+			size += entries[pending[0]]
+			bucket, pending = append(bucket, pending[0]), pending[1:]
+		}
+
+		s, carried, err := p.closeShard(ShardName(name, a.Version, strconv.Itoa(len(res))), bucket, a)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+		pending = append(carried, pending...)
+	}
+
+	return res, nil
+}
+
+// closeShard encodes shard from its bucket, trailing workers get carried to the next shard while the
+// estimated bucket exceeds max size
+func (p *ShardedProvider) closeShard(name string, bucket []string, a *cfg.Workloads) (shard, []string, error) {
+	var carried []string
+	for {
+		s, err := p.encodeShard(name, bucket, a)
+		if err == nil {
+			return s, carried, nil
+		}
+		if len(bucket) == 1 {
+			return shard{}, nil, err
+		}
+
+		carried = append([]string{bucket[len(bucket)-1]}, carried...)
+		bucket = bucket[:len(bucket)-1]
+	}
+}
+
+func (p *ShardedProvider) encodeShard(name string, workers []string, a *cfg.Workloads) (shard, error) {
+	w := &cfg.Workloads{Version: a.Version, Workloads: map[string]*cfg.Workload{}}
+	for _, worker := range workers {
+		w.Workloads[worker] = a.Workloads[worker]
+	}
+
+	data, err := p.codec.Encode(w)
+	if err != nil {
+		return shard{}, fmt.Errorf("unable to encode shard %s error %v", name, err)
+	}
+
+	if size := len(name) + len(p.key) + len(data); size > p.maxSize {
+		return shard{}, fmt.Errorf("shard %s size %d exceeds max size %d", name, size, p.maxSize)
+	}
+
+	return shard{name: name, workers: workers, data: data}, nil
+}
+
+func (p *ShardedProvider) writeShard(ctx context.Context, namespace, index, name string, data []byte) error {
+	cm, err := p.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{ShardLabel: index},
+			},
+		}
+		writeKey(cm, p.key, data, p.binary)
+		if _, err := p.client.CoreV1().ConfigMaps(namespace).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("unable to create shard config map %s error %v", name, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get shard config map %s error %v", name, err)
+	}
+
+	writeKey(cm, p.key, data, p.binary)
+	if size := configMapSize(cm); size > p.maxSize {
+		return fmt.Errorf("shard config map %s size %d exceeds max size %d", name, size, p.maxSize)
+	}
+
+	if _, err := p.client.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update shard config map %s error %v", name, err)
+	}
+
+	return nil
+}
+
+// removeStale deletes index shards not referenced by current manifest, including the ones left by failed updates
+func (p *ShardedProvider) removeStale(ctx context.Context, namespace, index string, current *Manifest) {
+	list, err := p.client.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{ShardLabel: index}).String(),
+	})
+	if err != nil {
+		logger.FromContext(ctx).Errorf("unable to list shard config maps of %s error %v", index, err)
+		return
+	}
+
+	active := map[string]struct{}{}
+	for _, s := range current.Shards {
+		active[s.Name] = struct{}{}
+	}
+
+	for _, s := range list.Items {
+		if _, ok := active[s.Name]; ok {
+			continue
+		}
+
+		err := p.client.CoreV1().ConfigMaps(namespace).Delete(ctx, s.Name, metav1.DeleteOptions{})
+		if err != nil && !apiErrors.IsNotFound(err) {
+			logger.FromContext(ctx).Errorf("unable to delete stale shard config map %s error %v", s.Name, err)
+		}
+	}
+}
+
+func (p *ShardedProvider) manifest(index *v1.ConfigMap) (*Manifest, error) {
+	m, ok, err := DecodeManifest(index)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &Manifest{}, nil
+	}
+
+	return m, nil
+}
+
+// ShardName builds shard configmap name from index name, assignation version and shard id
+func ShardName(index string, version int64, id string) string {
+	return fmt.Sprintf("%s-v%d-%s", index, version, id)
+}
+
+// DecodeManifest reads shard manifest from index configmap, reporting if index holds any
+func DecodeManifest(index *v1.ConfigMap) (*Manifest, bool, error) {
+	raw, ok := index.Data[ManifestKey]
+	if !ok {
+		return nil, false, nil
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal([]byte(raw), m); err != nil {
+		return nil, true, err
+	}
+
+	return m, true, nil
+}
+
+// ShardOf returns shard name holding worker workload
+func (m *Manifest) ShardOf(worker string) (string, bool) {
+	for _, s := range m.Shards {
+		for _, w := range s.Workers {
+			if w == worker {
+				return s.Name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func configMapSize(cm *v1.ConfigMap) int {
+	var size int
+	for k, v := range cm.Data {
+		size += len(k) + len(v)
+	}
+	for k, v := range cm.BinaryData {
+		size += len(k) + len(v)
+	}
+
+	return size
+}
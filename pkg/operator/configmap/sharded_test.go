@@ -0,0 +1,283 @@
+package configmap
+
+import (
+	"context"
+	"fmt"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage/storagetest"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"testing"
+)
+
+func TestShardedProvider_ItWritesOneShardPerWorkerAndReassemblesThem(t *testing.T) {
+	clientset := fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName))
+	p, err := NewShardedProvider(clientset, config.NewYAMLCodec(), "", false, ShardByWorker, 0)
+	if err != nil {
+		t.Fatalf("unexpected error building provider, error %v", err)
+	}
+
+	w := getFakeWorkloads()
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	for worker := range w.Workloads {
+		name := ShardName(configMapName, w.Version, worker)
+		cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error getting shard %s, error %v", name, err)
+		}
+		if expected, got := configMapName, cm.Labels[ShardLabel]; expected != got {
+			t.Errorf("shard label does not match, expected %s got %s", expected, got)
+		}
+	}
+
+	res, err := p.Get(context.Background(), namespace, configMapName)
+	if err != nil {
+		t.Fatalf("unexepcted error getting workload, got %v", err)
+	}
+
+	if !w.Equals(res) {
+		t.Errorf("workloads do not match, expected %v got %v", w, res)
+	}
+}
+
+func TestShardedProvider_ItSplitsWorkloadsOnSizeBuckets(t *testing.T) {
+	clientset := fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName))
+	p, err := NewShardedProvider(clientset, config.NewJSONCodec(), "config.json", false, ShardBySize, 256)
+	if err != nil {
+		t.Fatalf("unexpected error building provider, error %v", err)
+	}
+
+	w := getFakeWorkloads()
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	index, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), configMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting index, error %v", err)
+	}
+	m, err := p.manifest(index)
+	if err != nil {
+		t.Fatalf("unexpected error decoding manifest, error %v", err)
+	}
+
+	if len(m.Shards) < 2 {
+		t.Fatalf("expected multiple shards, got %d", len(m.Shards))
+	}
+
+	res, err := p.Get(context.Background(), namespace, configMapName)
+	if err != nil {
+		t.Fatalf("unexepcted error getting workload, got %v", err)
+	}
+
+	if !w.Equals(res) {
+		t.Errorf("workloads do not match, expected %v got %v", w, res)
+	}
+}
+
+func TestShardedProvider_ItEncodesEachWorkerOnceWhileSplittingOnSizeBuckets(t *testing.T) {
+	for _, c := range []config.Codec{config.NewJSONCodec(), config.NewYAMLCodec(), config.NewTOMLCodec()} {
+		codec := &countingCodec{Codec: c}
+		p, err := NewShardedProvider(fake.NewSimpleClientset(), codec, "", false, ShardBySize, 1024)
+		if err != nil {
+			t.Fatalf("unexpected error building provider, error %v", err)
+		}
+
+		w := &config.Workloads{Version: 1, Workloads: map[string]*config.Workload{}}
+		for i := 0; i < 200; i++ {
+			w.Workloads[fmt.Sprintf("swarm-worker-%d", i)] = &config.Workload{Jobs: []config.Job{config.Job(fmt.Sprintf("job-%d", i))}}
+		}
+
+		shards, err := p.split(configMapName, w)
+		if err != nil {
+			t.Fatalf("unexpected error splitting %s workloads, error %v", c.Format(), err)
+		}
+
+		// envelope, one encoding per worker and one per shard
+		if max, got := 1+len(w.Workloads)+len(shards), codec.encoded; got > max {
+			t.Errorf("%s total encodings exceed expected, max %d got %d", c.Format(), max, got)
+		}
+		total := 0
+		for _, s := range shards {
+			if size := len(s.name) + len(s.data); size > 1024 {
+				t.Errorf("%s shard %s size %d exceeds max size", c.Format(), s.name, size)
+			}
+			total += len(s.workers)
+		}
+		if expected, got := len(w.Workloads), total; expected != got {
+			t.Errorf("%s total sharded workers do not match, expected %d got %d", c.Format(), expected, got)
+		}
+	}
+}
+
+func TestShardedProvider_ItFailsWhenSingleWorkerExceedsMaxSize(t *testing.T) {
+	clientset := fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName))
+	p, err := NewShardedProvider(clientset, config.NewYAMLCodec(), "", false, ShardBySize, 32)
+	if err != nil {
+		t.Fatalf("unexpected error building provider, error %v", err)
+	}
+
+	if err := p.Set(context.Background(), namespace, configMapName, getFakeWorkloads()); err == nil {
+		t.Fatal("expected error on oversized shard")
+	}
+}
+
+func TestShardedProvider_ItRemovesStaleShardsOnScaleDown(t *testing.T) {
+	clientset := fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName))
+	p, err := NewShardedProvider(clientset, config.NewYAMLCodec(), "", false, ShardByWorker, 0)
+	if err != nil {
+		t.Fatalf("unexpected error building provider, error %v", err)
+	}
+
+	w := getFakeWorkloads()
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	previous := w.Version
+	delete(w.Workloads, "swarm-worker-2")
+	w.Version++
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	for _, worker := range []string{"swarm-worker-0", "swarm-worker-1", "swarm-worker-2"} {
+		name := ShardName(configMapName, previous, worker)
+		if _, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{}); err == nil {
+			t.Fatalf("expected stale shard %s removed", name)
+		}
+	}
+
+	res, err := p.Get(context.Background(), namespace, configMapName)
+	if err != nil {
+		t.Fatalf("unexepcted error getting workload, got %v", err)
+	}
+
+	if !w.Equals(res) {
+		t.Errorf("workloads do not match, expected %v got %v", w, res)
+	}
+}
+
+func TestShardedProvider_ItKeepsReferencedShardsUntouchedOnUpdates(t *testing.T) {
+	clientset := fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName))
+	p, err := NewShardedProvider(clientset, config.NewYAMLCodec(), "", false, ShardByWorker, 0)
+	if err != nil {
+		t.Fatalf("unexpected error building provider, error %v", err)
+	}
+
+	w := getFakeWorkloads()
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	clientset.ClearActions()
+	next := getFakeWorkloads()
+	next.Version = w.Version + 1
+	next.Workloads["swarm-worker-0"] = next.Workloads["swarm-worker-1"]
+	if err := p.Set(context.Background(), namespace, configMapName, next); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", next, err)
+	}
+
+	for _, action := range clientset.Actions() {
+		u, ok := action.(k8stesting.UpdateAction)
+		if !ok {
+			continue
+		}
+		name := u.GetObject().(*apiv1.ConfigMap).Name
+		if name != configMapName && name != ShardName(configMapName, next.Version, "swarm-worker-0") &&
+			name != ShardName(configMapName, next.Version, "swarm-worker-1") && name != ShardName(configMapName, next.Version, "swarm-worker-2") {
+			t.Errorf("unexpected update on referenced shard %s", name)
+		}
+	}
+
+	res, err := p.Get(context.Background(), namespace, configMapName)
+	if err != nil {
+		t.Fatalf("unexepcted error getting workload, got %v", err)
+	}
+	if !next.Equals(res) {
+		t.Errorf("workloads do not match, expected %v got %v", next, res)
+	}
+}
+
+func TestShardedProvider_ItRemovesShardsLeftByFailedUpdates(t *testing.T) {
+	clientset := fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName), &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ShardName(configMapName, 99, "swarm-worker-0"),
+			Namespace: namespace,
+			Labels:    map[string]string{ShardLabel: configMapName},
+		},
+	})
+	p, err := NewShardedProvider(clientset, config.NewYAMLCodec(), "", false, ShardByWorker, 0)
+	if err != nil {
+		t.Fatalf("unexpected error building provider, error %v", err)
+	}
+
+	if err := p.Set(context.Background(), namespace, configMapName, getFakeWorkloads()); err != nil {
+		t.Fatalf("unexepcted error setting workload, got %v", err)
+	}
+
+	name := ShardName(configMapName, 99, "swarm-worker-0")
+	if _, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{}); err == nil {
+		t.Fatalf("expected orphan shard %s removed", name)
+	}
+}
+
+func TestShardedProvider_ItWritesShardsOnBinaryData(t *testing.T) {
+	clientset := fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName))
+	p, err := NewShardedProvider(clientset, config.NewJSONCodec(), "config.json", true, ShardByWorker, 0)
+	if err != nil {
+		t.Fatalf("unexpected error building provider, error %v", err)
+	}
+
+	w := getFakeWorkloads()
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	for worker := range w.Workloads {
+		name := ShardName(configMapName, w.Version, worker)
+		cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error getting shard %s, error %v", name, err)
+		}
+		if _, ok := cm.BinaryData["config.json"]; !ok {
+			t.Errorf("expected shard %s binary data", name)
+		}
+		if _, ok := cm.Data["config.json"]; ok {
+			t.Errorf("unexpected shard %s data", name)
+		}
+	}
+
+	res, err := p.Get(context.Background(), namespace, configMapName)
+	if err != nil {
+		t.Fatalf("unexepcted error getting workload, got %v", err)
+	}
+
+	if !w.Equals(res) {
+		t.Errorf("workloads do not match, expected %v got %v", w, res)
+	}
+}
+
+func TestShardedProvider_Conformance(t *testing.T) {
+	p, err := NewShardedProvider(fake.NewSimpleClientset(getFakeConfigMap(namespace, configMapName)), config.NewYAMLCodec(), "", false, ShardByWorker, 0)
+	if err != nil {
+		t.Fatalf("unexpected error building provider, error %v", err)
+	}
+
+	storagetest.Run(t, p, namespace, configMapName)
+}
+
+type countingCodec struct {
+	config.Codec
+	encoded int
+}
+
+func (c *countingCodec) Encode(w *config.Workloads) ([]byte, error) {
+	c.encoded++
+	return c.Codec.Encode(w)
+}
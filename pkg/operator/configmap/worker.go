@@ -0,0 +1,237 @@
+package configmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"strconv"
+	"strings"
+)
+
+// WorkerConfigLabel marks per worker configmaps with its assignation name
+const WorkerConfigLabel = "k8slab.info/worker-config-of"
+
+// WorkerLabel holds per worker configmap worker name
+const WorkerLabel = "k8slab.info/worker"
+
+// WorkerVersionAnnotation holds last assignation version, unchanged workers keep their previous version on data
+const WorkerVersionAnnotation = "k8slab.info/assignation-version"
+
+// ErrNonOrdinalWorker reports worker names without statefulset ordinal suffix, per worker configmaps require them
+var ErrNonOrdinalWorker = errors.New("worker name without statefulset ordinal")
+
+// WorkerConfigMapName returns worker configmap name, suffixed with worker statefulset ordinal
+func WorkerConfigMapName(name, worker string) (string, error) {
+	i := strings.LastIndex(worker, "-")
+	if i < 0 || !isOrdinal(worker[i+1:]) {
+		return "", fmt.Errorf("%w %s, per worker configmaps require statefulset workers", ErrNonOrdinalWorker, worker)
+	}
+
+	ordinal, err := strconv.Atoi(worker[i+1:])
+	if err != nil {
+		return "", fmt.Errorf("%w %s, error %v", ErrNonOrdinalWorker, worker, err)
+	}
+
+	return fmt.Sprintf("%s-%d", name, ordinal), nil
+}
+
+func isOrdinal(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WorkerProvider writes one configmap per worker holding only its workload and version, workers
+// whose workload does not change keep their configmap untouched, so they do not see any file change
+type WorkerProvider struct {
+	client kubernetes.Interface
+	codec  cfg.Codec
+	key    string
+	binary bool
+}
+
+// NewWorkerProvider instantiates per worker configmap provider, workloads get encoded with codec on key,
+// binary flag stores them on BinaryData instead of Data
+func NewWorkerProvider(cl kubernetes.Interface, c cfg.Codec, key string, binary bool) *WorkerProvider {
+	if key == "" {
+		key = defaultConfigKey
+	}
+
+	return &WorkerProvider{
+		client: cl,
+		codec:  c,
+		key:    key,
+		binary: binary,
+	}
+}
+
+// Set writes changed worker workloads on its configmap, configmaps of workers no longer assigned get removed
+func (p *WorkerProvider) Set(ctx context.Context, namespace, name string, a *cfg.Workloads) error {
+	for _, worker := range a.Workers() {
+		w := a.Workloads[worker]
+		if w == nil {
+			w = &cfg.Workload{}
+		}
+		if err := p.write(ctx, namespace, name, worker, &cfg.Workloads{Version: a.Version, Workloads: map[string]*cfg.Workload{worker: w}}); err != nil {
+			return err
+		}
+	}
+
+	cms, err := p.list(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+	for _, cm := range cms {
+		if _, ok := a.Workloads[cm.Labels[WorkerLabel]]; ok {
+			continue
+		}
+		err := p.client.CoreV1().ConfigMaps(namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
+		if err != nil && !apiErrors.IsNotFound(err) {
+			return fmt.Errorf("unable to delete stale worker config map %s error %v", cm.Name, err)
+		}
+	}
+
+	logger.FromContext(ctx).Debugf("worker config maps %s updated on namespace %s version %d", name, namespace, a.Version)
+	return nil
+}
+
+// Get reassembles workloads from worker configmaps, version is the latest assignation one
+func (p *WorkerProvider) Get(ctx context.Context, namespace, name string) (*cfg.Workloads, error) {
+	cms, err := p.list(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &cfg.Workloads{Workloads: map[string]*cfg.Workload{}}
+	for _, cm := range cms {
+		w, err := p.decode(&cm)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode worker config map %s error %v", cm.Name, err)
+		}
+		v, err := strconv.ParseInt(cm.Annotations[WorkerVersionAnnotation], 10, 64)
+		if err != nil {
+			v = w.Version
+		}
+		if v > res.Version {
+			res.Version = v
+		}
+		for worker, wl := range w.Workloads {
+			res.Workloads[worker] = wl
+		}
+	}
+
+	return res, nil
+}
+
+// WorkerVersions returns each worker data version, unchanged workers keep the version its workload last
+// changed on, which is the one they acknowledge
+func (p *WorkerProvider) WorkerVersions(ctx context.Context, namespace, name string) (map[string]int64, error) {
+	cms, err := p.list(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]int64, len(cms))
+	for _, cm := range cms {
+		w, err := p.decode(&cm)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode worker config map %s error %v", cm.Name, err)
+		}
+		res[cm.Labels[WorkerLabel]] = w.Version
+	}
+
+	return res, nil
+}
+
+func (p *WorkerProvider) write(ctx context.Context, namespace, name, worker string, w *cfg.Workloads) error {
+	cmName, err := WorkerConfigMapName(name, worker)
+	if err != nil {
+		return err
+	}
+
+	data, err := p.codec.Encode(w)
+	if err != nil {
+		return fmt.Errorf("unable to encode worker %s workload, error %v", worker, err)
+	}
+
+	version := strconv.FormatInt(w.Version, 10)
+	cm, err := p.client.CoreV1().ConfigMaps(namespace).Get(ctx, cmName, metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        cmName,
+				Namespace:   namespace,
+				Labels:      map[string]string{WorkerConfigLabel: name, WorkerLabel: worker},
+				Annotations: map[string]string{WorkerVersionAnnotation: version},
+			},
+		}
+		writeKey(cm, p.key, data, p.binary)
+		if _, err := p.client.CoreV1().ConfigMaps(namespace).Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("unable to create worker config map %s error %v", cmName, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get worker config map %s error %v", cmName, err)
+	}
+
+	if cm.Labels == nil {
+		cm.Labels = map[string]string{}
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+
+	// unchanged workloads keep mounted data, only assignation version annotation gets updated, data stored
+	// on the other data field gets rewritten so that binary flag changes apply
+	previous, err := p.decode(cm)
+	_, stored := cm.Data[p.key]
+	if p.binary {
+		_, stored = cm.BinaryData[p.key]
+	}
+	unchanged := err == nil && stored && previous.Workloads[worker] != nil && previous.Workloads[worker].Equals(w.Workloads[worker])
+	if unchanged && cm.Annotations[WorkerVersionAnnotation] == version {
+		return nil
+	}
+	if !unchanged {
+		writeKey(cm, p.key, data, p.binary)
+	}
+	cm.Annotations[WorkerVersionAnnotation] = version
+	cm.Labels[WorkerConfigLabel], cm.Labels[WorkerLabel] = name, worker
+	if _, err := p.client.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update worker config map %s error %v", cmName, err)
+	}
+
+	return nil
+}
+
+func (p *WorkerProvider) list(ctx context.Context, namespace, name string) ([]v1.ConfigMap, error) {
+	cms, err := p.client.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{LabelSelector: WorkerConfigLabel + "=" + name})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list worker config maps %s error %v", name, err)
+	}
+
+	return cms.Items, nil
+}
+
+func (p *WorkerProvider) decode(cm *v1.ConfigMap) (*cfg.Workloads, error) {
+	data, err := readKey(cm, p.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.codec.Decode(data)
+}
@@ -0,0 +1,115 @@
+package configmap
+
+import (
+	"context"
+	"errors"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage/storagetest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+func TestWorkerConfigMapName_ItUsesWorkerOrdinal(t *testing.T) {
+	name, err := WorkerConfigMapName("swarm-assignation", "swarm-worker-12")
+	if err != nil {
+		t.Fatalf("unexpected error building name, error %v", err)
+	}
+	if expected, got := "swarm-assignation-12", name; expected != got {
+		t.Errorf("names do not match, expected %s got %s", expected, got)
+	}
+
+	for _, worker := range []string{"worker", "swarm-worker-7d4b9c5f6-x2kqz", "swarm-worker-", "swarm-worker-+1"} {
+		if _, err := WorkerConfigMapName("swarm-assignation", worker); !errors.Is(err, ErrNonOrdinalWorker) {
+			t.Errorf("expected non ordinal worker error on %s, got %v", worker, err)
+		}
+	}
+}
+
+func TestWorkerProvider_ItKeepsUnchangedWorkerDataAndRemovesStaleWorkers(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	p := NewWorkerProvider(clientset, config.NewYAMLCodec(), "", false)
+
+	w := getFakeWorkloads()
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	name := configMapName + "-0"
+	before, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting worker config map %s, error %v", name, err)
+	}
+
+	initial := w.Version
+	delete(w.Workloads, "swarm-worker-2")
+	w.Workloads["swarm-worker-1"].Jobs = append(w.Workloads["swarm-worker-1"].Jobs, "xfoo")
+	w.Version++
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	after, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting worker config map %s, error %v", name, err)
+	}
+	if expected, got := before.Data[defaultConfigKey], after.Data[defaultConfigKey]; expected != got {
+		t.Errorf("unchanged worker data does not match, expected %s got %s", expected, got)
+	}
+
+	if _, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), configMapName+"-2", metav1.GetOptions{}); err == nil {
+		t.Fatalf("expected stale worker config map removed")
+	}
+
+	res, err := p.Get(context.Background(), namespace, configMapName)
+	if err != nil {
+		t.Fatalf("unexepcted error getting workload, got %v", err)
+	}
+	if !w.Equals(res) {
+		t.Errorf("workloads do not match, expected %v got %v", w, res)
+	}
+
+	versions, err := p.WorkerVersions(context.Background(), namespace, configMapName)
+	if err != nil {
+		t.Fatalf("unexepcted error getting worker versions, got %v", err)
+	}
+	if expected, got := initial, versions["swarm-worker-0"]; expected != got {
+		t.Errorf("unchanged worker version does not match, expected %d got %d", expected, got)
+	}
+	if expected, got := w.Version, versions["swarm-worker-1"]; expected != got {
+		t.Errorf("changed worker version does not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestWorkerProvider_ItWritesWorkerDataOnBinaryData(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	p := NewWorkerProvider(clientset, config.NewYAMLCodec(), "", true)
+
+	w := getFakeWorkloads()
+	if err := p.Set(context.Background(), namespace, configMapName, w); err != nil {
+		t.Fatalf("unexepcted error setting workload %v, got %v", w, err)
+	}
+
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(context.Background(), configMapName+"-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting worker config map, error %v", err)
+	}
+	if _, ok := cm.BinaryData[defaultConfigKey]; !ok {
+		t.Error("expected worker binary data")
+	}
+	if _, ok := cm.Data[defaultConfigKey]; ok {
+		t.Error("unexpected worker data")
+	}
+
+	res, err := p.Get(context.Background(), namespace, configMapName)
+	if err != nil {
+		t.Fatalf("unexepcted error getting workload, got %v", err)
+	}
+	if !w.Equals(res) {
+		t.Errorf("workloads do not match, expected %v got %v", w, res)
+	}
+}
+
+func TestWorkerProvider_Conformance(t *testing.T) {
+	storagetest.Run(t, NewWorkerProvider(fake.NewSimpleClientset(), config.NewYAMLCodec(), "", false), namespace, configMapName)
+}
@@ -3,12 +3,24 @@ package operator
 import (
 	"context"
 	"fmt"
-	log "github.com/sirupsen/logrus"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/tools/cache"
+	"sort"
 )
 
+var informerLog = logger.Component(logger.Informer)
+
+// ControllerInfo reports controller watched resource keys and runner state
+type ControllerInfo struct {
+	Resource        string     `json:"resource"`
+	Synced          bool       `json:"synced"`
+	ResourceVersion string     `json:"resourceVersion"`
+	Keys            []string   `json:"keys"`
+	Runner          RunnerInfo `json:"runner"`
+}
+
 type Handler interface {
 	Create(ctx context.Context, o runtime.Object) error
 	Update(ctx context.Context, o, n runtime.Object) error
@@ -35,7 +47,7 @@ func New(eventHandler Handler, informer cache.SharedIndexInformer, runner Runner
 		AddFunc: func(obj interface{}) {
 			o, err := eh.Create(obj)
 			if err != nil {
-				log.Errorf("unable to create, error %v", err)
+				informerLog.Errorf("unable to create, error %v", err)
 				return
 			}
 			ctl.runner.Process(o)
@@ -43,7 +55,7 @@ func New(eventHandler Handler, informer cache.SharedIndexInformer, runner Runner
 		UpdateFunc: func(old, new interface{}) {
 			o, err := eh.Update(old, new)
 			if err != nil {
-				log.Errorf("unable to update, error %v", err)
+				informerLog.Errorf("unable to update, error %v", err)
 				return
 			}
 			ctl.runner.Process(o)
@@ -51,7 +63,7 @@ func New(eventHandler Handler, informer cache.SharedIndexInformer, runner Runner
 		DeleteFunc: func(obj interface{}) {
 			o, err := eh.Delete(obj)
 			if err != nil {
-				log.Errorf("unable to delete, error %v", err)
+				informerLog.Errorf("unable to delete, error %v", err)
 				return
 			}
 			ctl.runner.Process(o)
@@ -67,11 +79,25 @@ func (c *Controller) Run(ctx context.Context) {
 		return
 	}
 
-	log.Infof("%s First Cache Synced on version %s", c.resourceType, c.informer.LastSyncResourceVersion())
+	informerLog.Infof("%s First Cache Synced on version %s", c.resourceType, c.informer.LastSyncResourceVersion())
 
 	c.runner.Run(ctx, c.handle)
 }
 
+// Info returns controller introspection info
+func (c *Controller) Info() ControllerInfo {
+	keys := c.informer.GetStore().ListKeys()
+	sort.Strings(keys)
+
+	return ControllerInfo{
+		Resource:        c.resourceType,
+		Synced:          c.informer.HasSynced(),
+		ResourceVersion: c.informer.LastSyncResourceVersion(),
+		Keys:            keys,
+		Runner:          c.runner.Info(),
+	}
+}
+
 func (c *Controller) handle(ctx context.Context, k interface{}) error {
 	e, ok := k.(Event)
 	if !ok {
@@ -83,7 +109,7 @@ func (c *Controller) handle(ctx context.Context, k interface{}) error {
 	}
 
 	if !exists {
-		log.Infof("handling deletion on key %s", e.GetKey())
+		logger.ComponentFromContext(ctx, logger.Informer).Infof("handling deletion on key %s", e.GetKey())
 		if ev, ok := e.(*event); ok {
 			return c.eventHandler.Delete(ctx, ev.obj)
 		}
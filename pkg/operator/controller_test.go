@@ -23,15 +23,15 @@ func TestController_ItGetsCreatedOnListeningPodsWithPodAddition(t *testing.T) {
 	cl := fake.NewSimpleClientset(p)
 	i := informers.NewSharedInformerFactory(cl, 0)
 	pi := i.Core().V1().Pods()
-	ctl := New(eh, pi.Informer(), "Pod")
+	ctl := New(eh, pi.Informer(), NewRunner(), "Pod")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	i.Start(ctx.Done())
 	go ctl.Run(ctx)
 
-	if err := pi.Informer().GetIndexer().Add(p); err != nil {
-		t.Fatalf("unable to add entry to indexer,error %v", err)
-	}
+	// informer runner needs time @TODO: think on a real synced solution
+	time.Sleep(time.Second)
 
 	keys := pi.Informer().GetIndexer().ListKeys()
 	if expected, got := 1, len(keys); expected != got {
@@ -42,9 +42,6 @@ func TestController_ItGetsCreatedOnListeningPodsWithPodAddition(t *testing.T) {
 		t.Fatalf("keys do not match, expected %s got %s", expected, got)
 	}
 
-	// informer runner needs time @TODO: think on a real synced solution
-	time.Sleep(time.Second)
-
 	if expected, got := 1, eh.created(); expected != int(got) {
 		t.Errorf("calls do not match, expected %d got %d", expected, got)
 	}
@@ -82,33 +79,30 @@ func TestController_ItGetsCreatedOnListeningPodsWithPodAddition(t *testing.T) {
 	}
 }
 
-func TestController_ItGetsDeletedOnListeningPodsWithPodAdditionWithoutBeingPreloadedInTheIndexer(t *testing.T) {
+func TestController_ItGetsDeletedOnListeningPodsWithPodDeletion(t *testing.T) {
 	namespace := "default"
 	name := "foo"
 	eh := &fakeHandler{}
-	cl := fake.NewSimpleClientset()
+	cl := fake.NewSimpleClientset(getFakePod(namespace, name))
 	i := informers.NewSharedInformerFactory(cl, 0)
 	pi := i.Core().V1().Pods()
-	ctl := New(eh, pi.Informer(), "Pod")
+	ctl := New(eh, pi.Informer(), NewRunner(), "Pod")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	i.Start(ctx.Done())
 	go ctl.Run(ctx)
 
-	p := getFakePod(namespace, name)
-	if err := pi.Informer().GetIndexer().Add(p); err != nil {
-		t.Fatalf("unable to add entry to indexer,error %v", err)
-	}
+	// informer runner needs time @TODO: think on a real synced solution
+	time.Sleep(time.Second)
 
-	keys := pi.Informer().GetIndexer().ListKeys()
-	if expected, got := 1, len(keys); expected != got {
-		t.Fatalf("handled size does not match, expected %d got %d", expected, got)
+	if err := cl.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("unable to delete pod, error %v", err)
 	}
 
-	// informer runner needs time @TODO: think on a real synced solution
 	time.Sleep(time.Second)
 
-	if expected, got := 0, eh.created(); expected != int(got) {
+	if expected, got := 1, eh.created(); expected != int(got) {
 		t.Errorf("calls do not match, expected %d got %d", expected, got)
 	}
 	if expected, got := 1, eh.deleted(); expected != int(got) {
@@ -121,15 +115,20 @@ type fakeHandler struct {
 	totalDeleted int32
 }
 
-func (f *fakeHandler) Handle(ctx context.Context, o runtime.Object) error {
+func (f *fakeHandler) Create(ctx context.Context, o runtime.Object) error {
 	atomic.AddInt32(&f.totalCreated, 1)
 	return nil
 }
 
-func (f *fakeHandler) Delete(ctx context.Context, namespace, name string) error {
+func (f *fakeHandler) Update(ctx context.Context, o, n runtime.Object) error {
+	return nil
+}
+
+func (f *fakeHandler) Delete(ctx context.Context, o runtime.Object) error {
 	atomic.AddInt32(&f.totalDeleted, 1)
 	return nil
 }
+
 func (f *fakeHandler) created() int32 {
 	return atomic.LoadInt32(&f.totalCreated)
 }
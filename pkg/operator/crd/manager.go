@@ -3,13 +3,12 @@ package crd
 import (
 	"context"
 	"fmt"
-	"github.com/davecgh/go-spew/spew"
+	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/klog/v2"
 	"time"
 )
 
@@ -33,7 +32,7 @@ func (c *manager) Create(ctx context.Context, cr *v1.CustomResourceDefinition) e
 	_, err := c.apiExtensionsClientSet.ApiextensionsV1().CustomResourceDefinitions().Create(ctx, cr, metav1.CreateOptions{})
 
 	if err != nil {
-		klog.Fatalf("unable to create CRD , error: %v", err.Error())
+		return fmt.Errorf("unable to create CRD %s, error %v", cr.Name, err)
 	}
 
 	return c.waitCRDAccepted(ctx, cr.Name)
@@ -48,8 +47,13 @@ func (c *manager) IsAccepted(ctx context.Context, resourceName string) (bool, er
 		return false, err
 	}
 
-	spew.Dump(cr.Status.Conditions)
 	for _, condition := range cr.Status.Conditions {
+		log.WithFields(log.Fields{
+			"crd":    resourceName,
+			"type":   condition.Type,
+			"status": condition.Status,
+			"reason": condition.Reason,
+		}).Debug("CRD condition")
 		if condition.Type == v1.Established &&
 			condition.Status == v1.ConditionTrue {
 			return true, nil
@@ -0,0 +1,69 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"time"
+)
+
+// LeaderElection runs its callback while holding a coordination lease, standby replicas wait to acquire it
+type LeaderElection struct {
+	identity string
+	elector  *leaderelection.LeaderElector
+}
+
+// NewLeaderElection builds lease based leader election, lease gets renewed at two thirds of its duration
+// and released on context cancellation
+func NewLeaderElection(cl kubernetes.Interface, namespace, name, identity string, duration time.Duration, run func(ctx context.Context)) (*LeaderElection, error) {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Client:     cl.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+	}
+
+	el, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   duration,
+		RenewDeadline:   duration * 2 / 3,
+		RetryPeriod:     duration / 5,
+		ReleaseOnCancel: true,
+		Name:            name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: run,
+			OnStoppedLeading: func() {
+				log.Infof("leader election %s stopped leading as %s", name, identity)
+			},
+			OnNewLeader: func(current string) {
+				log.Infof("leader election %s current leader %s", name, current)
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to build leader election %s, error %v", name, err)
+	}
+
+	return &LeaderElection{
+		identity: identity,
+		elector:  el,
+	}, nil
+}
+
+// Run blocks until context gets cancelled or leadership gets lost
+func (l *LeaderElection) Run(ctx context.Context) {
+	l.elector.Run(ctx)
+}
+
+// IsLeader checks if lease is currently held
+func (l *LeaderElection) IsLeader() bool {
+	return l.elector.IsLeader()
+}
+
+// Identity returns lease holder identity of this process
+func (l *LeaderElection) Identity() string {
+	return l.identity
+}
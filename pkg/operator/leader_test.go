@@ -0,0 +1,52 @@
+package operator
+
+import (
+	"context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func TestLeaderElection_ItRunsCallbackWhileHoldingLease(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	started := make(chan struct{})
+	var le *LeaderElection
+	le, err := NewLeaderElection(clientset, "swarm", "swarm-pool-controller", "foo", 3*time.Second, func(ctx context.Context) {
+		if !le.IsLeader() {
+			t.Error("expected leader on started callback")
+		}
+		close(started)
+		<-ctx.Done()
+	})
+	if err != nil {
+		t.Fatalf("unexpected error building leader election, error %v", err)
+	}
+	if le.IsLeader() {
+		t.Fatal("unexpected leader before running")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		le.Run(ctx)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("leader callback not started")
+	}
+
+	l, err := clientset.CoordinationV1().Leases("swarm").Get(context.Background(), "swarm-pool-controller", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting lease, error %v", err)
+	}
+	if expected, got := "foo", *l.Spec.HolderIdentity; expected != got {
+		t.Errorf("lease holder does not match, expected %s got %s", expected, got)
+	}
+
+	cancel()
+	<-done
+}
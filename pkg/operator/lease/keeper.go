@@ -0,0 +1,92 @@
+package lease
+
+import (
+	"context"
+	"fmt"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	api "k8s.io/api/coordination/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"time"
+)
+
+// Keeper renews worker job leases, workers must only run jobs whose lease they hold
+type Keeper struct {
+	client kubernetes.Interface
+}
+
+// NewKeeper instantiates worker job lease keeper
+func NewKeeper(cl kubernetes.Interface) *Keeper {
+	return &Keeper{
+		client: cl,
+	}
+}
+
+// Renew renews holder job leases, returns held jobs and its shortest lease duration, held jobs must stop
+// once duration passes without renewal. Leases held by others, missing or concurrently transferred are not held
+func (k *Keeper) Renew(ctx context.Context, namespace, swarm, holder string, jobs []cfg.Job) ([]cfg.Job, time.Duration, error) {
+	var res []cfg.Job
+	var duration time.Duration
+	for _, j := range jobs {
+		var d time.Duration
+		ok, err := k.update(ctx, namespace, Name(swarm, j), holder, func(l *api.Lease) {
+			now := metav1.NewMicroTime(time.Now())
+			l.Spec.RenewTime = &now
+			if l.Spec.LeaseDurationSeconds != nil {
+				d = time.Duration(*l.Spec.LeaseDurationSeconds) * time.Second
+			}
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		if !ok {
+			continue
+		}
+		res = append(res, j)
+		if duration == 0 || (d > 0 && d < duration) {
+			duration = d
+		}
+	}
+
+	return res, duration, nil
+}
+
+// Release releases holder job leases, released leases get transferred without waiting its expiration
+func (k *Keeper) Release(ctx context.Context, namespace, swarm, holder string, jobs []cfg.Job) error {
+	for _, j := range jobs {
+		_, err := k.update(ctx, namespace, Name(swarm, j), holder, func(l *api.Lease) {
+			l.Spec.HolderIdentity = nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (k *Keeper) update(ctx context.Context, namespace, name, holder string, mutate func(*api.Lease)) (bool, error) {
+	l, err := k.client.CoordinationV1().Leases(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to get lease %s error %v", name, err)
+	}
+	if Holder(l) != holder {
+		return false, nil
+	}
+
+	updated := l.DeepCopy()
+	mutate(updated)
+	_, err = k.client.CoordinationV1().Leases(namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	if apiErrors.IsConflict(err) || apiErrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to update lease %s error %v", name, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,182 @@
+package lease
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	api "k8s.io/api/coordination/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SwarmLabel links job lease with its swarm
+const SwarmLabel = "k8slab.info/swarm"
+
+// JobAnnotation holds leased job
+const JobAnnotation = "k8slab.info/job"
+
+const maxNameLength = 63
+
+// Name returns swarm job lease name, job gets sanitized and suffixed with its hash so that names keep unique
+func Name(swarm string, job cfg.Job) string {
+	sum := sha256.Sum256([]byte(job))
+	suffix := hex.EncodeToString(sum[:4])
+
+	sanitized := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(swarm+"-"+string(job)))
+	if max := maxNameLength - len(suffix) - 1; len(sanitized) > max {
+		sanitized = sanitized[:max]
+	}
+
+	return strings.Trim(sanitized, "-") + "-" + suffix
+}
+
+// Provider keeps one lease per swarm job held by its assigned worker, leases get transferred
+// only once released or expired
+type Provider struct {
+	client   kubernetes.Interface
+	duration time.Duration
+}
+
+// NewProvider instantiates job lease provider, duration sets created leases duration
+func NewProvider(cl kubernetes.Interface, duration time.Duration) *Provider {
+	return &Provider{
+		client:   cl,
+		duration: duration,
+	}
+}
+
+// Duration returns leases duration
+func (p *Provider) Duration() time.Duration {
+	return p.duration
+}
+
+// Sync ensures each job lease holder is its owner worker, leases still held by previous holders are kept and
+// its jobs returned as pending. Leases of jobs without owner get deleted once released or expired
+func (p *Provider) Sync(ctx context.Context, namespace, swarm string, owners map[cfg.Job]string) ([]cfg.Job, error) {
+	jobs := make([]cfg.Job, 0, len(owners))
+	for j := range owners {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i] < jobs[j] })
+
+	var pending []cfg.Job
+	for _, j := range jobs {
+		ok, err := p.acquire(ctx, namespace, swarm, j, owners[j])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			pending = append(pending, j)
+		}
+	}
+
+	leases, err := p.client.CoordinationV1().Leases(namespace).List(ctx, metav1.ListOptions{LabelSelector: SwarmLabel + "=" + swarm})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list swarm %s leases error %v", swarm, err)
+	}
+	now := time.Now()
+	for _, l := range leases.Items {
+		if _, ok := owners[cfg.Job(l.Annotations[JobAnnotation])]; ok {
+			continue
+		}
+		// holders keep running its jobs until they release them or miss its renewal
+		if Holder(&l) != "" && !Expired(&l, now) {
+			continue
+		}
+		if err := p.client.CoordinationV1().Leases(namespace).Delete(ctx, l.Name, metav1.DeleteOptions{}); err != nil && !apiErrors.IsNotFound(err) {
+			return nil, fmt.Errorf("unable to delete lease %s error %v", l.Name, err)
+		}
+	}
+
+	return pending, nil
+}
+
+// Delete removes all swarm job leases
+func (p *Provider) Delete(ctx context.Context, namespace, swarm string) error {
+	err := p.client.CoordinationV1().Leases(namespace).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: SwarmLabel + "=" + swarm})
+	if err != nil {
+		return fmt.Errorf("unable to delete swarm %s leases error %v", swarm, err)
+	}
+
+	return nil
+}
+
+// acquire creates or transfers job lease to owner, held leases by other holders can not be transferred
+func (p *Provider) acquire(ctx context.Context, namespace, swarm string, job cfg.Job, owner string) (bool, error) {
+	name := Name(swarm, job)
+	now := metav1.NewMicroTime(time.Now())
+	seconds := int32(p.duration.Seconds())
+
+	l, err := p.client.CoordinationV1().Leases(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		l = &api.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   namespace,
+				Labels:      map[string]string{SwarmLabel: swarm},
+				Annotations: map[string]string{JobAnnotation: string(job)},
+			},
+			Spec: api.LeaseSpec{HolderIdentity: &owner, LeaseDurationSeconds: &seconds, AcquireTime: &now, RenewTime: &now},
+		}
+		if _, err := p.client.CoordinationV1().Leases(namespace).Create(ctx, l, metav1.CreateOptions{}); err != nil {
+			return false, fmt.Errorf("unable to create lease %s error %v", name, err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to get lease %s error %v", name, err)
+	}
+
+	holder := Holder(l)
+	if holder == owner {
+		return true, nil
+	}
+	if holder != "" && !Expired(l, now.Time) {
+		return false, nil
+	}
+
+	updated := l.DeepCopy()
+	var transitions int32
+	if l.Spec.LeaseTransitions != nil {
+		transitions = *l.Spec.LeaseTransitions + 1
+	}
+	updated.Spec = api.LeaseSpec{HolderIdentity: &owner, LeaseDurationSeconds: &seconds, AcquireTime: &now, RenewTime: &now, LeaseTransitions: &transitions}
+	_, err = p.client.CoordinationV1().Leases(namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	if apiErrors.IsConflict(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to transfer lease %s error %v", name, err)
+	}
+
+	return true, nil
+}
+
+// Holder returns lease holder, released leases return empty holder
+func Holder(l *api.Lease) string {
+	if l.Spec.HolderIdentity == nil {
+		return ""
+	}
+
+	return *l.Spec.HolderIdentity
+}
+
+// Expired checks if lease holder missed its renewal
+func Expired(l *api.Lease, now time.Time) bool {
+	if l.Spec.RenewTime == nil || l.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+
+	return l.Spec.RenewTime.Add(time.Duration(*l.Spec.LeaseDurationSeconds) * time.Second).Before(now)
+}
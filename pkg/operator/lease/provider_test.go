@@ -0,0 +1,127 @@
+package lease
+
+import (
+	"context"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestName_ItBuildsValidUniqueLeaseNames(t *testing.T) {
+	a, b := Name("swarm", "stream:Foo_1"), Name("swarm", "stream:foo-1")
+	if a == b {
+		t.Errorf("expected unique names, got %s", a)
+	}
+	if len(Name("swarm", cfg.Job(string(make([]byte, 200))))) > maxNameLength {
+		t.Error("expected bounded lease name")
+	}
+}
+
+func TestProvider_ItTransfersLeasesOnlyOnceReleasedOrExpired(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewSimpleClientset()
+	p := NewProvider(cl, time.Minute)
+	k := NewKeeper(cl)
+
+	pending, err := p.Sync(ctx, "swarm", "foo", map[cfg.Job]string{"a": "swarm-worker-0", "b": "swarm-worker-0"})
+	if err != nil {
+		t.Fatalf("unable to sync leases %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("unexpected pending transfers %v", pending)
+	}
+
+	pending, err = p.Sync(ctx, "swarm", "foo", map[cfg.Job]string{"a": "swarm-worker-1", "b": "swarm-worker-1"})
+	if err != nil {
+		t.Fatalf("unable to sync leases %v", err)
+	}
+	if expected, got := []cfg.Job{"a", "b"}, pending; !reflect.DeepEqual(expected, got) {
+		t.Errorf("pending transfers do not match, expected %v got %v", expected, got)
+	}
+	held, _, err := k.Renew(ctx, "swarm", "foo", "swarm-worker-1", []cfg.Job{"a", "b"})
+	if err != nil {
+		t.Fatalf("unable to renew leases %v", err)
+	}
+	if len(held) != 0 {
+		t.Errorf("unexpected new owner held jobs %v", held)
+	}
+
+	if err := k.Release(ctx, "swarm", "foo", "swarm-worker-0", []cfg.Job{"a"}); err != nil {
+		t.Fatalf("unable to release leases %v", err)
+	}
+	expire(t, cl, Name("foo", "b"))
+
+	pending, err = p.Sync(ctx, "swarm", "foo", map[cfg.Job]string{"a": "swarm-worker-1", "b": "swarm-worker-1"})
+	if err != nil {
+		t.Fatalf("unable to sync leases %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("unexpected pending transfers %v", pending)
+	}
+	held, duration, err := k.Renew(ctx, "swarm", "foo", "swarm-worker-1", []cfg.Job{"a", "b"})
+	if err != nil {
+		t.Fatalf("unable to renew leases %v", err)
+	}
+	if expected, got := []cfg.Job{"a", "b"}, held; !reflect.DeepEqual(expected, got) {
+		t.Errorf("held jobs do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := time.Minute, duration; expected != got {
+		t.Errorf("held lease duration does not match, expected %s got %s", expected, got)
+	}
+	held, _, err = k.Renew(ctx, "swarm", "foo", "swarm-worker-0", []cfg.Job{"a", "b"})
+	if err != nil {
+		t.Fatalf("unable to renew leases %v", err)
+	}
+	if len(held) != 0 {
+		t.Errorf("unexpected previous owner held jobs %v", held)
+	}
+}
+
+func TestProvider_ItDeletesReleasedLeasesOfJobsWithoutOwner(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewSimpleClientset()
+	p := NewProvider(cl, time.Minute)
+
+	if _, err := p.Sync(ctx, "swarm", "foo", map[cfg.Job]string{"a": "swarm-worker-0", "b": "swarm-worker-0", "c": "swarm-worker-0"}); err != nil {
+		t.Fatalf("unable to sync leases %v", err)
+	}
+	if _, err := p.Sync(ctx, "swarm", "foo", map[cfg.Job]string{"a": "swarm-worker-0"}); err != nil {
+		t.Fatalf("unable to sync leases %v", err)
+	}
+
+	leases, _ := cl.CoordinationV1().Leases("swarm").List(ctx, metav1.ListOptions{})
+	if expected, got := 3, len(leases.Items); expected != got {
+		t.Fatalf("held leases must be kept, expected %d got %d", expected, got)
+	}
+
+	if err := NewKeeper(cl).Release(ctx, "swarm", "foo", "swarm-worker-0", []cfg.Job{"b"}); err != nil {
+		t.Fatalf("unable to release leases %v", err)
+	}
+	expire(t, cl, Name("foo", "c"))
+	if _, err := p.Sync(ctx, "swarm", "foo", map[cfg.Job]string{"a": "swarm-worker-0"}); err != nil {
+		t.Fatalf("unable to sync leases %v", err)
+	}
+
+	leases, _ = cl.CoordinationV1().Leases("swarm").List(ctx, metav1.ListOptions{})
+	if expected, got := 1, len(leases.Items); expected != got {
+		t.Fatalf("total leases do not match, expected %d got %d", expected, got)
+	}
+	if expected, got := "a", leases.Items[0].Annotations[JobAnnotation]; expected != got {
+		t.Errorf("lease job does not match, expected %s got %s", expected, got)
+	}
+}
+
+func expire(t *testing.T, cl *fake.Clientset, name string) {
+	l, err := cl.CoordinationV1().Leases("swarm").Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unable to get lease %v", err)
+	}
+	past := metav1.NewMicroTime(time.Now().Add(-time.Hour))
+	l.Spec.RenewTime = &past
+	if _, err := cl.CoordinationV1().Leases("swarm").Update(context.Background(), l, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("unable to expire lease %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package pod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// AssignationOwnerLabel links worker pod with the assignation name, as label assigned pods get listed by selector
+const AssignationOwnerLabel = "k8slab.info/assignation"
+
+// WorkloadAnnotation holds worker pod assigned workload
+const WorkloadAnnotation = "k8slab.info/workload"
+
+type podWorkload struct {
+	Version int64     `json:"version"`
+	Jobs    []cfg.Job `json:"jobs"`
+	Weight  int64     `json:"weight,omitempty"`
+}
+
+// AnnotationProvider stores each worker workload as worker pod annotations
+type AnnotationProvider struct {
+	client kubernetes.Interface
+}
+
+// NewAnnotationProvider instantiates pod annotation provider
+func NewAnnotationProvider(cl kubernetes.Interface) *AnnotationProvider {
+	return &AnnotationProvider{
+		client: cl,
+	}
+}
+
+// Set annotates each worker pod with its workload, not found pods are skipped,
+// pods no longer assigned get its annotations removed
+func (p *AnnotationProvider) Set(ctx context.Context, namespace, name string, a *cfg.Workloads) error {
+	for worker, w := range a.Workloads {
+		pw := podWorkload{Version: a.Version}
+		if w != nil {
+			pw.Jobs = w.Jobs
+			pw.Weight = w.Weight
+		}
+		raw, err := json.Marshal(pw)
+		if err != nil {
+			return fmt.Errorf("unable to marshall pod %s workload, error %v", worker, err)
+		}
+
+		err = p.patch(ctx, namespace, worker, name, string(raw))
+		if apiErrors.IsNotFound(err) {
+			logger.FromContext(ctx).Warnf("worker pod %s not found on namespace %s, workload annotation skipped", worker, namespace)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	pods, err := p.list(ctx, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	for _, pd := range pods {
+		if _, ok := a.Workloads[pd.Name]; ok {
+			continue
+		}
+
+		err := p.patch(ctx, namespace, pd.Name, nil, nil)
+		if err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get rebuilds workload assignations from annotated worker pods
+func (p *AnnotationProvider) Get(ctx context.Context, namespace, name string) (*cfg.Workloads, error) {
+	pods, err := p.list(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &cfg.Workloads{Workloads: map[string]*cfg.Workload{}}
+	for _, pd := range pods {
+		pw := podWorkload{}
+		if err := json.Unmarshal([]byte(pd.Annotations[WorkloadAnnotation]), &pw); err != nil {
+			return nil, fmt.Errorf("unable to unmarshall pod %s workload, error %v", pd.Name, err)
+		}
+
+		if pw.Version > res.Version {
+			res.Version = pw.Version
+		}
+		res.Workloads[pd.Name] = &cfg.Workload{Jobs: pw.Jobs, Weight: pw.Weight}
+	}
+
+	return res, nil
+}
+
+func (p *AnnotationProvider) list(ctx context.Context, namespace, name string) ([]v1.Pod, error) {
+	pods, err := p.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{AssignationOwnerLabel: name}).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list pods on namespace %s error %v", namespace, err)
+	}
+
+	return pods.Items, nil
+}
+
+// patch sets pod owner label and workload annotation, nil values remove them
+func (p *AnnotationProvider) patch(ctx context.Context, namespace, pod string, owner, workload interface{}) error {
+	return patchMetadata(ctx, p.client, namespace, pod, map[string]interface{}{AssignationOwnerLabel: owner}, map[string]interface{}{WorkloadAnnotation: workload})
+}
+
+// patchAnnotations merges annotations on pod, nil values remove them, not found errors get returned unwrapped
+func patchAnnotations(ctx context.Context, cl kubernetes.Interface, namespace, name string, annotations map[string]interface{}) error {
+	return patchMetadata(ctx, cl, namespace, name, nil, annotations)
+}
+
+// patchMetadata merges labels and annotations on pod, nil values remove them, not found errors get returned unwrapped
+func patchMetadata(ctx context.Context, cl kubernetes.Interface, namespace, name string, podLabels, annotations map[string]interface{}) error {
+	meta := map[string]interface{}{}
+	if len(podLabels) > 0 {
+		meta["labels"] = podLabels
+	}
+	if len(annotations) > 0 {
+		meta["annotations"] = annotations
+	}
+	data, err := json.Marshal(map[string]interface{}{"metadata": meta})
+	if err != nil {
+		return fmt.Errorf("unable to marshall pod %s patch, error %v", name, err)
+	}
+
+	_, err = cl.CoreV1().Pods(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+	if apiErrors.IsNotFound(err) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("unable to patch pod %s annotations, error %v", name, err)
+	}
+
+	return nil
+}
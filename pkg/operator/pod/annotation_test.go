@@ -0,0 +1,72 @@
+package pod
+
+import (
+	"context"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage/storagetest"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"testing"
+)
+
+func TestAnnotationProvider_Conformance(t *testing.T) {
+	namespace := "swarm"
+	var pods []runtime.Object
+	for _, name := range storagetest.Workers {
+		pods = append(pods, &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		})
+	}
+
+	p := NewAnnotationProvider(fake.NewSimpleClientset(pods...))
+
+	storagetest.Run(t, p, namespace, "swarm-worker-config")
+}
+
+func TestAnnotationProvider_ItListsOnlyAssignationOwnedPods(t *testing.T) {
+	namespace := "swarm"
+	clientset := fake.NewSimpleClientset(&apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "swarm-worker-0", Namespace: namespace},
+	}, &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-0", Namespace: namespace, Labels: map[string]string{"app": "other"}},
+	})
+	p := NewAnnotationProvider(clientset)
+
+	w := &config.Workloads{Version: 1, Workloads: map[string]*config.Workload{"swarm-worker-0": {Jobs: []config.Job{"foo"}}}}
+	if err := p.Set(context.Background(), namespace, "swarm-worker-config", w); err != nil {
+		t.Fatalf("unexpected error setting workloads, error %v", err)
+	}
+
+	pd, err := clientset.CoreV1().Pods(namespace).Get(context.Background(), "swarm-worker-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error getting pod, error %v", err)
+	}
+	if expected, got := "swarm-worker-config", pd.Labels[AssignationOwnerLabel]; expected != got {
+		t.Errorf("owner label does not match, expected %s got %s", expected, got)
+	}
+
+	clientset.ClearActions()
+	res, err := p.Get(context.Background(), namespace, "swarm-worker-config")
+	if err != nil {
+		t.Fatalf("unexpected error getting workloads, error %v", err)
+	}
+	if !w.Equals(res) {
+		t.Errorf("workloads do not match, expected %v got %v", w, res)
+	}
+
+	for _, action := range clientset.Actions() {
+		l, ok := action.(k8stesting.ListAction)
+		if !ok {
+			continue
+		}
+		if expected, got := AssignationOwnerLabel+"=swarm-worker-config", l.GetListRestrictions().Labels.String(); expected != got {
+			t.Errorf("list selector does not match, expected %s got %s", expected, got)
+		}
+	}
+}
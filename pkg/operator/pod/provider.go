@@ -3,6 +3,7 @@ package pod
 import (
 	"context"
 	"fmt"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -19,10 +20,10 @@ func NewProvider(cl kubernetes.Interface) *Provider {
 	}
 }
 
-// Refresh deletes pod to force restart on the latest version
+// Refresh deletes pod to force restart on the latest version, already deleted pods are done
 func (p *Provider) Refresh(ctx context.Context, namespace, name string) error {
 	err := p.client.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
-	if err != nil {
+	if err != nil && !apiErrors.IsNotFound(err) {
 		return fmt.Errorf("unable to delete pod %s error %v", name, err)
 	}
 
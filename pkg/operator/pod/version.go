@@ -0,0 +1,51 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	api "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"strconv"
+)
+
+// AppliedVersionAnnotation holds the workload version applied by the worker pod
+const AppliedVersionAnnotation = "k8slab.info/applied-version"
+
+// AppliedVersion returns pod applied workload version, pods without acknowledgement report false
+func AppliedVersion(pod *api.Pod) (int64, bool) {
+	v, ok := pod.Annotations[AppliedVersionAnnotation]
+	if !ok {
+		return 0, false
+	}
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return i, true
+}
+
+// VersionAcknowledger annotates worker pods with their applied workload version
+type VersionAcknowledger struct {
+	client kubernetes.Interface
+}
+
+// NewVersionAcknowledger instantiates pod version acknowledger
+func NewVersionAcknowledger(cl kubernetes.Interface) *VersionAcknowledger {
+	return &VersionAcknowledger{
+		client: cl,
+	}
+}
+
+// Acknowledge annotates pod with its applied workload version
+func (a *VersionAcknowledger) Acknowledge(ctx context.Context, namespace, name string, version int64) error {
+	err := patchAnnotations(ctx, a.client, namespace, name, map[string]interface{}{
+		AppliedVersionAnnotation: strconv.FormatInt(version, 10),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to acknowledge version %d on pod %s error %v", version, name, err)
+	}
+
+	return nil
+}
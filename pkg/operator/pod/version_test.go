@@ -0,0 +1,37 @@
+package pod
+
+import (
+	"context"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+func TestVersionAcknowledger_ItAnnotatesPodAppliedVersion(t *testing.T) {
+	ctx := context.Background()
+	cl := fake.NewSimpleClientset(&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "swarm-worker-0", Namespace: "swarm"}})
+
+	if err := NewVersionAcknowledger(cl).Acknowledge(ctx, "swarm", "swarm-worker-0", 7); err != nil {
+		t.Fatalf("unable to acknowledge version %v", err)
+	}
+
+	pd, err := cl.CoreV1().Pods("swarm").Get(ctx, "swarm-worker-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unable to get pod %v", err)
+	}
+	v, ok := AppliedVersion(pd)
+	if !ok {
+		t.Fatal("expected applied version annotation")
+	}
+	if expected, got := int64(7), v; expected != got {
+		t.Errorf("applied version does not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestAppliedVersion_ItIgnoresMalformedAnnotations(t *testing.T) {
+	pd := &apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{AppliedVersionAnnotation: "foo"}}}
+	if _, ok := AppliedVersion(pd); ok {
+		t.Error("expected malformed applied version ignored")
+	}
+}
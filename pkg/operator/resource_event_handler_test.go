@@ -9,78 +9,67 @@ import (
 	"time"
 )
 
-func TestResourceEventHandler_AddPodIncludesItemOnQueue(t *testing.T) {
+func TestResourceEventHandler_AddPodBuildsCreateEvent(t *testing.T) {
 	name := "swarm-worker-0"
 	namespace := "swarm"
-	q := newFakeQueue()
-	reh := NewResourceEventHandler(q)
+	reh := NewResourceEventHandler()
 	p := getFakePod(namespace, name)
-	reh.Add(&p)
-
-	if expected, got := 1, q.Len(); expected != got {
-		t.Fatalf("unexpected queue size, expected %d got %d", expected, got)
-	}
-	e, shut := q.Get()
-	if shut {
-		t.Fatalf("unexpected type, got %T", e)
+	e, err := reh.Create(p)
+	if err != nil {
+		t.Fatalf("unexpected error creating event, error %v", err)
 	}
 
-	key, ok := e.(string)
-	if !ok {
-		t.Fatalf("unexpected type, got %T", e)
+	if expected, got := Create, e.GetAction(); expected != got {
+		t.Fatalf("action does not match, expected %s got %s", expected, got)
 	}
-	if expected, got := fmt.Sprintf("%s/%s", namespace, name), key; expected != got {
+	if expected, got := fmt.Sprintf("%s/%s", namespace, name), e.GetKey(); expected != got {
 		t.Fatalf("pod name does not match, expected %s got %s", expected, got)
 	}
 }
 
-func TestResourceEventHandler_UpdatePodIncludesItemOnQueue(t *testing.T) {
+func TestResourceEventHandler_UpdatePodBuildsUpdateEvent(t *testing.T) {
 	name := "swarm-worker-0"
 	namespace := "swarm"
-	q := newFakeQueue()
-	reh := NewResourceEventHandler(q)
+	reh := NewResourceEventHandler()
 	p := getFakePod(namespace, name)
-	reh.Update(&p, &p)
-
-	if expected, got := 1, q.Len(); expected != got {
-		t.Fatalf("unexpected queue size, expected %d got %d", expected, got)
-	}
-	e, shut := q.Get()
-	if shut {
-		t.Fatalf("unexpected type, got %T", e)
+	e, err := reh.Update(p, p)
+	if err != nil {
+		t.Fatalf("unexpected error creating event, error %v", err)
 	}
 
-	key, ok := e.(string)
-	if !ok {
-		t.Fatalf("unexpected type, got %T", e)
+	if expected, got := Update, e.GetAction(); expected != got {
+		t.Fatalf("action does not match, expected %s got %s", expected, got)
 	}
-	if expected, got := fmt.Sprintf("%s/%s", namespace, name), key; expected != got {
+	if expected, got := fmt.Sprintf("%s/%s", namespace, name), e.GetKey(); expected != got {
 		t.Fatalf("pod name does not match, expected %s got %s", expected, got)
 	}
 }
 
-func TestResourceEventHandler_DeletePodIncludesItemOnQueue(t *testing.T) {
+func TestResourceEventHandler_DeletePodBuildsDeleteEvent(t *testing.T) {
 	name := "swarm-worker-0"
 	namespace := "swarm"
-	q := newFakeQueue()
-	reh := NewResourceEventHandler(q)
+	reh := NewResourceEventHandler()
 	p := getFakePod(namespace, name)
-	reh.Delete(&p)
+	e, err := reh.Delete(p)
+	if err != nil {
+		t.Fatalf("unexpected error creating event, error %v", err)
+	}
 
-	if expected, got := 1, q.Len(); expected != got {
-		t.Fatalf("unexpected queue size, expected %d got %d", expected, got)
+	if expected, got := Delete, e.GetAction(); expected != got {
+		t.Fatalf("action does not match, expected %s got %s", expected, got)
 	}
-	e, shut := q.Get()
-	if shut {
-		t.Fatalf("unexpected type, got %T", e)
+	if expected, got := fmt.Sprintf("%s/%s", namespace, name), e.GetKey(); expected != got {
+		t.Fatalf("pod name does not match, expected %s got %s", expected, got)
 	}
+}
 
-	key, ok := e.(string)
-	if !ok {
-		t.Fatalf("unexpected type, got %T", e)
+func TestResourceEventHandler_NilObjectsGetRejected(t *testing.T) {
+	reh := NewResourceEventHandler()
+	if _, err := reh.Create(nil); err != errNilObject {
+		t.Errorf("expected nil object error, got %v", err)
 	}
-	if expected, got := fmt.Sprintf("%s/%s", namespace, name), key; expected != got {
-		t.Fatalf("pod name does not match, expected %s got %s", expected, got)
+	if _, err := reh.Delete(nil); err != errNilObject {
+		t.Errorf("expected nil object error, got %v", err)
 	}
 }
 
@@ -2,52 +2,203 @@ package operator
 
 import (
 	"context"
-	log "github.com/sirupsen/logrus"
+	"fmt"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	"github.com/sirupsen/logrus"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/workqueue"
+	"sort"
 	"sync"
 	"time"
 )
 
-const maxRetries = 5
-const handleTimeout = time.Second
-const workerFrequency = time.Second
+var runnerLog = logger.Component(logger.Runner)
 
 type Runner interface {
 	Process(e interface{})
+	ProcessAfter(e interface{}, d time.Duration)
 	Run(ctx context.Context, h func(context.Context, interface{}) error)
+	Tune(r config.Runner)
+	Status() Status
+	Info() RunnerInfo
+}
+
+// Status reports runner liveness, heartbeat gets updated on each processed entry
+type Status struct {
+	Running    bool      `json:"running"`
+	Processing bool      `json:"processing"`
+	Heartbeat  time.Time `json:"heartbeat"`
+	QueueDepth int       `json:"queueDepth"`
+}
+
+// Entry reports queued entry state, entries waiting retry backoff remain listed
+type Entry struct {
+	Key        string    `json:"key"`
+	Action     string    `json:"action,omitempty"`
+	Retries    int       `json:"retries"`
+	Processing bool      `json:"processing"`
+	QueuedAt   time.Time `json:"queuedAt"`
+}
+
+// KeyError reports last handling error by entry key, it gets cleared on key success
+type KeyError struct {
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	At       time.Time `json:"at"`
+}
+
+// RunnerInfo reports runner status and queue contents
+type RunnerInfo struct {
+	Status  Status              `json:"status"`
+	Entries []Entry             `json:"entries"`
+	Errors  map[string]KeyError `json:"errors"`
+}
+
+type entry struct {
+	id         string
+	processing bool
+	queuedAt   time.Time
 }
 
 type runner struct {
-	queue           workqueue.RateLimitingInterface
-	handle          func(context.Context, interface{}) error
-	mutex           sync.RWMutex
-	workerFrequency time.Duration
+	queue   workqueue.RateLimitingInterface
+	handle  func(context.Context, interface{}) error
+	mutex   sync.RWMutex
+	config  config.Runner
+	entries map[interface{}]*entry
+	errors  map[string]KeyError
+	status  Status
 }
 
-// NewRunner instantiates queue producer and consumer
+// NewRunner instantiates queue producer and consumer with default tuning
 func NewRunner() Runner {
+	return NewConfiguredRunner(config.DefaultRunner())
+}
+
+// NewConfiguredRunner instantiates queue producer and consumer with runner tuning
+func NewConfiguredRunner(r config.Runner) Runner {
 	return &runner{
-		queue:           workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
-		workerFrequency: workerFrequency,
+		queue:   workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		config:  r,
+		entries: map[interface{}]*entry{},
+		errors:  map[string]KeyError{},
 	}
 }
 
+// Tune updates runner tuning, applies from next processed entry
+func (c *runner) Tune(r config.Runner) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	runnerLog.Infof("Runner tuning updated frequency %s timeout %s max retries %d", r.WorkerFrequency, r.HandleTimeout, r.MaxRetries)
+	c.config = r
+}
+
 // Process adds entry to the processing queue
 func (c *runner) Process(e interface{}) {
+	c.mutex.Lock()
+	c.track(e)
+	c.mutex.Unlock()
+
 	c.queue.Add(e)
 }
 
+// ProcessAfter adds entry to the processing queue once delay expires, entry gets tracked when queued
+func (c *runner) ProcessAfter(e interface{}, d time.Duration) {
+	c.queue.AddAfter(e, d)
+}
+
 // Run will start ticker worker that will call handler func on each match
 func (c *runner) Run(ctx context.Context, h func(context.Context, interface{}) error) {
 	defer c.queue.ShutDown()
 
 	c.mutex.Lock()
 	c.handle = h
+	c.status.Running = true
+	c.status.Heartbeat = time.Now()
 	c.mutex.Unlock()
 
-	wait.UntilWithContext(ctx, c.worker, c.workerFrequency)
+	defer func() {
+		c.mutex.Lock()
+		c.status.Running = false
+		c.mutex.Unlock()
+	}()
+
+	c.until(ctx, c.worker)
+}
+
+// until restarts worker until context gets cancelled, frequency gets read on each restart so tuning applies
+func (c *runner) until(ctx context.Context, f func(context.Context)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		func() {
+			defer utilruntime.HandleCrash()
+			f(ctx)
+		}()
+
+		t := time.NewTimer(c.tuning().WorkerFrequency)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Status returns runner liveness status
+func (c *runner) Status() Status {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	st := c.status
+	st.QueueDepth = c.queue.Len()
+
+	return st
+}
+
+// Info returns runner status, queued entries sorted by key and last errors by key
+func (c *runner) Info() RunnerInfo {
+	st := c.Status()
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	res := RunnerInfo{Status: st, Entries: []Entry{}, Errors: map[string]KeyError{}}
+	for e, en := range c.entries {
+		key, action := entryKey(e)
+		res.Entries = append(res.Entries, Entry{
+			Key:        key,
+			Action:     action,
+			Retries:    c.queue.NumRequeues(e),
+			Processing: en.processing,
+			QueuedAt:   en.queuedAt,
+		})
+	}
+	sort.Slice(res.Entries, func(i, j int) bool {
+		if res.Entries[i].Key == res.Entries[j].Key {
+			return res.Entries[i].QueuedAt.Before(res.Entries[j].QueuedAt)
+		}
+		return res.Entries[i].Key < res.Entries[j].Key
+	})
+	for k, v := range c.errors {
+		res.Errors[k] = v
+	}
+
+	return res
+}
+
+func (c *runner) tuning() config.Runner {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.config
 }
 
 func (c *runner) worker(ctx context.Context) {
@@ -58,36 +209,120 @@ func (c *runner) worker(ctx context.Context) {
 func (c *runner) processNextItem(ctx context.Context) bool {
 	e, quit := c.queue.Get()
 	if quit {
-		log.Error("Queue goes down!")
+		runnerLog.Error("Queue goes down!")
 		return false
 	}
 	defer c.queue.Done(e)
 
-	ctx, cancel := context.WithTimeout(ctx, handleTimeout)
-	defer cancel()
+	c.beat(true)
+	defer c.beat(false)
 
 	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-	if c.handle == nil {
-		log.Fatal("no handler defined")
+	h, t := c.handle, c.config
+	c.mutex.RUnlock()
+	if h == nil {
+		runnerLog.Fatal("no handler defined")
 		return false
 	}
 
-	err := c.handle(ctx, e)
+	l := c.eventLogger(e, c.queue.NumRequeues(e)+1)
+	ctx = logger.WithLogger(ctx, l)
+	ctx, cancel := context.WithTimeout(ctx, t.HandleTimeout)
+	defer cancel()
+
+	c.processing(e, true)
+	err := h(ctx, e)
+	c.processing(e, false)
+	c.record(e, err)
 	if err == nil {
-		c.queue.Forget(e)
+		c.forget(e)
 		return true
 	}
 
-	if c.queue.NumRequeues(e) < maxRetries {
-		log.Errorf("Error processing ev %v, retry. Error: %v", e, err)
+	if c.queue.NumRequeues(e) < t.MaxRetries {
+		l.Errorf("Error processing ev %v, retry. Error: %v", e, err)
 		c.queue.AddRateLimited(e)
 		return true
 	}
 
-	log.Errorf("Error processing %v Max retries achieved: %v", e, err)
-	c.queue.Forget(e)
+	l.Errorf("Error processing %v Max retries achieved: %v", e, err)
+	c.forget(e)
 	utilruntime.HandleError(err)
 
 	return true
 }
+
+// eventLogger builds handler context logger, correlation id remains the same between entry retries
+func (c *runner) eventLogger(e interface{}, attempt int) *logrus.Entry {
+	c.mutex.Lock()
+	id := c.track(e).id
+	c.mutex.Unlock()
+
+	f := logrus.Fields{
+		logger.CorrelationIDField: id,
+		logger.AttemptField:       attempt,
+	}
+	if ev, ok := e.(Event); ok {
+		f[logger.KeyField] = ev.GetKey()
+		f[logger.ActionField] = string(ev.GetAction())
+	}
+
+	return runnerLog.WithFields(f)
+}
+
+func (c *runner) beat(processing bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.status.Processing = processing
+	c.status.Heartbeat = time.Now()
+}
+
+func (c *runner) forget(e interface{}) {
+	c.queue.Forget(e)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, e)
+}
+
+// track returns entry state, registering it if needed, caller must hold the lock
+func (c *runner) track(e interface{}) *entry {
+	en, ok := c.entries[e]
+	if !ok {
+		en = &entry{id: logger.NewCorrelationID(), queuedAt: time.Now()}
+		c.entries[e] = en
+	}
+
+	return en
+}
+
+func (c *runner) processing(e interface{}, processing bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.track(e).processing = processing
+}
+
+// record keeps last error by entry key, successful handling clears it
+func (c *runner) record(e interface{}, err error) {
+	key, _ := entryKey(e)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err == nil {
+		delete(c.errors, key)
+		return
+	}
+
+	c.errors[key] = KeyError{Error: err.Error(), Attempts: c.queue.NumRequeues(e) + 1, At: time.Now()}
+}
+
+func entryKey(e interface{}) (key, action string) {
+	if ev, ok := e.(Event); ok {
+		return ev.GetKey(), string(ev.GetAction())
+	}
+
+	return fmt.Sprint(e), ""
+}
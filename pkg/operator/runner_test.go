@@ -3,19 +3,24 @@ package operator
 import (
 	"context"
 	"errors"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	"github.com/sirupsen/logrus"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 )
 
+const maxRetries = 5
+
 func TestItConsumesProducedEntriesWithSuccess(t *testing.T) {
 	var totalCalls int32
 	f := func(context.Context, interface{}) error {
 		atomic.AddInt32(&totalCalls, 1)
 		return nil
 	}
-	r := NewRunner()
-	r.workerFrequency = time.Millisecond * 50
+	r := NewConfiguredRunner(config.Runner{WorkerFrequency: time.Millisecond * 50, HandleTimeout: time.Second, MaxRetries: maxRetries})
 	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
 	defer cancel()
 
@@ -28,14 +33,36 @@ func TestItConsumesProducedEntriesWithSuccess(t *testing.T) {
 	}
 }
 
+func TestItConsumesDelayedEntriesOnceDelayExpires(t *testing.T) {
+	var totalCalls int32
+	f := func(context.Context, interface{}) error {
+		atomic.AddInt32(&totalCalls, 1)
+		return nil
+	}
+	r := NewConfiguredRunner(config.Runner{WorkerFrequency: time.Millisecond * 10, HandleTimeout: time.Second, MaxRetries: maxRetries})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*300)
+	defer cancel()
+
+	go r.Run(ctx, f)
+	r.ProcessAfter("hello", time.Millisecond*100)
+	time.Sleep(time.Millisecond * 50)
+	if expected, got := 0, atomic.LoadInt32(&totalCalls); expected != int(got) {
+		t.Fatalf("unexpected totalCalls before delay, expected %d got %d", expected, got)
+	}
+
+	time.Sleep(time.Millisecond * 150)
+	if expected, got := 1, atomic.LoadInt32(&totalCalls); expected != int(got) {
+		t.Fatalf("unexpected totalCalls, expected %d got %d", expected, got)
+	}
+}
+
 func TestItRetriesConsumedEntriesOnHandlingErrorUntilMaxRetries(t *testing.T) {
 	var totalCalls int32
 	f := func(context.Context, interface{}) error {
 		atomic.AddInt32(&totalCalls, 1)
 		return errors.New("foo error")
 	}
-	r := NewRunner()
-	r.workerFrequency = time.Millisecond * 50
+	r := NewConfiguredRunner(config.Runner{WorkerFrequency: time.Millisecond * 50, HandleTimeout: time.Second, MaxRetries: maxRetries})
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -47,3 +74,167 @@ func TestItRetriesConsumedEntriesOnHandlingErrorUntilMaxRetries(t *testing.T) {
 		t.Fatalf("unexpected totalCalls, expected %d got %d", expected, got)
 	}
 }
+
+func TestItAppliesTunedMaxRetries(t *testing.T) {
+	var totalCalls int32
+	f := func(context.Context, interface{}) error {
+		atomic.AddInt32(&totalCalls, 1)
+		return errors.New("foo error")
+	}
+	r := NewConfiguredRunner(config.Runner{WorkerFrequency: time.Millisecond * 50, HandleTimeout: time.Second, MaxRetries: maxRetries})
+	r.Tune(config.Runner{WorkerFrequency: time.Millisecond * 50, HandleTimeout: time.Second, MaxRetries: 0})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go r.Run(ctx, f)
+	r.Process("hello")
+	time.Sleep(time.Millisecond * 200) // Let the worker run
+
+	if expected, got := 1, atomic.LoadInt32(&totalCalls); expected != int(got) {
+		t.Fatalf("unexpected totalCalls, expected %d got %d", expected, got)
+	}
+}
+
+func TestItAppliesTunedFrequencyOnWorkerRestart(t *testing.T) {
+	r := NewConfiguredRunner(config.Runner{WorkerFrequency: time.Hour, HandleTimeout: time.Second, MaxRetries: maxRetries}).(*runner)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var restarts int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.until(ctx, func(context.Context) {
+			if atomic.AddInt32(&restarts, 1) == 1 {
+				r.Tune(config.Runner{WorkerFrequency: time.Millisecond, HandleTimeout: time.Second, MaxRetries: maxRetries})
+			}
+		})
+	}()
+	time.Sleep(time.Millisecond * 100)
+	cancel()
+	<-done
+
+	if got := atomic.LoadInt32(&restarts); got < 2 {
+		t.Fatalf("expected worker restarts on tuned frequency, got %d", got)
+	}
+}
+
+func TestItAttachesEventLoggerWithStableCorrelationIDBetweenRetries(t *testing.T) {
+	var mutex sync.Mutex
+	var entries []*logrus.Entry
+	f := func(ctx context.Context, e interface{}) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		entries = append(entries, logger.FromContext(ctx))
+		if len(entries) == 1 {
+			return errors.New("foo error")
+		}
+		return nil
+	}
+	r := NewConfiguredRunner(config.Runner{WorkerFrequency: time.Millisecond * 50, HandleTimeout: time.Second, MaxRetries: maxRetries})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go r.Run(ctx, f)
+	r.Process(newCreateEvent("swarm/foo", getFakePod("swarm", "foo")))
+	time.Sleep(time.Millisecond * 200) // Let the worker run
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if expected, got := 2, len(entries); expected != got {
+		t.Fatalf("unexpected totalCalls, expected %d got %d", expected, got)
+	}
+
+	first, second := entries[0].Data, entries[1].Data
+	if first[logger.CorrelationIDField] == nil || first[logger.CorrelationIDField] != second[logger.CorrelationIDField] {
+		t.Errorf("correlation ids do not match, first %v second %v", first[logger.CorrelationIDField], second[logger.CorrelationIDField])
+	}
+	if expected, got := "swarm/foo", first[logger.KeyField]; expected != got {
+		t.Errorf("key does not match, expected %s got %v", expected, got)
+	}
+	if expected, got := string(Create), first[logger.ActionField]; expected != got {
+		t.Errorf("action does not match, expected %s got %v", expected, got)
+	}
+	if expected, got := 2, second[logger.AttemptField]; expected != got {
+		t.Errorf("attempt does not match, expected %d got %v", expected, got)
+	}
+}
+
+func TestItReportsRunnerStatusHeartbeat(t *testing.T) {
+	r := NewConfiguredRunner(config.Runner{WorkerFrequency: time.Millisecond * 50, HandleTimeout: time.Second, MaxRetries: maxRetries})
+	if r.Status().Running {
+		t.Fatal("expected not running runner")
+	}
+
+	release := make(chan struct{})
+	f := func(context.Context, interface{}) error {
+		<-release
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go r.Run(ctx, f)
+	r.Process("hello")
+	r.Process("bye")
+	time.Sleep(time.Millisecond * 100) // Let the worker run
+
+	st := r.Status()
+	if !st.Running || !st.Processing {
+		t.Fatalf("expected running and processing runner, got %+v", st)
+	}
+	if expected, got := 1, st.QueueDepth; expected != got {
+		t.Errorf("queue depth does not match, expected %d got %d", expected, got)
+	}
+
+	heartbeat := st.Heartbeat
+	close(release)
+	time.Sleep(time.Millisecond * 100)
+
+	st = r.Status()
+	if st.Processing || st.QueueDepth != 0 {
+		t.Errorf("expected idle runner, got %+v", st)
+	}
+	if !st.Heartbeat.After(heartbeat) {
+		t.Errorf("expected updated heartbeat, previous %s got %s", heartbeat, st.Heartbeat)
+	}
+}
+
+func TestItReportsQueuedEntriesAndLastErrorByKey(t *testing.T) {
+	release := make(chan struct{})
+	f := func(ctx context.Context, e interface{}) error {
+		if e.(Event).GetKey() == "swarm/bar" {
+			<-release
+			return nil
+		}
+		return errors.New("foo error")
+	}
+	r := NewConfiguredRunner(config.Runner{WorkerFrequency: time.Millisecond * 50, HandleTimeout: time.Second, MaxRetries: 0})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go r.Run(ctx, f)
+	r.Process(newCreateEvent("swarm/foo", getFakePod("swarm", "foo")))
+	r.Process(newCreateEvent("swarm/bar", getFakePod("swarm", "bar")))
+	time.Sleep(time.Millisecond * 100) // Let the worker run
+
+	info := r.Info()
+	if expected, got := 1, len(info.Entries); expected != got {
+		t.Fatalf("total entries do not match, expected %d got %d", expected, got)
+	}
+	if expected, got := "swarm/bar", info.Entries[0].Key; expected != got {
+		t.Errorf("entry key does not match, expected %s got %s", expected, got)
+	}
+	if !info.Entries[0].Processing {
+		t.Error("expected processing entry")
+	}
+	if expected, got := "foo error", info.Errors["swarm/foo"].Error; expected != got {
+		t.Errorf("last error does not match, expected %s got %s", expected, got)
+	}
+
+	close(release)
+	time.Sleep(time.Millisecond * 100)
+	if expected, got := 0, len(r.Info().Entries); expected != got {
+		t.Errorf("total entries do not match, expected %d got %d", expected, got)
+	}
+}
@@ -0,0 +1,98 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
+	v1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultConfigKey = "config.yml"
+
+// Provider implements config repository in top of secret
+type Provider struct {
+	client kubernetes.Interface
+	codec  cfg.Codec
+	key    string
+}
+
+// NewProvider instantiate secret provider encoding workloads with codec on the configured key
+func NewProvider(cl kubernetes.Interface, c cfg.Codec, key string) *Provider {
+	if key == "" {
+		key = defaultConfigKey
+	}
+
+	return &Provider{
+		client: cl,
+		codec:  c,
+		key:    key,
+	}
+}
+
+// Set updates workload assignation to secret, secret gets created if not found
+func (p *Provider) Set(ctx context.Context, namespace, name string, a *cfg.Workloads) error {
+	data, err := p.codec.Encode(a)
+	if err != nil {
+		return fmt.Errorf("unable to Marshall secret, error %v", err)
+	}
+
+	s, err := p.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		s = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Type: v1.SecretTypeOpaque,
+			Data: map[string][]byte{p.key: data},
+		}
+		if _, err := p.client.CoreV1().Secrets(namespace).Create(ctx, s, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("unable to create secret %v", err)
+		}
+		logger.FromContext(ctx).Debugf("secret %s created on namespace %s version %d", name, namespace, a.Version)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to get secret %v", err)
+	}
+
+	if s.Data == nil {
+		s.Data = map[string][]byte{}
+	}
+	s.Data[p.key] = data
+	_, err = p.client.CoreV1().Secrets(namespace).Update(ctx, s, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to update secret %v", err)
+	}
+
+	logger.FromContext(ctx).Debugf("secret %s updated on namespace %s version %d", name, namespace, a.Version)
+	return nil
+}
+
+// Get returns workload assignation from secret
+func (p *Provider) Get(ctx context.Context, namespace, name string) (*cfg.Workloads, error) {
+	s, err := p.client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get secret %v", err)
+	}
+
+	data, ok := s.Data[p.key]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found on secret %s", p.key, name)
+	}
+
+	w, err := p.codec.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode workloads from secret %v", err)
+	}
+
+	return w, nil
+}
@@ -0,0 +1,14 @@
+package secret
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage/storagetest"
+	"k8s.io/client-go/kubernetes/fake"
+	"testing"
+)
+
+func TestProvider_Conformance(t *testing.T) {
+	p := NewProvider(fake.NewSimpleClientset(), config.NewYAMLCodec(), "")
+
+	storagetest.Run(t, p, "swarm", "swarm-worker-config")
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// FileStorage writes workload assignations on local files, one per namespace and name
+type FileStorage struct {
+	path  string
+	codec cfg.Codec
+}
+
+// NewFileStorage instantiates file storage on base path
+func NewFileStorage(path string, c cfg.Codec) *FileStorage {
+	return &FileStorage{
+		path:  path,
+		codec: c,
+	}
+}
+
+// Set writes encoded workload assignations, file is replaced atomically
+func (f *FileStorage) Set(ctx context.Context, namespace, name string, a *cfg.Workloads) error {
+	data, err := f.codec.Encode(a)
+	if err != nil {
+		return fmt.Errorf("unable to encode workloads, error %v", err)
+	}
+
+	p := f.filePath(namespace, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("unable to create path %s error %v", filepath.Dir(p), err)
+	}
+
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("unable to write file %s error %v", tmp, err)
+	}
+
+	if err := os.Rename(tmp, p); err != nil {
+		return fmt.Errorf("unable to rename file %s error %v", tmp, err)
+	}
+
+	return nil
+}
+
+// Get reads workload assignations from file
+func (f *FileStorage) Get(ctx context.Context, namespace, name string) (*cfg.Workloads, error) {
+	p := f.filePath(namespace, name)
+	data, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file %s error %v", p, err)
+	}
+
+	return f.codec.Decode(data)
+}
+
+func (f *FileStorage) filePath(namespace, name string) string {
+	return filepath.Join(f.path, namespace, fmt.Sprintf("%s.%s", name, f.codec.Format()))
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	"sync"
+)
+
+// MemoryStorage keeps workload assignations in memory, useful on tests and local runs
+type MemoryStorage struct {
+	index map[string]*cfg.Workloads
+	mutex sync.RWMutex
+}
+
+// NewMemoryStorage instantiates in memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		index: map[string]*cfg.Workloads{},
+	}
+}
+
+// Set stores a copy of workload assignations
+func (m *MemoryStorage) Set(ctx context.Context, namespace, name string, a *cfg.Workloads) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.index[namespace+"/"+name] = copyWorkloads(a)
+	return nil
+}
+
+// Get returns a copy of stored workload assignations
+func (m *MemoryStorage) Get(ctx context.Context, namespace, name string) (*cfg.Workloads, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	w, ok := m.index[namespace+"/"+name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return copyWorkloads(w), nil
+}
+
+func copyWorkloads(a *cfg.Workloads) *cfg.Workloads {
+	res := &cfg.Workloads{Version: a.Version, Workloads: map[string]*cfg.Workload{}}
+	for k, w := range a.Workloads {
+		if w == nil {
+			res.Workloads[k] = nil
+			continue
+		}
+		res.Workloads[k] = &cfg.Workload{Jobs: append([]cfg.Job{}, w.Jobs...)}
+	}
+
+	return res
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+)
+
+const (
+	ConfigMap       = "configmap"
+	WorkerConfigMap = "worker-configmap"
+	Secret          = "secret"
+	Status          = "status"
+	Annotations     = "annotations"
+	Memory          = "memory"
+	File            = "file"
+)
+
+// ErrNotFound gets returned unwrapped by backends without workloads stored on namespace and name
+var ErrNotFound = errors.New("workloads not found")
+
+// Storage persists workload assignations on a backend identified by namespace and name
+type Storage interface {
+	Set(ctx context.Context, namespace, name string, a *cfg.Workloads) error
+	Get(ctx context.Context, namespace, name string) (*cfg.Workloads, error)
+}
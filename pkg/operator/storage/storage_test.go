@@ -0,0 +1,16 @@
+package storage_test
+
+import (
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage/storagetest"
+	"testing"
+)
+
+func TestMemoryStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, storage.NewMemoryStorage(), "swarm", "swarm-worker-config")
+}
+
+func TestFileStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, storage.NewFileStorage(t.TempDir(), cfg.NewYAMLCodec()), "swarm", "swarm-worker-config")
+}
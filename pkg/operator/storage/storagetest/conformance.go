@@ -0,0 +1,88 @@
+package storagetest
+
+import (
+	"context"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
+	"testing"
+)
+
+// Workers defines worker names used by conformance suite, backends bound to existing resources
+// (pod annotations) need them created in advance
+var Workers = []string{"swarm-worker-0", "swarm-worker-1", "swarm-worker-2"}
+
+// Run checks storage backend honours storage contract
+func Run(t *testing.T, s storage.Storage, namespace, name string) {
+	t.Run("ItGetsStoredWorkloads", func(t *testing.T) {
+		w := fakeWorkloads(1, len(Workers))
+		if err := s.Set(context.Background(), namespace, name, w); err != nil {
+			t.Fatalf("unexpected error setting workloads, error %v", err)
+		}
+
+		res, err := s.Get(context.Background(), namespace, name)
+		if err != nil {
+			t.Fatalf("unexpected error getting workloads, error %v", err)
+		}
+
+		if !w.Equals(res) {
+			t.Errorf("workloads do not match, expected %v got %v", w, res)
+		}
+	})
+
+	t.Run("ItOverwritesPreviousWorkloads", func(t *testing.T) {
+		w := fakeWorkloads(2, len(Workers))
+		if err := s.Set(context.Background(), namespace, name, w); err != nil {
+			t.Fatalf("unexpected error setting workloads, error %v", err)
+		}
+
+		w = fakeWorkloads(3, len(Workers)-1)
+		if err := s.Set(context.Background(), namespace, name, w); err != nil {
+			t.Fatalf("unexpected error setting workloads, error %v", err)
+		}
+
+		res, err := s.Get(context.Background(), namespace, name)
+		if err != nil {
+			t.Fatalf("unexpected error getting workloads, error %v", err)
+		}
+
+		if expected, got := len(Workers)-1, len(res.Workloads); expected != got {
+			t.Fatalf("total workers do not match, expected %d got %d", expected, got)
+		}
+
+		if !w.Equals(res) {
+			t.Errorf("workloads do not match, expected %v got %v", w, res)
+		}
+	})
+
+	t.Run("ItIsolatesWorkloadsByName", func(t *testing.T) {
+		w := fakeWorkloads(4, len(Workers))
+		if err := s.Set(context.Background(), namespace, name, w); err != nil {
+			t.Fatalf("unexpected error setting workloads, error %v", err)
+		}
+
+		res, err := s.Get(context.Background(), namespace, name+"-unknown")
+		if err == storage.ErrNotFound {
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error getting unknown name, expected %v got %v", storage.ErrNotFound, err)
+		}
+		if expected, got := 0, len(res.Workloads); expected != got {
+			t.Errorf("unknown name workloads do not match, expected %d got %d", expected, got)
+		}
+	})
+}
+
+func fakeWorkloads(version int64, workers int) *cfg.Workloads {
+	jobs := []cfg.Job{"stream:xxrtve1", "stream:xxrtve2", "stream:zrtve2", "stream:cctv0", "stream:cctv1", "stream:xabcn0"}
+	w := &cfg.Workloads{Version: version, Workloads: map[string]*cfg.Workload{}}
+	for i := 0; i < workers; i++ {
+		w.Workloads[Workers[i]] = &cfg.Workload{}
+	}
+	for i, job := range jobs {
+		wk := w.Workloads[Workers[i%workers]]
+		wk.Jobs = append(wk.Jobs, job)
+	}
+
+	return w
+}
@@ -1,15 +1,14 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
 	"github.com/gorilla/mux"
 	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	ht "github.com/marcosQuesada/k8s-lab/pkg/http/handler"
+	"github.com/marcosQuesada/k8s-lab/pkg/http/server"
 	log "github.com/sirupsen/logrus"
-	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -20,7 +19,7 @@ var internalCmd = &cobra.Command{
 	Short: "config reloader internal controller, useful on development path",
 	Long:  `config reloader controller restarts deployment/statefulset on watched configmap change`,
 	Run: func(cmd *cobra.Command, args []string) {
-		log.Infof("%s internal running release %s date %s http server on port %s", appID, cfg.Commit, cfg.Date, cfg.HttpPort)
+		log.Infof("%s internal running release %s date %s http server on port %s", appID, cfg.Commit, cfg.Date, conf.HttpPort)
 
 		//q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
 		//crdClient := k8s.BuildConfigMapPodRefresherInternalClient()
@@ -37,28 +36,17 @@ var internalCmd = &cobra.Command{
 		//informerFactory.Start(stopCh)
 
 		router := mux.NewRouter()
-		srv := &http.Server{
-			Addr:         fmt.Sprintf(":%s", cfg.HttpPort),
-			Handler:      router,
-			ReadTimeout:  10 * time.Second,
-			WriteTimeout: 10 * time.Second,
+		ht.NewLogLevel(conf.LogLevelUpdates).Routes(router)
+		srv, err := server.New(serverConfig(conf), router)
+		if err != nil {
+			log.Fatalf("unable to build http server, error %v", err)
 		}
 
-		go func(h *http.Server) {
-			log.Infof("starting server on port %s", cfg.HttpPort)
-			e := h.ListenAndServe()
-			if e != nil && e != http.ErrServerClosed {
-				log.Fatalf("Could not Listen and server, error %v", e)
-			}
-		}(srv)
-
-		sigTerm := make(chan os.Signal, 1)
-		signal.Notify(sigTerm, syscall.SIGTERM, syscall.SIGINT)
-		<-sigTerm
-		if err := srv.Close(); err != nil {
-			log.Errorf("unexpected error on http server close %v", err)
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+		defer stop()
+		if err := srv.Run(ctx); err != nil {
+			log.Errorf("unexpected error on http server %v", err)
 		}
-		_ = srv.Close()
 		//close(stopCh)
 
 		log.Info("Stopping controller")
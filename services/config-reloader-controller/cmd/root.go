@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/http/server"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"os"
@@ -25,13 +26,42 @@ func Execute() {
 	}
 }
 
+var conf = &cfg.Core{}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 	cfg.SetCoreFlags(rootCmd, appID)
 }
 
+// initConfig loads typed config from flags, env vars and optional config file, log levels get hot reloaded
 func initConfig() {
-	if err := cfg.BuildLogger(appID); err != nil {
+	l, err := cfg.NewLoader(rootCmd, nil)
+	if err != nil {
+		log.Fatalf("unable to build config loader, error %v", err)
+	}
+
+	if err := l.Load(conf); err != nil {
+		log.Fatalf("unable to load config, error %v", err)
+	}
+
+	if err := conf.BuildLogger(appID); err != nil {
 		log.Fatalf("unable to build logger, error %v", err)
 	}
+
+	l.Watch(func() cfg.Validatable { return &cfg.Core{} }, func(c cfg.Validatable) {
+		if err := c.(*cfg.Core).ApplyLevels(); err != nil {
+			log.Errorf("unable to update log levels, error %v", err)
+		}
+	})
+}
+
+// serverConfig builds http server config from core config
+func serverConfig(c *cfg.Core) server.Config {
+	sc := server.DefaultConfig(c.HttpPort)
+	sc.ShutdownTimeout = c.HttpShutdownTimeout
+	sc.TLSCert = c.TLSCert
+	sc.TLSKey = c.TLSKey
+	sc.Pprof = c.Pprof
+
+	return sc
 }
@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"github.com/davecgh/go-spew/spew"
 	"github.com/marcosQuesada/k8s-lab/pkg/operator"
 	"github.com/marcosQuesada/k8s-lab/services/config-reloader-controller/internal/infra/k8s/crd/apis/configmappodrefresher/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -46,6 +45,7 @@ func fakeConfigMapRefresher(namespace, name, c, wcm string) *v1alpha1.ConfigMapP
 }
 
 func TestWatchAllConfigMapsFromAllNamespaces(t *testing.T) {
+	t.Skip()
 	clientset := operator.BuildExternalClient()
 
 	cms, err := clientset.CoreV1().ConfigMaps("").List(context.TODO(), metav1.ListOptions{})
@@ -53,12 +53,12 @@ func TestWatchAllConfigMapsFromAllNamespaces(t *testing.T) {
 		panic(err.Error())
 	}
 
-	spew.Dump(cms)
+	t.Logf("%+v", cms)
 
 	dp, err := clientset.AppsV1().Deployments("").List(context.Background(), metav1.ListOptions{})
 	if err != nil {
 		panic(err.Error())
 	}
-	spew.Dump(dp)
+	t.Logf("%+v", dp)
 	//controllerRef := metav1.GetControllerOf(pod)
 }
@@ -2,7 +2,7 @@ package configmap
 
 import (
 	"context"
-	"github.com/davecgh/go-spew/spew"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
 	log "github.com/sirupsen/logrus"
 	api "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -20,20 +20,21 @@ func NewHandler() *Handler {
 // Created handles configmap creation event
 func (h *Handler) Created(ctx context.Context, obj runtime.Object) {
 	cm := obj.(*api.ConfigMap)
-	spew.Dump(cm)
-	log.Debugf("Created ConfigMap %s ", cm.Name)
+	fields(ctx, cm).Debugf("Created %s", cm.Name)
 }
 
 // Updated handles configmap updates event
 func (h *Handler) Updated(ctx context.Context, new, old runtime.Object) {
 	cm := new.(*api.ConfigMap)
-	spew.Dump(cm)
-	log.Debugf("Deleted ConfigMap %s", cm.Name)
+	fields(ctx, cm).Debugf("Updated %s", cm.Name)
 }
 
 // Deleted handles configmap deletion event
 func (h *Handler) Deleted(ctx context.Context, obj runtime.Object) {
 	cm := obj.(*api.ConfigMap)
-	spew.Dump(cm)
-	log.Debugf("Deleted ConfigMap %s", cm.Name)
+	fields(ctx, cm).Debugf("Deleted %s", cm.Name)
+}
+
+func fields(ctx context.Context, cm *api.ConfigMap) *log.Entry {
+	return logger.ObjectFromContext(ctx, "ConfigMap", cm, log.Fields{"keys": len(cm.Data) + len(cm.BinaryData)})
 }
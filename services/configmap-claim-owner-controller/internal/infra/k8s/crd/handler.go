@@ -2,7 +2,7 @@ package crd
 
 import (
 	"context"
-	"github.com/davecgh/go-spew/spew"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
 	"github.com/marcosQuesada/k8s-lab/services/configmap-claim-owner-controller/internal/infra/k8s/crd/apis/configmapownerclaim/v1alpha1"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -20,20 +20,25 @@ func NewHandler() *Handler {
 // Created handles configmap creation event
 func (h *Handler) Created(ctx context.Context, obj runtime.Object) {
 	cm := obj.(*v1alpha1.ConfigMapClaimOwner)
-	spew.Dump(cm)
-	log.Infof("Created ConfigMap %s ", cm.Name)
+	fields(ctx, cm).Infof("Created %s", cm.Name)
 }
 
 // Updated handles configmap updates event
 func (h *Handler) Updated(ctx context.Context, new, old runtime.Object) {
 	cm := new.(*v1alpha1.ConfigMapClaimOwner)
-	spew.Dump(cm)
-	log.Infof("Deleted ConfigMap %s", cm.Name)
+	fields(ctx, cm).Infof("Updated %s", cm.Name)
 }
 
 // Deleted handles configmap deletion event
 func (h *Handler) Deleted(ctx context.Context, obj runtime.Object) {
 	cm := obj.(*v1alpha1.ConfigMapClaimOwner)
-	spew.Dump(cm)
-	log.Infof("Deleted ConfigMap %s", cm.Name)
+	fields(ctx, cm).Infof("Deleted %s", cm.Name)
+}
+
+func fields(ctx context.Context, cm *v1alpha1.ConfigMapClaimOwner) *log.Entry {
+	return logger.ObjectFromContext(ctx, "ConfigMapClaimOwner", cm, log.Fields{
+		"configMap": cm.Spec.ConfigMap,
+		"ownerType": cm.Spec.OwnerType,
+		"ownerName": cm.Spec.OwnerName,
+	})
 }
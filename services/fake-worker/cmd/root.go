@@ -1,16 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/http/server"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/configmap"
 	cfg2 "github.com/marcosQuesada/k8s-lab/services/fake-worker/internal/config"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"os"
+	"path/filepath"
 )
 
 const appID = "swarm-worker"
 
+// workerConfig mirrors worker own configmap or sharded configmap shard on config file, nil on mounted configs
+var workerConfig *configmap.FileSync
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "root",
@@ -25,13 +33,44 @@ func Execute() {
 	}
 }
 
+var conf = &cfg.Core{}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
-	if err := cfg.BuildLogger(appID); err != nil {
-		log.Fatalf("unable to unMarshall config, error %v", err)
+	l, err := cfg.NewLoader(rootCmd, nil)
+	if err != nil {
+		log.Fatalf("unable to build config loader, error %v", err)
+	}
+
+	if err := l.Load(conf); err != nil {
+		log.Fatalf("unable to load config, error %v", err)
+	}
+
+	if err := conf.BuildLogger(appID); err != nil {
+		log.Fatalf("unable to build logger, error %v", err)
 	}
 
-	if err := cfg2.LoadConfig(cfg.ConfigFilePath, cfg.ConfigFile); err != nil {
+	// per worker configmaps get mirrored on config file, only workload changes touch it
+	if name := cfg2.WorkerConfigMap(); name != "" && cfg2.Namespace() != "" {
+		cmName, err := configmap.WorkerConfigMapName(name, cfg2.HostName(DefaultHostName))
+		if err != nil {
+			log.Fatalf("unable to build worker config map name, error %v", err)
+		}
+		workerConfig = configmap.NewFileSync(operator.BuildInternalClient(), cfg2.Namespace(), cmName, "", filepath.Join(conf.ConfigFilePath, conf.ConfigFile))
+		if err := workerConfig.Fetch(context.Background()); err != nil {
+			log.Fatalf("unable to fetch worker config map, error %v", err)
+		}
+	}
+
+	// sharded configmaps get followed through its index manifest to the shard holding worker workload
+	if name := cfg2.ShardedConfigMap(); name != "" && cfg2.Namespace() != "" {
+		workerConfig = configmap.NewShardedFileSync(operator.BuildInternalClient(), cfg2.Namespace(), name, cfg2.HostName(DefaultHostName), "", filepath.Join(conf.ConfigFilePath, conf.ConfigFile))
+		if err := workerConfig.Fetch(context.Background()); err != nil {
+			log.Fatalf("unable to fetch sharded config map, error %v", err)
+		}
+	}
+
+	if err := cfg2.LoadConfig(conf.ConfigFilePath, conf.ConfigFile); err != nil {
 		log.Fatalf("unable to unMarshall config, error %v", err)
 	}
 }
@@ -41,3 +80,14 @@ func init() {
 
 	cfg.SetCoreFlags(rootCmd, "fake-worker")
 }
+
+// serverConfig builds http server config from core config
+func serverConfig(c *cfg.Core) server.Config {
+	sc := server.DefaultConfig(c.HttpPort)
+	sc.ShutdownTimeout = c.HttpShutdownTimeout
+	sc.TLSCert = c.TLSCert
+	sc.TLSKey = c.TLSKey
+	sc.Pprof = c.Pprof
+
+	return sc
+}
@@ -1,18 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"github.com/fsnotify/fsnotify"
 	"github.com/gorilla/mux"
 	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
 	ht "github.com/marcosQuesada/k8s-lab/pkg/http/handler"
+	"github.com/marcosQuesada/k8s-lab/pkg/http/server"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/lease"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/pod"
 	app2 "github.com/marcosQuesada/k8s-lab/services/fake-worker/internal/app"
 	cfg2 "github.com/marcosQuesada/k8s-lab/services/fake-worker/internal/config"
 	htv "github.com/marcosQuesada/k8s-lab/services/fake-worker/internal/transport/http"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
-	"net/http"
-	"os"
 	"os/signal"
 	"syscall"
 	"time"
@@ -22,10 +25,29 @@ import (
 
 const DefaultHostName = "swarm-worker-0"
 
+// leaseRenewPeriod is used until held leases report its duration, then leases get renewed three times per duration
+const leaseRenewPeriod = 5 * time.Second
+
 type Processor interface {
 	Assign(w *cfg.Workload) error
 }
 
+// Acknowledger reports worker applied workload version
+type Acknowledger interface {
+	Acknowledge(ctx context.Context, namespace, name string, version int64) error
+}
+
+// LeaseKeeper renews and releases worker job leases
+type LeaseKeeper interface {
+	Renew(ctx context.Context, namespace, swarm, holder string, jobs []cfg.Job) ([]cfg.Job, time.Duration, error)
+	Release(ctx context.Context, namespace, swarm, holder string, jobs []cfg.Job) error
+}
+
+// Holder runs jobs held by lease
+type Holder interface {
+	Hold(jobs []cfg.Job)
+}
+
 // workerCmd represents the worker command
 var workerCmd = &cobra.Command{
 	Use:   "worker",
@@ -35,13 +57,24 @@ var workerCmd = &cobra.Command{
 		name := cfg2.HostName(DefaultHostName)
 		log.Infof("worker %s started", name)
 		app := app2.NewApp()
-		if err := updateWorkloadFromConfig(app); err != nil {
+
+		// applied versions get acknowledged annotating worker pod and job leases kept, only when running in cluster
+		var ack Acknowledger
+		var keeper LeaseKeeper
+		if cfg2.Namespace() != "" {
+			cl := operator.BuildInternalClient()
+			ack = pod.NewVersionAcknowledger(cl)
+			if cfg2.Swarm() != "" {
+				keeper = lease.NewKeeper(cl)
+			}
+		}
+		if err := updateWorkloadFromConfig(app, ack); err != nil {
 			log.Errorf("unable to watch keys, %v", err)
 		}
 
 		viper.OnConfigChange(func(e fsnotify.Event) {
 			log.Info("Config file changed")
-			if err := updateWorkloadFromConfig(app); err != nil {
+			if err := updateWorkloadFromConfig(app, ack); err != nil {
 				log.Errorf("unable to watch keys, %v", err)
 			}
 		})
@@ -49,31 +82,26 @@ var workerCmd = &cobra.Command{
 		go app.Run() // @TODO: Right now just a mock
 
 		router := mux.NewRouter()
+		ht.NewLogLevel(conf.LogLevelUpdates).Routes(router)
 		ch := ht.NewChecker(cfg.Commit, cfg.Date)
 		ch.Routes(router)
 		vCh := htv.NewVersionChecker(cfg2.NewVersionAdapter(cfg2.HostName(DefaultHostName)))
 		vCh.Routes(router)
-		srv := &http.Server{
-			Addr:         fmt.Sprintf(":%s", cfg.HttpPort),
-			Handler:      router,
-			ReadTimeout:  10 * time.Second,
-			WriteTimeout: 10 * time.Second,
+		srv, err := server.New(serverConfig(conf), router)
+		if err != nil {
+			log.Fatalf("unable to build http server, error %v", err)
 		}
 
-		go func(h *http.Server) {
-			log.Infof("starting server on port %s", cfg.HttpPort)
-			e := h.ListenAndServe()
-			if e != nil && e != http.ErrServerClosed {
-				log.Fatalf("Could not Listen and server, error %v", e)
-			}
-		}(srv)
-
-		sigTerm := make(chan os.Signal, 1)
-		signal.Notify(sigTerm, syscall.SIGTERM, syscall.SIGINT)
-		<-sigTerm
-
-		if err := srv.Close(); err != nil {
-			log.Errorf("unexpected error on http server close %v", err)
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+		defer stop()
+		if keeper != nil {
+			go keepLeases(ctx, keeper, app)
+		}
+		if workerConfig != nil {
+			go workerConfig.Run(ctx)
+		}
+		if err := srv.Run(ctx); err != nil {
+			log.Errorf("unexpected error on http server %v", err)
 		}
 		app.Terminate()
 	},
@@ -83,9 +111,9 @@ func init() {
 	rootCmd.AddCommand(workerCmd)
 }
 
-func updateWorkloadFromConfig(mng Processor) error {
-	if err := cfg2.LoadConfig(cfg.ConfigFilePath, cfg.ConfigFile); err != nil {
-		log.Fatalf("unable to unMarshall config, error %v", err)
+func updateWorkloadFromConfig(mng Processor, ack Acknowledger) error {
+	if err := cfg2.LoadConfig(conf.ConfigFilePath, conf.ConfigFile); err != nil {
+		return fmt.Errorf("unable to load config, error %v", err)
 	}
 
 	wl, err := cfg2.HostWorkLoad(cfg2.HostName(DefaultHostName))
@@ -99,5 +127,69 @@ func updateWorkloadFromConfig(mng Processor) error {
 		return fmt.Errorf("unable to start manager, error %v", err)
 	}
 
-	return nil
+	if ack == nil {
+		return nil
+	}
+
+	return ack.Acknowledge(context.Background(), cfg2.Namespace(), cfg2.HostName(DefaultHostName), cfg2.Version())
+}
+
+// keepLeases renews assigned job leases, only held jobs keep running and unassigned job leases get released.
+// Held jobs stop once its leases expire without renewal, as they may get transferred to other workers
+func keepLeases(ctx context.Context, k LeaseKeeper, h Holder) {
+	namespace, swarm, name := cfg2.Namespace(), cfg2.Swarm(), cfg2.HostName(DefaultHostName)
+	period := leaseRenewPeriod
+
+	var assigned []cfg.Job
+	var expiry time.Time
+	for {
+		var jobs []cfg.Job
+		if wl, err := cfg2.HostWorkLoad(name); err == nil {
+			jobs = wl.Jobs
+		}
+
+		start := time.Now()
+		rctx, cancel := renewContext(ctx, expiry)
+		held, duration, err := k.Renew(rctx, namespace, swarm, name, jobs)
+		cancel()
+		switch {
+		case err == nil:
+			h.Hold(held)
+			expiry = time.Time{}
+			if duration > 0 {
+				period, expiry = duration/3, start.Add(duration)
+			}
+		case !expiry.IsZero() && time.Now().After(expiry):
+			log.Errorf("job leases expired, stopping held jobs, error %v", err)
+			h.Hold(nil)
+			expiry = time.Time{}
+		default:
+			log.Errorf("unable to renew job leases, error %v", err)
+		}
+
+		_, released := (&cfg.Workload{Jobs: assigned}).Difference(&cfg.Workload{Jobs: jobs})
+		if err := k.Release(ctx, namespace, swarm, name, released); err != nil {
+			log.Errorf("unable to release job leases, error %v", err)
+		}
+		assigned = jobs
+
+		select {
+		case <-ctx.Done():
+			h.Hold(nil)
+			if err := k.Release(context.Background(), namespace, swarm, name, assigned); err != nil {
+				log.Errorf("unable to release job leases, error %v", err)
+			}
+			return
+		case <-time.After(period):
+		}
+	}
+}
+
+// renewContext bounds lease renewals to held leases expiry, stuck renewals must not keep jobs running
+func renewContext(ctx context.Context, expiry time.Time) (context.Context, context.CancelFunc) {
+	if expiry.IsZero() {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithDeadline(ctx, expiry)
 }
@@ -8,6 +8,7 @@ import (
 
 type App struct {
 	state *cfg.Workload
+	held  []cfg.Job
 	mutex sync.Mutex
 }
 
@@ -31,6 +32,18 @@ func (a *App) Assign(w *cfg.Workload) error {
 	return nil
 }
 
+// Hold updates jobs whose lease is held by the worker, only held jobs get run
+func (a *App) Hold(jobs []cfg.Job) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	i, e := (&cfg.Workload{Jobs: a.held}).Difference(&cfg.Workload{Jobs: jobs})
+	if len(i) > 0 || len(e) > 0 {
+		log.Infof("Held jobs updated starts %v stops %v", i, e)
+	}
+	a.held = jobs
+}
+
 func (a *App) Run() {
 	// @TODO: Pending to implement
 }
@@ -32,6 +32,21 @@ func (cfg *Config) HostWorkLoad(host string) (*config.Workload, error) {
 	return v, nil
 }
 
+// Validate checks workloads consistency, version regressions get accepted with a warning as a recreated
+// swarm restarts its versions, rejecting them would keep the worker on the stale config forever
+func (cfg *Config) Validate(previous *Config) error {
+	w := &config.Workloads{Workloads: cfg.Workload, Version: cfg.Version}
+	if err := w.Validate(nil); err != nil {
+		return err
+	}
+
+	if cfg.Version < previous.Version {
+		log.Warnf("config version regression from %d to %d, swarm assignation got reset", previous.Version, cfg.Version)
+	}
+
+	return nil
+}
+
 // HostWorkLoad gets assignation Workload to the Host
 func HostWorkLoad(host string) (*config.Workload, error) {
 	workloadsMutex.RLock()
@@ -79,6 +94,26 @@ func HostName(defaultHostName string) string {
 	return p
 }
 
+// Namespace returns worker pod namespace, empty out of cluster
+func Namespace() string {
+	return os.Getenv("POD_NAMESPACE")
+}
+
+// Swarm returns worker swarm name, empty disables job leases
+func Swarm() string {
+	return os.Getenv("SWARM_NAME")
+}
+
+// WorkerConfigMap returns per worker configmaps assignation name, empty reads config from mounted file
+func WorkerConfigMap() string {
+	return os.Getenv("WORKER_CONFIGMAP")
+}
+
+// ShardedConfigMap returns sharded index configmap name, worker config gets read from its manifest shard
+func ShardedConfigMap() string {
+	return os.Getenv("SHARDED_CONFIGMAP")
+}
+
 func LoadConfig(configFilePath, configFile string) error {
 	viper.AddConfigPath(configFilePath)
 	viper.SetConfigName(configFile)
@@ -90,13 +125,20 @@ func LoadConfig(configFilePath, configFile string) error {
 
 	log.Infof("Using config file: %s", viper.ConfigFileUsed())
 
-	workloadsMutex.Lock()
-	defer workloadsMutex.Unlock()
-	if err := viper.Unmarshal(&workloadsConfig); err != nil {
+	// keep watching even on invalid configs, a later fixed version will be picked up
+	viper.WatchConfig()
+
+	c := Config{}
+	if err := viper.Unmarshal(&c); err != nil {
 		return fmt.Errorf("unable to unMarshall config, error %v", err)
 	}
 
-	viper.WatchConfig()
+	workloadsMutex.Lock()
+	defer workloadsMutex.Unlock()
+	if err := c.Validate(&workloadsConfig); err != nil {
+		return fmt.Errorf("invalid config version %d, error %v", c.Version, err)
+	}
+	workloadsConfig = c
 
 	return nil
 }
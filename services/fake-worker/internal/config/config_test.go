@@ -1,6 +1,7 @@
 package config
 
 import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
 	"testing"
 )
 
@@ -26,3 +27,25 @@ func TestItLoadsConfigFromFileAndDumpsSlaveKeySetConfigByHostName(t *testing.T)
 		t.Errorf("expected keys do not match, expected %d got %d", expected, got)
 	}
 }
+
+func TestConfig_ValidateRejectsDuplicatedJobsAndAcceptsVersionRegressions(t *testing.T) {
+	previous := &Config{Version: 2}
+	c := &Config{
+		Version: 3,
+		Workload: map[string]*config.Workload{
+			"swarm-worker-0": {Jobs: []config.Job{"foo"}},
+			"swarm-worker-1": {Jobs: []config.Job{"foo"}},
+		},
+	}
+	if err := c.Validate(previous); err == nil {
+		t.Fatal("expected error on duplicated jobs")
+	}
+
+	c = &Config{
+		Version:  1,
+		Workload: map[string]*config.Workload{"swarm-worker-0": {Jobs: []config.Job{"foo"}}},
+	}
+	if err := c.Validate(previous); err != nil {
+		t.Fatalf("unexpected error on version regression, error %v", err)
+	}
+}
@@ -15,6 +15,11 @@ type provider interface {
 	Workload() *config.Workload
 }
 
+type versionResponse struct {
+	Version int64        `json:"version"`
+	Jobs    []config.Job `json:"jobs"`
+}
+
 // VersionChecker handles health checker handler, replying commit version and release date
 type VersionChecker struct {
 	accessor provider
@@ -27,7 +32,7 @@ func NewVersionChecker(p provider) *VersionChecker {
 	}
 }
 
-// versionHandler replies current release hash and date
+// versionHandler replies applied workload version and worker jobs
 func (a *VersionChecker) versionHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set(httpPkg.ContentType, httpPkg.JSONContentType)
 	log.Infof("requested version, got %d jobs %v", a.accessor.Version(), a.accessor.Workload())
@@ -36,8 +41,9 @@ func (a *VersionChecker) versionHandler(w http.ResponseWriter, r *http.Request)
 	if a.accessor.Workload() != nil {
 		jobs = a.accessor.Workload().Jobs
 	}
-	wrk := &config.Workload{
-		Jobs: jobs,
+	wrk := &versionResponse{
+		Version: a.accessor.Version(),
+		Jobs:    jobs,
 	}
 	if err := json.NewEncoder(w).Encode(wrk); err != nil {
 		log.Errorf("Unexpected error Marshalling version, error %v", err)
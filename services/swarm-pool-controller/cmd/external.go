@@ -2,27 +2,29 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 	"github.com/gorilla/mux"
 	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	ht "github.com/marcosQuesada/k8s-lab/pkg/http/handler"
+	"github.com/marcosQuesada/k8s-lab/pkg/http/server"
 	"github.com/marcosQuesada/k8s-lab/pkg/operator"
-	"github.com/marcosQuesada/k8s-lab/pkg/operator/configmap"
 	crdop "github.com/marcosQuesada/k8s-lab/pkg/operator/crd"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/lease"
+	podop "github.com/marcosQuesada/k8s-lab/pkg/operator/pod"
 	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/app"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/balancer"
 	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s"
 	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd"
 	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/apis/swarm/v1alpha1"
 	crdinformers "github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/generated/informers/externalversions"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/pod"
 	statefulset "github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/statefulset"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/tools/cache"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 )
 
 // externalCmd represents the external command
@@ -31,8 +33,8 @@ var externalCmd = &cobra.Command{
 	Short: "swarm pool external controller, useful on development path",
 	Long:  `swarm pool internal controller balance configured keys between swarm peers, useful on development path`,
 	Run: func(cmd *cobra.Command, args []string) {
-		log.Infof("controller external listening on namespace %s label %s Version %s release date %s http server on port %s", namespace, watchLabel, cfg.Commit, cfg.Date, cfg.HttpPort)
-		ctx, cancel := context.WithCancel(context.Background())
+		log.Infof("controller external listening on namespace %s label %s Version %s release date %s http server on port %s", conf.Namespace, conf.WatchLabel, cfg.Commit, cfg.Date, conf.HttpPort)
+		ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
 		defer cancel()
 		clientSet := operator.BuildExternalClient()
 		swarmClientSet := k8s.BuildSwarmExternalClient()
@@ -48,59 +50,108 @@ var externalCmd = &cobra.Command{
 
 		swi := crdif.K8slab().V1alpha1().Swarms().Informer()
 		stsi := sif.Apps().V1().StatefulSets().Informer()
+		di := sif.Apps().V1().Deployments().Informer()
 		podi := sif.Core().V1().Pods().Informer()
 
-		crdif.Start(ctx.Done())
-		sif.Start(ctx.Done())
-
-		if !cache.WaitForNamedCacheSync(v1alpha1.CrdKind, ctx.Done(), podi.HasSynced, swi.HasSynced, stsi.HasSynced) {
-			log.Fatal("unable to sync pod informer")
-		}
-
 		swl := crdif.K8slab().V1alpha1().Swarms().Lister()
 		stsl := sif.Apps().V1().StatefulSets().Lister()
+		dl := sif.Apps().V1().Deployments().Lister()
 		podl := sif.Core().V1().Pods().Lister()
 
-		cmp := configmap.NewProvider(clientSet)
-		ex := app.NewExecutor(cmp, nil) // @TODO: Add Refresher Option
-		appm := app.NewManager(ex, swl)
+		str, err := newStorageRegistry(clientSet, swarmClientSet)
+		if err != nil {
+			log.Fatalf("unable to build workload storages, error %v", err)
+		}
+		ex, err := app.NewExecutor(conf.Storage, str, podop.NewProvider(clientSet))
+		if err != nil {
+			log.Fatalf("unable to build executor, error %v", err)
+		}
+		appm := app.NewManager(ex, swl, balancer.NewRegistry(conf.BalanceTolerance), conf.HandoffTimeout, conf.UnhealthyGrace)
 		selSt := statefulset.NewSelectorStore()
-		pr := app.NewProvider(swl, stsl, podl)
-		ctl := app.NewSwarmController(swarmClientSet, selSt, appm, pr, operator.NewRunner())
-		go ctl.Run(ctx)
-
+		pr := app.NewProvider(swl, stsl, dl, podl)
+		var leases app.JobLeases
+		if conf.JobLeaseDuration > 0 {
+			leases = lease.NewProvider(clientSet, conf.JobLeaseDuration)
+		}
+		ctl := app.NewSwarmController(swarmClientSet, selSt, appm, pr, newRunner("swarm"), k8s.NewRecorder(clientSet, "swarm-pool-controller"), leases)
 		crdh := crd.NewHandler(ctl)
-		swCtl := operator.New(crdh, swi, operator.NewRunner(), v1alpha1.CrdKind)
-		go swCtl.Run(ctx)
-
+		swCtl := operator.New(crdh, swi, newRunner("swarm-crd"), v1alpha1.CrdKind)
 		stsh := statefulset.NewHandler(ctl, selSt)
-		stsCtl := operator.New(stsh, stsi, operator.NewRunner(), "StatefulSet")
-		go stsCtl.Run(ctx)
+		stsCtl := operator.New(stsh, stsi, newRunner("statefulset"), "StatefulSet")
+		podh := pod.NewHandler(ctl, selSt)
+		podCtl := operator.New(podh, podi, newRunner("pod"), "Pod")
+
+		health := ht.NewHealth(cfg.Commit, cfg.Date)
+		health.AddReadiness("informers", ht.InformersSyncedCheck(map[string]cache.InformerSynced{
+			"swarm":       swi.HasSynced,
+			"statefulset": stsi.HasSynced,
+			"deployment":  di.HasSynced,
+			"pod":         podi.HasSynced,
+		}))
+		health.AddReadiness("crd", ht.CRDEstablishedCheck(m, v1alpha1.Name))
+		addRunnerChecks(health)
+
+		run := func(ctx context.Context) {
+			go ctl.Run(ctx)
+			go swCtl.Run(ctx)
+			go stsCtl.Run(ctx)
+			go podCtl.Run(ctx)
+			<-ctx.Done()
+		}
+
+		// standby replicas keep informers synced and wait for the leader lease to start controllers
+		var le *operator.LeaderElection
+		if conf.LeaderElect {
+			identity, err := os.Hostname()
+			if err != nil {
+				log.Fatalf("unable to get leader election identity, error %v", err)
+			}
+			le, err = operator.NewLeaderElection(clientSet, conf.Namespace, conf.LeaderLease, identity, conf.LeaderLeaseTime, run)
+			if err != nil {
+				log.Fatalf("unable to build leader election, error %v", err)
+			}
+			health.AddReadiness("leader", ht.LeaderCheck(identity, le.IsLeader))
+		}
+
+		admin := ht.NewAdmin()
+		admin.Add("swarm", func() interface{} { return ctl.Info() })
+		admin.Add("swarm-crd", func() interface{} { return swCtl.Info() })
+		admin.Add("statefulset", func() interface{} { return stsCtl.Info() })
+		admin.Add("pod", func() interface{} { return podCtl.Info() })
 
 		router := mux.NewRouter()
-		srv := &http.Server{
-			Addr:         fmt.Sprintf(":%s", cfg.HttpPort),
-			Handler:      router,
-			ReadTimeout:  10 * time.Second,
-			WriteTimeout: 10 * time.Second,
+		ht.NewLogLevel(conf.LogLevelUpdates).Routes(router)
+		health.Routes(router)
+		admin.Routes(router)
+		srv, err := server.New(serverConfig(&conf.Core), router)
+		if err != nil {
+			log.Fatalf("unable to build http server, error %v", err)
 		}
 
-		go func(h *http.Server) {
-			log.Infof("starting server on port %s", cfg.HttpPort)
-			e := h.ListenAndServe()
-			if e != nil && e != http.ErrServerClosed {
-				log.Fatalf("Could not Listen and server, error %v", e)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if err := srv.Run(ctx); err != nil {
+				log.Fatalf("unexpected error on http server %v", err)
 			}
-		}(srv)
+		}()
+
+		crdif.Start(ctx.Done())
+		sif.Start(ctx.Done())
+
+		if !cache.WaitForNamedCacheSync(v1alpha1.CrdKind, ctx.Done(), podi.HasSynced, swi.HasSynced, stsi.HasSynced, di.HasSynced) {
+			log.Fatal("unable to sync pod informer")
+		}
 
-		sigTerm := make(chan os.Signal, 1)
-		signal.Notify(sigTerm, syscall.SIGTERM, syscall.SIGINT)
-		<-sigTerm
-		if err := srv.Close(); err != nil {
-			log.Errorf("unexpected error on http server close %v", err)
+		if le == nil {
+			run(ctx)
+		} else {
+			le.Run(ctx)
+			if ctx.Err() == nil {
+				log.Fatal("leader election lease lost")
+			}
 		}
-		cancel()
-		_ = srv.Close()
+		<-done
 
 		log.Info("Stopping controller")
 	},
@@ -3,6 +3,10 @@ package cmd
 import (
 	"fmt"
 	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	ht "github.com/marcosQuesada/k8s-lab/pkg/http/handler"
+	"github.com/marcosQuesada/k8s-lab/pkg/http/server"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/config"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"os"
@@ -11,9 +15,8 @@ import (
 const appID = "swarm-pool-controller"
 
 var (
-	namespace            string
-	watchLabel           string
-	workersConfigMapName string
+	conf    = &config.Config{}
+	runners = map[string]operator.Runner{}
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -30,29 +33,69 @@ func Execute() {
 	}
 }
 
-// initConfig reads in config file and ENV variables if set.
+// initConfig loads typed config from flags, env vars and optional config file, log levels and runner tuning get hot reloaded
 func initConfig() {
-	if err := cfg.BuildLogger(appID); err != nil {
+	l, err := cfg.NewLoader(rootCmd, config.EnvAliases)
+	if err != nil {
+		log.Fatalf("unable to build config loader, error %v", err)
+	}
+
+	if err := l.Load(conf); err != nil {
+		log.Fatalf("unable to load config, error %v", err)
+	}
+
+	if err := conf.BuildLogger(appID); err != nil {
 		log.Fatalf("unable to build logger, error %v", err)
 	}
+
+	l.Watch(func() cfg.Validatable { return &config.Config{} }, reload)
 }
 
-func init() {
-	cobra.OnInitialize(initConfig)
-	cfg.SetCoreFlags(rootCmd, appID)
+func reload(c cfg.Validatable) {
+	n := c.(*config.Config)
+	if conf.RequiresRestart(n) {
+		log.Warn("config changes apart from log levels and runner tuning require restart")
+	}
 
-	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "swarm", "namespace to listen")
-	if p := os.Getenv("NAMESPACE"); p != "" {
-		namespace = p
+	if err := n.ApplyLevels(); err != nil {
+		log.Errorf("unable to update log levels, error %v", err)
 	}
 
-	rootCmd.PersistentFlags().StringVar(&watchLabel, "label", "swarm-worker", "label to watch statefulsets and pods")
-	if p := os.Getenv("WATCHED_LABEL"); p != "" {
-		watchLabel = p
+	for _, r := range runners {
+		r.Tune(n.Runner)
 	}
+}
+
+// newRunner builds named runner from config, its tuning gets updated on config reloads
+func newRunner(name string) operator.Runner {
+	r := operator.NewConfiguredRunner(conf.Runner)
+	runners[name] = r
 
-	rootCmd.PersistentFlags().StringVar(&workersConfigMapName, "configmap", "swarm-worker-config", "workers configmap name")
-	if p := os.Getenv("WORKERS_CONFIGMAP_NAME"); p != "" {
-		workersConfigMapName = p
+	return r
+}
+
+// addRunnerChecks registers heartbeat liveness and queue depth readiness checks from all built runners
+func addRunnerChecks(h *ht.Health) {
+	for name, r := range runners {
+		h.AddLiveness("runner-"+name, ht.RunnerHeartbeatCheck(r, conf.HeartbeatTimeout))
+		h.AddReadiness("queue-"+name, ht.QueueDepthCheck(r, conf.MaxQueueDepth))
 	}
 }
+
+func init() {
+	cobra.OnInitialize(initConfig)
+	cfg.SetCoreFlags(rootCmd, appID)
+	cfg.SetRunnerFlags(rootCmd)
+	config.SetFlags(rootCmd)
+}
+
+// serverConfig builds http server config from core config
+func serverConfig(c *cfg.Core) server.Config {
+	sc := server.DefaultConfig(c.HttpPort)
+	sc.ShutdownTimeout = c.HttpShutdownTimeout
+	sc.TLSCert = c.TLSCert
+	sc.TLSKey = c.TLSKey
+	sc.Pprof = c.Pprof
+
+	return sc
+}
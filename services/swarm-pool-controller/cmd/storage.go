@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/configmap"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/pod"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/secret"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/app"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/generated/clientset/versioned"
+	"k8s.io/client-go/kubernetes"
+)
+
+// newStorageRegistry builds all workload storage backends from config
+func newStorageRegistry(cl kubernetes.Interface, swarmCl versioned.Interface) (app.StorageRegistry, error) {
+	codec, err := cfg.NewCodec(conf.ConfigMapFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	r := app.StorageRegistry{
+		storage.ConfigMap:       configmap.NewCodecProvider(cl, codec, conf.ConfigMapKey, conf.ConfigMapBinary),
+		storage.WorkerConfigMap: configmap.NewWorkerProvider(cl, codec, conf.ConfigMapKey, conf.ConfigMapBinary),
+		storage.Secret:          secret.NewProvider(cl, codec, conf.ConfigMapKey),
+		storage.Status:          crd.NewStatusStorage(swarmCl),
+		storage.Annotations:     pod.NewAnnotationProvider(cl),
+		storage.Memory:          storage.NewMemoryStorage(),
+	}
+
+	if conf.StoragePath != "" {
+		r[storage.File] = storage.NewFileStorage(conf.StoragePath, codec)
+	}
+
+	if conf.ConfigMapSharding != "" {
+		sp, err := configmap.NewShardedProvider(cl, codec, conf.ConfigMapKey, conf.ConfigMapBinary, configmap.ShardMode(conf.ConfigMapSharding), configmap.MaxConfigMapSize)
+		if err != nil {
+			return nil, err
+		}
+		r[storage.ConfigMap] = sp
+	}
+
+	return r, nil
+}
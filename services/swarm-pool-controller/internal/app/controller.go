@@ -3,19 +3,39 @@ package app
 import (
 	"context"
 	"fmt"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
 	"github.com/marcosQuesada/k8s-lab/pkg/operator"
 	swapi "github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/apis/swarm/v1alpha1"
 	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/generated/clientset/versioned"
 	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/statefulset"
-	log "github.com/sirupsen/logrus"
 	api "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"reflect"
+	"sort"
+	"time"
 )
 
 type Manager interface {
-	Process(ctx context.Context, namespace, name string, version int64, workloads []swapi.Job)
+	Process(ctx context.Context, namespace, name string, spec swapi.SwarmSpec) error
 	UpdateSize(ctx context.Context, namespace, name string, size int) (version int64, err error)
+	Handoff(ctx context.Context, namespace, name string, versions map[string]int64, pods map[string]types.UID) (time.Duration, error)
+	UpdateHealth(ctx context.Context, namespace, name string, notReady map[string]time.Time) (time.Duration, error)
+	UpdateSlots(ctx context.Context, namespace, name string, pods []string, size int, previous map[string]string) (map[string]string, error)
+	Slots(namespace, name string) map[string]string
 	Delete(ctx context.Context, namespace, name string)
+	Pool(namespace, name string) (PoolInfo, bool)
+	Pools() []PoolInfo
+}
+
+// SwarmInfo reports swarm controller runner, registered selectors and in memory pools
+type SwarmInfo struct {
+	Runner    operator.RunnerInfo `json:"runner"`
+	Selectors map[string]string   `json:"selectors"`
+	Pools     []PoolInfo          `json:"pools"`
 }
 
 type Provider interface {
@@ -23,6 +43,17 @@ type Provider interface {
 	StatefulSet(namespace, name string) (*api.StatefulSet, error)
 	PodNamesFromSelector(namespace string, ls *metav1.LabelSelector) ([]string, error)
 	SwarmNameFromStatefulSetName(namespace, name string) (string, error)
+	AppliedVersions(namespace string, ls *metav1.LabelSelector) (map[string]int64, error)
+	NotReadyPods(namespace string, ls *metav1.LabelSelector) (map[string]time.Time, error)
+	PodUIDs(namespace string, ls *metav1.LabelSelector) (map[string]types.UID, error)
+	SlotPool(namespace string, spec swapi.SwarmSpec) (*metav1.LabelSelector, int, error)
+}
+
+// JobLeases enforces job ownership keeping one lease per job held by its assigned worker
+type JobLeases interface {
+	Sync(ctx context.Context, namespace, swarm string, owners map[config.Job]string) ([]config.Job, error)
+	Delete(ctx context.Context, namespace, swarm string) error
+	Duration() time.Duration
 }
 
 // swarmController linearize incoming commands, concurrent processing wouldn't make sense
@@ -32,15 +63,20 @@ type swarmController struct {
 	manager       Manager
 	provider      Provider
 	runner        operator.Runner
+	recorder      record.EventRecorder
+	leases        JobLeases
 }
 
-func NewSwarmController(cl versioned.Interface, ss statefulset.SelectorStore, m Manager, p Provider, r operator.Runner) *swarmController {
+// NewSwarmController instantiates swarm controller, nil job leases disables lease ownership enforcement
+func NewSwarmController(cl versioned.Interface, ss statefulset.SelectorStore, m Manager, p Provider, r operator.Runner, rec record.EventRecorder, l JobLeases) *swarmController {
 	return &swarmController{
 		swarmClient:   cl,
 		selectorStore: ss,
 		manager:       m,
 		provider:      p,
 		runner:        r,
+		recorder:      rec,
+		leases:        l,
 	}
 }
 
@@ -62,12 +98,33 @@ func (c *swarmController) UpdatePoolSize(ctx context.Context, namespace, name st
 	return nil
 }
 
+// RefreshPool happens on worker pods applied version acknowledgements
+func (c *swarmController) RefreshPool(ctx context.Context, namespace, name string) error {
+	c.runner.Process(newRefreshSwarm(namespace, name))
+	return nil
+}
+
+// SyncSlots happens on deployment and selector pool pods changes
+func (c *swarmController) SyncSlots(ctx context.Context, namespace, name string) error {
+	c.runner.Process(newSyncSlots(namespace, name))
+	return nil
+}
+
 // Delete happens on swarm deletion
 func (c *swarmController) Delete(ctx context.Context, namespace, name string) error {
 	c.runner.Process(newDeleteSwarm(namespace, name))
 	return nil
 }
 
+// Info returns swarm controller introspection info
+func (c *swarmController) Info() SwarmInfo {
+	return SwarmInfo{
+		Runner:    c.runner.Info(),
+		Selectors: c.selectorStore.Selectors(),
+		Pools:     c.manager.Pools(),
+	}
+}
+
 func (c *swarmController) Run(ctx context.Context) {
 	c.runner.Run(ctx, c.handle)
 }
@@ -79,6 +136,10 @@ func (c *swarmController) handle(ctx context.Context, e interface{}) error {
 		return c.process(ctx, e.namespace, e.name)
 	case updateSwarmSize:
 		return c.updatePool(ctx, e.namespace, e.name, e.size)
+	case refreshSwarm:
+		return c.refreshPool(ctx, e.namespace, e.name)
+	case syncSlots:
+		return c.syncSlots(ctx, e.namespace, e.name)
 	case deleteSwarm:
 		return c.delete(ctx, e.namespace, e.name)
 	}
@@ -88,6 +149,7 @@ func (c *swarmController) handle(ctx context.Context, e interface{}) error {
 
 // process happens on swarm create/update event
 func (c *swarmController) process(ctx context.Context, namespace, name string) error {
+	log := logger.ComponentFromContext(ctx, logger.Swarm)
 	log.Infof("Create swarm %s %s", namespace, name)
 	sw, err := c.provider.Swarm(namespace, name)
 	if err != nil {
@@ -97,8 +159,17 @@ func (c *swarmController) process(ctx context.Context, namespace, name string) e
 	log.Infof("Processing swarm %s namespace %s statefulset name %s configmap name %s version %d total workloads %d",
 		sw.Name, sw.Namespace, sw.Spec.StatefulSetName, sw.Spec.ConfigMapName, sw.Spec.Version, len(sw.Spec.Workload))
 
+	if IsSlotPool(sw.Spec) {
+		return c.processSlotPool(ctx, sw)
+	}
+
 	sts, err := c.provider.StatefulSet(sw.Namespace, sw.Spec.StatefulSetName)
 	if err != nil {
+		if err := c.syncStatus(ctx, namespace, name, func(u *swapi.Swarm) {
+			u.Status.Phase, u.Status.Message = swapi.PhasePending, fmt.Sprintf("statefulset %s not found", sw.Spec.StatefulSetName)
+		}); err != nil {
+			log.Errorf("unable to update swarm %s status, error %v", name, err)
+		}
 		return fmt.Errorf("unable to get statefulset from swarm %s on namespace %s error %v", name, namespace, err)
 	}
 
@@ -114,54 +185,423 @@ func (c *swarmController) process(ctx context.Context, namespace, name string) e
 
 	log.Infof("Controller found size %d worker pods %s", len(names), names)
 
-	c.manager.Process(ctx, namespace, name, sw.Spec.Version, sw.Spec.Workload)
+	if ok, err := c.processSpec(ctx, sw); !ok {
+		return err
+	}
+
+	return c.syncPool(ctx, namespace, name, sts.Name, int(*sts.Spec.Replicas), sw.Generation)
+}
+
+// processSpec registers swarm spec on its pool, invalid strategies can only be fixed updating swarm spec so
+// swarm gets degraded without retries. Returns false when pool does not get registered
+func (c *swarmController) processSpec(ctx context.Context, sw *swapi.Swarm) (bool, error) {
+	// assignment versions never go backwards, spec version only sets the initial one
+	spec := sw.Spec
+	if sw.Status.Version > spec.Version {
+		spec.Version = sw.Status.Version
+	}
+
+	if err := c.manager.Process(ctx, sw.Namespace, sw.Name, spec); err != nil {
+		logger.ComponentFromContext(ctx, logger.Swarm).Errorf("swarm %s %s degraded, %v", sw.Namespace, sw.Name, err)
+		return false, c.syncStatus(ctx, sw.Namespace, sw.Name, func(u *swapi.Swarm) {
+			u.Status.Phase, u.Status.Message = swapi.PhaseDegraded, err.Error()
+			u.Status.ObservedGeneration = sw.Generation
+		})
+	}
+
+	return true, nil
+}
+
+// processSlotPool registers deployment and selector swarm pools, its slots get assigned to live pods
+func (c *swarmController) processSlotPool(ctx context.Context, sw *swapi.Swarm) error {
+	if ok, err := c.processSpec(ctx, sw); !ok {
+		return err
+	}
 
-	return c.updatePool(ctx, namespace, sts.Name, int(*sts.Spec.Replicas))
+	return c.slotPool(ctx, sw, sw.Generation)
+}
+
+// syncSlots reassigns slot pool slots whose pod disappeared and balances pool to its size
+func (c *swarmController) syncSlots(ctx context.Context, namespace, name string) error {
+	sw, err := c.provider.Swarm(namespace, name)
+	if err != nil {
+		return err
+	}
+	if !IsSlotPool(sw.Spec) {
+		return nil
+	}
+	if _, ok := c.manager.Pool(namespace, name); !ok {
+		return c.processSlotPool(ctx, sw)
+	}
+
+	return c.slotPool(ctx, sw, 0)
+}
+
+func (c *swarmController) slotPool(ctx context.Context, sw *swapi.Swarm, generation int64) error {
+	selector, size, err := c.provider.SlotPool(sw.Namespace, sw.Spec)
+	if err != nil {
+		if err := c.syncStatus(ctx, sw.Namespace, sw.Name, func(u *swapi.Swarm) {
+			u.Status.Phase, u.Status.Message = swapi.PhasePending, fmt.Sprintf("deployment %s not found", sw.Spec.DeploymentName)
+		}); err != nil {
+			logger.ComponentFromContext(ctx, logger.Swarm).Errorf("unable to update swarm %s status, error %v", sw.Name, err)
+		}
+		return fmt.Errorf("unable to get swarm %s pool on namespace %s error %v", sw.Name, sw.Namespace, err)
+	}
+
+	// slot pools get registered by swarm name, its pods do not have statefulset owner
+	if err := c.selectorStore.Register(sw.Namespace, sw.Name, selector); err != nil {
+		return fmt.Errorf("unable to register key %s %s error %v", sw.Namespace, sw.Name, err)
+	}
+
+	pods, err := c.provider.PodNamesFromSelector(sw.Namespace, selector)
+	if err != nil {
+		return fmt.Errorf("unable to get pods from selector, error %v", err)
+	}
+
+	if _, err := c.manager.UpdateSlots(ctx, sw.Namespace, sw.Name, pods, size, sw.Status.Slots); err != nil {
+		return fmt.Errorf("unable to update swarm %s slots error %v", sw.Name, err)
+	}
+
+	return c.syncPool(ctx, sw.Namespace, sw.Name, "", size, generation)
 }
 
 func (c *swarmController) updatePool(ctx context.Context, namespace, name string, size int) error {
-	log.Infof("Update swarm %s %s", namespace, name)
+	swarmName, err := c.provider.SwarmNameFromStatefulSetName(namespace, name)
+	if err != nil {
+		return fmt.Errorf("unable to get swarm error %v", err)
+	}
 
+	return c.syncPool(ctx, namespace, swarmName, name, size, 0)
+}
+
+// refreshPool reports pool status without balancing it again
+func (c *swarmController) refreshPool(ctx context.Context, namespace, name string) error {
 	swarmName, err := c.provider.SwarmNameFromStatefulSetName(namespace, name)
 	if err != nil {
 		return fmt.Errorf("unable to get swarm error %v", err)
 	}
 
-	version, err := c.manager.UpdateSize(ctx, namespace, swarmName, size)
+	return c.reportPool(ctx, namespace, swarmName, name, 0)
+}
+
+// syncPool balances swarm pool to statefulset size and reports it on swarm status, generation gets observed
+// on processed swarm specs, size updates keep observed generation. Slot pools go without statefulset name
+func (c *swarmController) syncPool(ctx context.Context, namespace, name, statefulSetName string, size int, generation int64) error {
+	logger.ComponentFromContext(ctx, logger.Swarm).Infof("Update swarm %s %s statefulset %s size %d", namespace, name, statefulSetName, size)
+
+	if _, err := c.manager.UpdateSize(ctx, namespace, name, size); err != nil {
+		return fmt.Errorf("unable to update swarm %s size error %v", name, err)
+	}
+
+	return c.reportPool(ctx, namespace, name, statefulSetName, generation)
+}
+
+// reportPool rebalances unhealthy workers, completes pending handoffs and reports pool on swarm status
+func (c *swarmController) reportPool(ctx context.Context, namespace, name, statefulSetName string, generation int64) error {
+	w := c.workers(ctx, namespace, name, statefulSetName)
+
+	grace, err := c.manager.UpdateHealth(ctx, namespace, name, w.notReady)
 	if err != nil {
-		return fmt.Errorf("unable to update swarm %s size error %v", swarmName, err)
+		return fmt.Errorf("unable to update swarm %s health error %v", name, err)
+	}
+	// not ready workers get refreshed once its grace period expires
+	if grace > 0 {
+		c.runner.ProcessAfter(c.refreshEvent(namespace, name, statefulSetName), grace)
+	}
+
+	// handoffs wait previous owners pods to be gone, they can not complete without knowing them
+	if w.pods != nil {
+		wait, err := c.manager.Handoff(ctx, namespace, name, w.versions, w.pods)
+		if err != nil {
+			return fmt.Errorf("unable to handoff swarm %s jobs error %v", name, err)
+		}
+		// handoffs waiting acknowledgements get refreshed on its deadline
+		if wait > 0 {
+			c.runner.ProcessAfter(c.refreshEvent(namespace, name, statefulSetName), wait)
+		}
 	}
 
-	_, err = c.updateSwarm(ctx, namespace, swarmName, version, size)
+	info, ok := c.manager.Pool(namespace, name)
+	if !ok {
+		return nil
+	}
+
+	pendingLeases, err := c.syncLeases(ctx, namespace, name, statefulSetName, info)
 	if err != nil {
-		return fmt.Errorf("unable to update swarm %s error %v", name, err)
+		return err
 	}
-	return nil
+
+	unassignedJobs.WithLabelValues(namespace, name).Set(float64(len(info.Unassigned)))
+
+	return c.syncStatus(ctx, namespace, name, func(u *swapi.Swarm) {
+		if generation > 0 {
+			u.Status.ObservedGeneration = generation
+		}
+		if u.Status.Phase != swapi.PhaseDegraded || generation > 0 {
+			u.Status.Phase, u.Status.Message = phase(info, w.ready)
+			if len(pendingLeases) > 0 && u.Status.Phase == swapi.PhaseRunning {
+				u.Status.Phase, u.Status.Message = swapi.PhaseUpdating, fmt.Sprintf("waiting %d job leases release", len(pendingLeases))
+			}
+		}
+		u.Status.Version = info.Version
+		u.Status.Size = info.Size
+		u.Status.Members = members(info, u.Status.Members, w.versions)
+		u.Status.Converged = converged(info, u.Status.Members)
+		u.Status.PendingLeases = pendingLeases
+		u.Status.Slots = c.manager.Slots(namespace, name)
+		c.balanceStatus(u, info)
+		c.healthStatus(u, info)
+	})
 }
 
-func (c *swarmController) delete(ctx context.Context, namespace, name string) error {
-	c.selectorStore.UnRegister(namespace, name)
-	c.manager.Delete(ctx, namespace, name)
-	return nil
+// poolWorkers reports pool ready workers total, applied versions, not ready workers and running pods uid,
+// terminating ones included. Unknown pods get reported as nil
+type poolWorkers struct {
+	ready    int32
+	versions map[string]int64
+	notReady map[string]time.Time
+	pods     map[string]types.UID
 }
 
-func (c *swarmController) updateSwarm(ctx context.Context, namespace, name string, version int64, size int) (*swapi.Swarm, error) {
-	sw, err := c.provider.Swarm(namespace, name)
+// workers returns pool workers, slot pools report them by slot
+func (c *swarmController) workers(ctx context.Context, namespace, name, statefulSetName string) poolWorkers {
+	log := logger.ComponentFromContext(ctx, logger.Swarm)
+	var selector *metav1.LabelSelector
+	res := poolWorkers{versions: map[string]int64{}, notReady: map[string]time.Time{}}
+	found := false
+	if statefulSetName == "" {
+		if sw, err := c.provider.Swarm(namespace, name); err == nil {
+			selector, _, err = c.provider.SlotPool(namespace, sw.Spec)
+			found = err == nil
+		}
+	} else if sts, err := c.provider.StatefulSet(namespace, statefulSetName); err == nil {
+		res.ready, selector, found = sts.Status.ReadyReplicas, sts.Spec.Selector, true
+	}
+
+	if !found {
+		return res
+	}
+	if v, err := c.provider.AppliedVersions(namespace, selector); err == nil {
+		res.versions = v
+	} else {
+		log.Errorf("unable to get swarm %s applied versions, error %v", name, err)
+	}
+	if n, err := c.provider.NotReadyPods(namespace, selector); err == nil {
+		res.notReady = n
+	} else {
+		log.Errorf("unable to get swarm %s not ready workers, error %v", name, err)
+	}
+	if p, err := c.provider.PodUIDs(namespace, selector); err == nil {
+		res.pods = p
+	} else {
+		log.Errorf("unable to get swarm %s pods, error %v", name, err)
+	}
+	if statefulSetName != "" {
+		return res
+	}
+
+	slotted := poolWorkers{versions: map[string]int64{}, notReady: map[string]time.Time{}}
+	if res.pods != nil {
+		slotted.pods = map[string]types.UID{}
+	}
+	for slot, pd := range c.manager.Slots(namespace, name) {
+		if v, ok := res.versions[pd]; ok {
+			slotted.versions[slot] = v
+		}
+		if uid, ok := res.pods[pd]; ok && slotted.pods != nil {
+			slotted.pods[slot] = uid
+		}
+		if since, ok := res.notReady[pd]; ok {
+			slotted.notReady[slot] = since
+			continue
+		}
+		slotted.ready++
+	}
+
+	return slotted
+}
+
+// refreshEvent returns pool refresh event, slot pools get its slots synced
+func (c *swarmController) refreshEvent(namespace, name, statefulSetName string) Event {
+	if statefulSetName == "" {
+		return newSyncSlots(namespace, name)
+	}
+
+	return newRefreshSwarm(namespace, statefulSetName)
+}
+
+// syncLeases transfers pool job leases to its assigned workers, leases still held by previous owners get
+// retried once they expire
+func (c *swarmController) syncLeases(ctx context.Context, namespace, name, statefulSetName string, info PoolInfo) ([]swapi.Job, error) {
+	if c.leases == nil {
+		return nil, nil
+	}
+
+	// slot pool leases get held by slot pods
+	slots := c.manager.Slots(namespace, name)
+	owners := map[config.Job]string{}
+	if info.Workloads != nil {
+		for w, wl := range info.Workloads.Workloads {
+			if slots != nil {
+				pd, ok := slots[w]
+				if !ok {
+					continue
+				}
+				w = pd
+			}
+			for _, j := range wl.Jobs {
+				owners[j] = w
+			}
+		}
+	}
+
+	pending, err := c.leases.Sync(ctx, namespace, name, owners)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get swarm namespace %s name %s", namespace, name)
+		return nil, fmt.Errorf("unable to sync swarm %s job leases error %v", name, err)
+	}
+	if len(pending) > 0 {
+		c.runner.ProcessAfter(c.refreshEvent(namespace, name, statefulSetName), c.leases.Duration())
 	}
 
-	log.Infof("Update swarm %s namespace %s statefulset name %s configmap name %s version %d workloads %d Phase %s",
-		name, namespace, sw.Spec.StatefulSetName, sw.Spec.ConfigMapName, sw.Spec.Version, len(sw.Spec.Workload), sw.Status.Phase)
+	var res []swapi.Job
+	for _, j := range pending {
+		res = append(res, swapi.Job(j))
+	}
+
+	return res, nil
+}
 
-	swr := sw.DeepCopyObject()
-	updatedSwarm := swr.(*swapi.Swarm)
-	updatedSwarm.Spec.Size = size
-	updatedSwarm.Spec.Version = version
-	swu, err := c.swarmClient.K8slabV1alpha1().Swarms(namespace).Update(ctx, updatedSwarm, metav1.UpdateOptions{})
+// syncStatus updates swarm status through status subresource, fresh swarm copy gets mutated and updated
+// only when its status changes
+func (c *swarmController) syncStatus(ctx context.Context, namespace, name string, mutate func(*swapi.Swarm)) error {
+	sw, err := c.swarmClient.K8slabV1alpha1().Swarms(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("unable to update swarm %s error %v", sw.Name, err)
+		return fmt.Errorf("unable to get swarm %s error %v", name, err)
+	}
+
+	updated := sw.DeepCopy()
+	mutate(updated)
+	if reflect.DeepEqual(sw.Status, updated.Status) {
+		return nil
+	}
+
+	if _, err := c.swarmClient.K8slabV1alpha1().Swarms(namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update swarm %s status error %v", name, err)
 	}
 
-	return swu, nil
+	return nil
+}
+
+// phase resolves swarm phase from its pool, pools get updating while handing off jobs and until all workers
+// become ready
+func phase(info PoolInfo, ready int32) (string, string) {
+	total := 0
+	if info.Workloads != nil {
+		for _, w := range info.Workloads.Workloads {
+			total += len(w.Jobs)
+		}
+	}
+
+	switch {
+	case info.Size == 0:
+		return swapi.PhasePending, "waiting workers"
+	case total == 0 && len(info.Unassigned) == 0:
+		return swapi.PhaseDone, "empty workload"
+	case info.Handoff != nil:
+		gone := append(append([]string{}, info.Handoff.Removed...), info.Handoff.Restarted...)
+		return swapi.PhaseUpdating, fmt.Sprintf("waiting workers %v to release moved jobs, workers %v to be gone", info.Handoff.Owners, gone)
+	case int(ready) < info.Size:
+		return swapi.PhaseUpdating, fmt.Sprintf("%d of %d workers ready", ready, info.Size)
+	}
+
+	return swapi.PhaseRunning, ""
+}
+
+// members builds status members from pool workloads, existing members keep their creation time, applied
+// versions come from worker acknowledgements
+func members(info PoolInfo, previous []swapi.Worker, versions map[string]int64) []swapi.Worker {
+	createdAt := map[string]int64{}
+	for _, m := range previous {
+		createdAt[m.Name] = m.CreatedAt
+	}
+
+	var res []swapi.Worker
+	if info.Workloads == nil {
+		return res
+	}
+	for name, w := range info.Workloads.Workloads {
+		m := swapi.Worker{Name: name, Jobs: []swapi.Job{}, CreatedAt: createdAt[name], AppliedVersion: versions[name]}
+		if m.CreatedAt == 0 {
+			m.CreatedAt = time.Now().Unix()
+		}
+		for _, j := range w.Jobs {
+			m.Jobs = append(m.Jobs, swapi.Job(j))
+		}
+		res = append(res, m)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+
+	return res
+}
+
+// converged checks if all pool members acknowledged its current assignment version, workers without
+// workload changes keep the version they were last assigned
+func converged(info PoolInfo, members []swapi.Worker) bool {
+	if info.Size == 0 || len(members) == 0 {
+		return false
+	}
+	for _, m := range members {
+		version, ok := info.WorkerVersions[m.Name]
+		if !ok {
+			version = info.Version
+		}
+		if m.AppliedVersion < version {
+			return false
+		}
+	}
+
+	return true
+}
+
+// healthStatus reports pool unhealthy workers, unhealthy worker changes get notified with a warning event
+func (c *swarmController) healthStatus(sw *swapi.Swarm, info PoolInfo) {
+	if len(info.Unhealthy) > 0 && !reflect.DeepEqual(info.Unhealthy, sw.Status.Unhealthy) {
+		c.recorder.Eventf(sw, corev1.EventTypeWarning, UnhealthyWorkersReason, "workers %v not ready, jobs moved to healthy workers", info.Unhealthy)
+	}
+	sw.Status.Unhealthy = info.Unhealthy
+}
+
+// balanceStatus reports pool unsatisfied constraints and unassigned jobs, unassigned job changes get notified
+// with a warning event
+func (c *swarmController) balanceStatus(sw *swapi.Swarm, info PoolInfo) {
+	var unsatisfied []swapi.UnsatisfiedConstraint
+	for _, u := range info.Unsatisfied {
+		uc := swapi.UnsatisfiedConstraint{Type: u.Type, Reason: u.Reason, Jobs: []swapi.Job{}}
+		for _, j := range u.Jobs {
+			uc.Jobs = append(uc.Jobs, swapi.Job(j))
+		}
+		unsatisfied = append(unsatisfied, uc)
+	}
+	var unassigned []swapi.Job
+	for _, j := range info.Unassigned {
+		unassigned = append(unassigned, swapi.Job(j))
+	}
+
+	if len(unassigned) > 0 && !reflect.DeepEqual(unassigned, sw.Status.Unassigned) {
+		c.recorder.Eventf(sw, corev1.EventTypeWarning, UnassignedJobsReason, "%d jobs do not fit on %d workers: %v", len(unassigned), info.Size, unassigned)
+	}
+	sw.Status.Unsatisfied = unsatisfied
+	sw.Status.Unassigned = unassigned
+}
+
+func (c *swarmController) delete(ctx context.Context, namespace, name string) error {
+	c.selectorStore.UnRegister(namespace, name)
+	c.manager.Delete(ctx, namespace, name)
+	if c.leases != nil {
+		if err := c.leases.Delete(ctx, namespace, name); err != nil {
+			logger.ComponentFromContext(ctx, logger.Swarm).Errorf("unable to delete swarm %s leases, error %v", name, err)
+		}
+	}
+	unassignedJobs.DeleteLabelValues(namespace, name)
+	return nil
 }
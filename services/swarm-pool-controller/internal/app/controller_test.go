@@ -1 +1,294 @@
 package app
+
+import (
+	"context"
+	"errors"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/balancer"
+	swapi "github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/apis/swarm/v1alpha1"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/generated/clientset/versioned/fake"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	api "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSwarmController_ItReportsUnassignedJobsOnStatusEventsAndMetrics(t *testing.T) {
+	ctx := context.Background()
+	sw := &swapi.Swarm{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "swarm"}}
+	cl := fake.NewSimpleClientset(sw)
+	rec := record.NewFakeRecorder(10)
+	m := &fakeManager{info: PoolInfo{
+		Size:        2,
+		Unassigned:  []config.Job{"stream:a", "stream:b"},
+		Unsatisfied: []balancer.Unsatisfied{{Type: balancer.AntiAffinityConstraint, Jobs: []config.Job{"x", "y", "z"}, Reason: "foo"}},
+	}}
+	c := NewSwarmController(cl, nil, m, &fakeProvider{}, nil, rec, nil)
+
+	for i := 0; i < 2; i++ {
+		if err := c.syncPool(ctx, "swarm", "foo", "swarm-worker", 2, 0); err != nil {
+			t.Fatalf("unexpected error updating status %v", err)
+		}
+	}
+
+	updated, _ := cl.K8slabV1alpha1().Swarms("swarm").Get(ctx, "foo", metav1.GetOptions{})
+	if expected, got := []swapi.Job{"stream:a", "stream:b"}, updated.Status.Unassigned; !reflect.DeepEqual(expected, got) {
+		t.Errorf("unassigned jobs do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := 1, len(updated.Status.Unsatisfied); expected != got {
+		t.Errorf("total unsatisfied constraints do not match, expected %d got %d", expected, got)
+	}
+	if expected, got := 2.0, testutil.ToFloat64(unassignedJobs.WithLabelValues("swarm", "foo")); expected != got {
+		t.Errorf("unassigned jobs metric does not match, expected %f got %f", expected, got)
+	}
+	if expected, got := 1, len(rec.Events); expected != got {
+		t.Fatalf("total events do not match, expected %d got %d", expected, got)
+	}
+	if ev := <-rec.Events; !strings.HasPrefix(ev, "Warning "+UnassignedJobsReason) {
+		t.Errorf("unexpected event %s", ev)
+	}
+}
+
+func TestSwarmController_ItOwnsSwarmStatusLifecycle(t *testing.T) {
+	ctx := context.Background()
+	sw := &swapi.Swarm{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "swarm", Generation: 3}}
+	cl := fake.NewSimpleClientset(sw)
+	wl := &config.Workloads{Version: 7, Workloads: map[string]*config.Workload{
+		"swarm-worker-1": {Jobs: []config.Job{"stream:b"}},
+		"swarm-worker-0": {Jobs: []config.Job{"stream:a"}},
+	}}
+	m := &fakeManager{info: PoolInfo{Version: 7, Size: 2, Workloads: wl}}
+	p := &fakeProvider{ready: 1}
+	c := NewSwarmController(cl, nil, m, p, nil, record.NewFakeRecorder(10), nil)
+
+	for _, sc := range []struct {
+		ready      int32
+		generation int64
+		phase      string
+	}{
+		{1, 3, swapi.PhaseUpdating},
+		{2, 0, swapi.PhaseRunning},
+	} {
+		p.ready = sc.ready
+		if err := c.syncPool(ctx, "swarm", "foo", "swarm-worker", 2, sc.generation); err != nil {
+			t.Fatalf("unexpected error syncing pool %v", err)
+		}
+
+		updated, _ := cl.K8slabV1alpha1().Swarms("swarm").Get(ctx, "foo", metav1.GetOptions{})
+		if expected, got := sc.phase, updated.Status.Phase; expected != got {
+			t.Errorf("phase does not match, expected %s got %s", expected, got)
+		}
+		if expected, got := int64(3), updated.Status.ObservedGeneration; expected != got {
+			t.Errorf("observed generation does not match, expected %d got %d", expected, got)
+		}
+		if expected, got := int64(7), updated.Status.Version; expected != got {
+			t.Errorf("version does not match, expected %d got %d", expected, got)
+		}
+		if expected, got := 2, len(updated.Status.Members); expected != got {
+			t.Fatalf("total members do not match, expected %d got %d", expected, got)
+		}
+		if expected, got := "swarm-worker-0", updated.Status.Members[0].Name; expected != got {
+			t.Errorf("member name does not match, expected %s got %s", expected, got)
+		}
+		if updated.Status.Members[0].CreatedAt == 0 {
+			t.Error("expected member creation time")
+		}
+	}
+}
+
+func TestSwarmController_ItKeepsDegradedPhaseOnSizeUpdates(t *testing.T) {
+	ctx := context.Background()
+	sw := &swapi.Swarm{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "swarm"}, Status: swapi.Status{Phase: swapi.PhaseDegraded, Message: "foo"}}
+	cl := fake.NewSimpleClientset(sw)
+	m := &fakeManager{info: PoolInfo{Version: 2, Size: 1}}
+	c := NewSwarmController(cl, nil, m, &fakeProvider{ready: 1}, nil, record.NewFakeRecorder(10), nil)
+
+	if err := c.syncPool(ctx, "swarm", "foo", "swarm-worker", 1, 0); err != nil {
+		t.Fatalf("unexpected error syncing pool %v", err)
+	}
+
+	updated, _ := cl.K8slabV1alpha1().Swarms("swarm").Get(ctx, "foo", metav1.GetOptions{})
+	if expected, got := swapi.PhaseDegraded, updated.Status.Phase; expected != got {
+		t.Errorf("phase does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := int64(2), updated.Status.Version; expected != got {
+		t.Errorf("version does not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestSwarmController_ItReportsWorkerAppliedVersionsConvergence(t *testing.T) {
+	ctx := context.Background()
+	sw := &swapi.Swarm{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "swarm"}}
+	cl := fake.NewSimpleClientset(sw)
+	wl := &config.Workloads{Version: 3, Workloads: map[string]*config.Workload{
+		"swarm-worker-0": {Jobs: []config.Job{"stream:a"}},
+		"swarm-worker-1": {Jobs: []config.Job{"stream:b"}},
+	}}
+	m := &fakeManager{info: PoolInfo{Version: 3, Size: 2, Workloads: wl}}
+	p := &fakeProvider{ready: 2}
+	c := NewSwarmController(cl, nil, m, p, nil, record.NewFakeRecorder(10), nil)
+
+	for _, sc := range []struct {
+		versions  map[string]int64
+		converged bool
+	}{
+		{map[string]int64{"swarm-worker-0": 3}, false},
+		{map[string]int64{"swarm-worker-0": 3, "swarm-worker-1": 2}, false},
+		{map[string]int64{"swarm-worker-0": 3, "swarm-worker-1": 3}, true},
+	} {
+		p.versions = sc.versions
+		if err := c.reportPool(ctx, "swarm", "foo", "swarm-worker", 0); err != nil {
+			t.Fatalf("unexpected error reporting pool %v", err)
+		}
+
+		updated, _ := cl.K8slabV1alpha1().Swarms("swarm").Get(ctx, "foo", metav1.GetOptions{})
+		if expected, got := sc.converged, updated.Status.Converged; expected != got {
+			t.Errorf("convergence does not match, expected %t got %t", expected, got)
+		}
+		for _, w := range updated.Status.Members {
+			if expected, got := sc.versions[w.Name], w.AppliedVersion; expected != got {
+				t.Errorf("worker %s applied version does not match, expected %d got %d", w.Name, expected, got)
+			}
+		}
+	}
+}
+
+func TestSwarmController_ItReportsPendingJobLeaseTransfers(t *testing.T) {
+	ctx := context.Background()
+	sw := &swapi.Swarm{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "swarm"}}
+	cl := fake.NewSimpleClientset(sw)
+	wl := &config.Workloads{Version: 3, Workloads: map[string]*config.Workload{
+		"swarm-worker-0": {Jobs: []config.Job{"stream:a"}},
+		"swarm-worker-1": {Jobs: []config.Job{"stream:b"}},
+	}}
+	m := &fakeManager{info: PoolInfo{Version: 3, Size: 2, Workloads: wl}}
+	l := &fakeLeases{pending: []config.Job{"stream:b"}}
+	r := &fakeRunner{}
+	c := NewSwarmController(cl, nil, m, &fakeProvider{ready: 2}, r, record.NewFakeRecorder(10), l)
+
+	if err := c.reportPool(ctx, "swarm", "foo", "swarm-worker", 0); err != nil {
+		t.Fatalf("unexpected error reporting pool %v", err)
+	}
+
+	if expected, got := "swarm-worker-1", l.owners["stream:b"]; expected != got {
+		t.Errorf("lease owner does not match, expected %s got %s", expected, got)
+	}
+	updated, _ := cl.K8slabV1alpha1().Swarms("swarm").Get(ctx, "foo", metav1.GetOptions{})
+	if expected, got := []swapi.Job{"stream:b"}, updated.Status.PendingLeases; !reflect.DeepEqual(expected, got) {
+		t.Errorf("pending leases do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := swapi.PhaseUpdating, updated.Status.Phase; expected != got {
+		t.Errorf("phase does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := 1, len(r.delayed); expected != got {
+		t.Errorf("total delayed refreshes do not match, expected %d got %d", expected, got)
+	}
+}
+
+type fakeLeases struct {
+	owners  map[config.Job]string
+	pending []config.Job
+}
+
+func (f *fakeLeases) Sync(ctx context.Context, namespace, swarm string, owners map[config.Job]string) ([]config.Job, error) {
+	f.owners = owners
+	return f.pending, nil
+}
+
+func (f *fakeLeases) Delete(ctx context.Context, namespace, swarm string) error {
+	return nil
+}
+
+func (f *fakeLeases) Duration() time.Duration {
+	return time.Minute
+}
+
+type fakeRunner struct {
+	operator.Runner
+	delayed []interface{}
+}
+
+func (f *fakeRunner) ProcessAfter(e interface{}, d time.Duration) {
+	f.delayed = append(f.delayed, e)
+}
+
+type fakeProvider struct {
+	ready    int32
+	versions map[string]int64
+}
+
+func (f *fakeProvider) Swarm(namespace, name string) (*swapi.Swarm, error) {
+	return nil, errors.New("not found")
+}
+
+func (f *fakeProvider) StatefulSet(namespace, name string) (*api.StatefulSet, error) {
+	return &api.StatefulSet{Status: api.StatefulSetStatus{ReadyReplicas: f.ready}}, nil
+}
+
+func (f *fakeProvider) PodNamesFromSelector(namespace string, ls *metav1.LabelSelector) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeProvider) AppliedVersions(namespace string, ls *metav1.LabelSelector) (map[string]int64, error) {
+	return f.versions, nil
+}
+
+func (f *fakeProvider) NotReadyPods(namespace string, ls *metav1.LabelSelector) (map[string]time.Time, error) {
+	return nil, nil
+}
+
+func (f *fakeProvider) PodUIDs(namespace string, ls *metav1.LabelSelector) (map[string]types.UID, error) {
+	return map[string]types.UID{}, nil
+}
+
+func (f *fakeProvider) SlotPool(namespace string, spec swapi.SwarmSpec) (*metav1.LabelSelector, int, error) {
+	return nil, 0, nil
+}
+
+func (f *fakeProvider) SwarmNameFromStatefulSetName(namespace, name string) (string, error) {
+	return "", errors.New("not found")
+}
+
+type fakeManager struct {
+	info PoolInfo
+}
+
+func (f *fakeManager) Process(ctx context.Context, namespace, name string, spec swapi.SwarmSpec) error {
+	return nil
+}
+
+func (f *fakeManager) UpdateSize(ctx context.Context, namespace, name string, size int) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeManager) Handoff(ctx context.Context, namespace, name string, versions map[string]int64, pods map[string]types.UID) (time.Duration, error) {
+	return 0, nil
+}
+
+func (f *fakeManager) UpdateHealth(ctx context.Context, namespace, name string, notReady map[string]time.Time) (time.Duration, error) {
+	return 0, nil
+}
+
+func (f *fakeManager) UpdateSlots(ctx context.Context, namespace, name string, pods []string, size int, previous map[string]string) (map[string]string, error) {
+	return nil, nil
+}
+
+func (f *fakeManager) Slots(namespace, name string) map[string]string {
+	return nil
+}
+
+func (f *fakeManager) Delete(ctx context.Context, namespace, name string) {}
+
+func (f *fakeManager) Pool(namespace, name string) (PoolInfo, bool) {
+	return f.info, true
+}
+
+func (f *fakeManager) Pools() []PoolInfo {
+	return []PoolInfo{f.info}
+}
@@ -1,13 +1,20 @@
 package app
 
+import "github.com/marcosQuesada/k8s-lab/pkg/operator"
+
 type action string
 
 const processSwarmAction = action("processSwarmAction")
 const updateSwarmAction = action("updateSwarmAction")
 const deleteSwarmAction = action("deleteSwarmAction")
+const refreshSwarmAction = action("refreshSwarmAction")
+const syncSlotsAction = action("syncSlotsAction")
 
+// Event defines swarm controller command, key and action get attached to runner event loggers
 type Event interface {
 	Type() action
+	GetKey() string
+	GetAction() operator.Action
 }
 
 type processSwarm struct {
@@ -37,6 +44,32 @@ func (e updateSwarmSize) Type() action {
 	return updateSwarmAction
 }
 
+type refreshSwarm struct {
+	namespace string
+	name      string
+}
+
+func newRefreshSwarm(namespace, name string) refreshSwarm {
+	return refreshSwarm{namespace: namespace, name: name}
+}
+
+func (e refreshSwarm) Type() action {
+	return refreshSwarmAction
+}
+
+type syncSlots struct {
+	namespace string
+	name      string
+}
+
+func newSyncSlots(namespace, name string) syncSlots {
+	return syncSlots{namespace: namespace, name: name}
+}
+
+func (e syncSlots) Type() action {
+	return syncSlotsAction
+}
+
 type deleteSwarm struct {
 	namespace string
 	name      string
@@ -49,3 +82,53 @@ func newDeleteSwarm(namespace, name string) deleteSwarm {
 func (e deleteSwarm) Type() action {
 	return deleteSwarmAction
 }
+
+// GetKey returns swarm key
+func (e processSwarm) GetKey() string {
+	return e.namespace + "/" + e.name
+}
+
+// GetAction returns command action
+func (e processSwarm) GetAction() operator.Action {
+	return operator.Action(e.Type())
+}
+
+// GetKey returns swarm key
+func (e updateSwarmSize) GetKey() string {
+	return e.namespace + "/" + e.name
+}
+
+// GetAction returns command action
+func (e updateSwarmSize) GetAction() operator.Action {
+	return operator.Action(e.Type())
+}
+
+// GetKey returns swarm key
+func (e deleteSwarm) GetKey() string {
+	return e.namespace + "/" + e.name
+}
+
+// GetAction returns command action
+func (e deleteSwarm) GetAction() operator.Action {
+	return operator.Action(e.Type())
+}
+
+// GetKey returns swarm key
+func (e refreshSwarm) GetKey() string {
+	return e.namespace + "/" + e.name
+}
+
+// GetAction returns command action
+func (e refreshSwarm) GetAction() operator.Action {
+	return operator.Action(e.Type())
+}
+
+// GetKey returns swarm key
+func (e syncSlots) GetKey() string {
+	return e.namespace + "/" + e.name
+}
+
+// GetAction returns command action
+func (e syncSlots) GetAction() operator.Action {
+	return operator.Action(e.Type())
+}
@@ -2,7 +2,9 @@ package app
 
 import (
 	"context"
+	"fmt"
 	ap "github.com/marcosQuesada/k8s-lab/pkg/config"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -11,28 +13,106 @@ type workerManager interface {
 }
 
 type delegatedStorage interface {
-	Set(ctx context.Context, namespace, configMapName string, a *ap.Workloads) error
+	Set(ctx context.Context, namespace, name string, a *ap.Workloads) error
+	Get(ctx context.Context, namespace, name string) (*ap.Workloads, error)
 }
 
+// workerVersionedStorage persists each worker workload on its own version
+type workerVersionedStorage interface {
+	WorkerVersions(ctx context.Context, namespace, name string) (map[string]int64, error)
+}
+
+// StorageRegistry indexes workload storage backends by name
+type StorageRegistry map[string]delegatedStorage
+
 type executor struct {
-	storage delegatedStorage
-	manager workerManager
+	storages       StorageRegistry
+	defaultStorage string
+	manager        workerManager
 }
 
-func NewExecutor(s delegatedStorage, m workerManager) *executor {
+// NewExecutor instantiates executor, storages are indexed by backend name, empty
+// storage requests get resolved to the default one
+func NewExecutor(defaultStorage string, storages StorageRegistry, m workerManager) (*executor, error) {
+	if _, ok := storages[defaultStorage]; !ok {
+		return nil, fmt.Errorf("default storage %s not registered", defaultStorage)
+	}
+
 	return &executor{
-		storage: s,
-		manager: m,
+		storages:       storages,
+		defaultStorage: defaultStorage,
+		manager:        m,
+	}, nil
+}
+
+func (e *executor) Assign(ctx context.Context, storage, namespace, name string, w *ap.Workloads) (err error) {
+	if storage == "" {
+		storage = e.defaultStorage
+	}
+
+	s, ok := e.storages[storage]
+	if !ok {
+		return fmt.Errorf("storage %s not registered", storage)
 	}
+
+	if err := w.Validate(nil); err != nil {
+		return fmt.Errorf("invalid workloads version %d, error %v", w.Version, err)
+	}
+
+	l := logger.FromContext(ctx)
+	l.Infof("Persist Workload version %d on %s %s to assign to %v", w.Version, storage, name, w.Workloads)
+	if err := s.Set(ctx, namespace, name, w); err != nil {
+		return err
+	}
+
+	l.Debugf("Workload version %d stored on %s %s", w.Version, storage, name)
+	return nil
 }
 
-func (e *executor) Assign(ctx context.Context, namespace, configMapName string, w *ap.Workloads) (err error) {
-	log.Infof("Persist Workload version %d to assign to %v", w.Version, w.Workloads)
-	return e.storage.Set(ctx, namespace, configMapName, w)
+// Storage resolves empty storage to the default one
+func (e *executor) Storage(storage string) string {
+	if storage == "" {
+		return e.defaultStorage
+	}
+
+	return storage
+}
+
+// Load returns workloads persisted on storage and each worker persisted version, empty storage gets resolved
+// to the default one. Storages without per worker versions report workloads version on all workers
+func (e *executor) Load(ctx context.Context, storage, namespace, name string) (*ap.Workloads, map[string]int64, error) {
+	if storage == "" {
+		storage = e.defaultStorage
+	}
+
+	s, ok := e.storages[storage]
+	if !ok {
+		return nil, nil, fmt.Errorf("storage %s not registered", storage)
+	}
+
+	w, err := s.Get(ctx, namespace, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if ws, ok := s.(workerVersionedStorage); ok {
+		versions, err := ws.WorkerVersions(ctx, namespace, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return w, versions, nil
+	}
+
+	versions := make(map[string]int64, len(w.Workloads))
+	for worker := range w.Workloads {
+		versions[worker] = w.Version
+	}
+
+	return w, versions, nil
 }
 
 func (e *executor) RestartWorker(ctx context.Context, namespace, name string) error {
-	log.Infof("Restarting worker %s", name)
+	logger.FromContext(ctx).Infof("Restarting worker %s", name)
 	return e.manager.Refresh(ctx, namespace, name)
 }
 
@@ -43,7 +123,7 @@ func NewNopExecutor() *nopExecutor {
 	return &nopExecutor{}
 }
 
-func (e *nopExecutor) Assign(ctx context.Context, w *ap.Workloads) error {
+func (e *nopExecutor) Assign(ctx context.Context, storage, namespace, name string, w *ap.Workloads) error {
 	log.Infof("Persist Workload version %d to assign to %v", w.Version, w.Workloads)
 	return nil
 }
@@ -52,3 +132,7 @@ func (e *nopExecutor) RestartWorker(ctx context.Context, namespace, name string)
 	log.Infof("Restarting worker %s", name)
 	return nil
 }
+
+func (e *nopExecutor) Load(ctx context.Context, storage, namespace, name string) (*ap.Workloads, map[string]int64, error) {
+	return nil, nil, fmt.Errorf("no workloads persisted on namespace %s name %s", namespace, name)
+}
@@ -1,3 +1,82 @@
 package app
 
-// @TODO: Add required mocks and test it
+import (
+	"context"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
+	"testing"
+)
+
+func TestExecutor_ItAssignsWorkloadsOnRequestedStorage(t *testing.T) {
+	cm := storage.NewMemoryStorage()
+	st := storage.NewMemoryStorage()
+	ex, err := NewExecutor(storage.ConfigMap, StorageRegistry{storage.ConfigMap: cm, storage.Status: st}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building executor, error %v", err)
+	}
+
+	w := &config.Workloads{Version: 1, Workloads: map[string]*config.Workload{"foo-0": {Jobs: []config.Job{"foo"}}}}
+	if err := ex.Assign(context.Background(), storage.Status, "swarm", "swarm-config", w); err != nil {
+		t.Fatalf("unexpected error assigning workloads, error %v", err)
+	}
+
+	if _, err := st.Get(context.Background(), "swarm", "swarm-config"); err != nil {
+		t.Errorf("expected workloads on status storage, error %v", err)
+	}
+	if _, err := cm.Get(context.Background(), "swarm", "swarm-config"); err == nil {
+		t.Error("unexpected workloads on configmap storage")
+	}
+}
+
+func TestExecutor_ItAssignsWorkloadsOnDefaultStorage(t *testing.T) {
+	cm := storage.NewMemoryStorage()
+	ex, err := NewExecutor(storage.ConfigMap, StorageRegistry{storage.ConfigMap: cm}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building executor, error %v", err)
+	}
+
+	w := &config.Workloads{Version: 1, Workloads: map[string]*config.Workload{}}
+	if err := ex.Assign(context.Background(), "", "swarm", "swarm-worker-config", w); err != nil {
+		t.Fatalf("unexpected error assigning workloads, error %v", err)
+	}
+
+	if _, err := cm.Get(context.Background(), "swarm", "swarm-worker-config"); err != nil {
+		t.Errorf("expected workloads on default storage, error %v", err)
+	}
+}
+
+func TestExecutor_ItFailsOnUnknownStorage(t *testing.T) {
+	if _, err := NewExecutor("foo", StorageRegistry{}, nil); err == nil {
+		t.Fatal("expected error on unknown default storage")
+	}
+
+	ex, err := NewExecutor(storage.Memory, StorageRegistry{storage.Memory: storage.NewMemoryStorage()}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building executor, error %v", err)
+	}
+
+	w := &config.Workloads{Version: 1}
+	if err := ex.Assign(context.Background(), "foo", "swarm", "swarm-config", w); err == nil {
+		t.Fatal("expected error on unknown storage")
+	}
+}
+
+func TestExecutor_ItRejectsInvalidWorkloads(t *testing.T) {
+	cm := storage.NewMemoryStorage()
+	ex, err := NewExecutor(storage.ConfigMap, StorageRegistry{storage.ConfigMap: cm}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building executor, error %v", err)
+	}
+
+	w := &config.Workloads{Version: 1, Workloads: map[string]*config.Workload{
+		"foo-0": {Jobs: []config.Job{"foo"}},
+		"foo-1": {Jobs: []config.Job{"foo"}},
+	}}
+	if err := ex.Assign(context.Background(), "", "swarm", "swarm-worker-config", w); err == nil {
+		t.Fatal("expected error assigning invalid workloads")
+	}
+
+	if _, err := cm.Get(context.Background(), "swarm", "swarm-worker-config"); err == nil {
+		t.Error("unexpected workloads persisted")
+	}
+}
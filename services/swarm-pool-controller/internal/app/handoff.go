@@ -0,0 +1,144 @@
+package app
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
+	"time"
+)
+
+// handoff tracks a two phase rebalance, moved jobs get revoked from their previous owners on version and
+// target gets assigned once all owners acknowledge it or deadline expires. Owners removed from target and
+// owners restarted on deadline must be gone (or recreated) before target gets assigned
+type handoff struct {
+	version   int64
+	target    *config.Workloads
+	owners    []string
+	removed   []string
+	deadline  time.Time
+	restarted map[string]types.UID
+}
+
+// HandoffInfo reports pending handoff revoked version, previous owners releasing moved jobs, removed owners
+// and restarted owners waiting to be gone, and deadline
+type HandoffInfo struct {
+	Version   int64     `json:"version"`
+	Owners    []string  `json:"owners"`
+	Removed   []string  `json:"removed,omitempty"`
+	Restarted []string  `json:"restarted,omitempty"`
+	Deadline  time.Time `json:"deadline"`
+}
+
+func (h *handoff) info() *HandoffInfo {
+	if h == nil {
+		return nil
+	}
+
+	var restarted []string
+	for w := range h.restarted {
+		restarted = append(restarted, w)
+	}
+	sort.Strings(restarted)
+
+	return &HandoffInfo{Version: h.version, Owners: h.owners, Removed: h.removed, Restarted: restarted, Deadline: h.deadline}
+}
+
+// lagging returns running owners without revoked version acknowledgement and removed owners whose pod keeps
+// running, owners already gone or restarted are no longer lagging
+func (h *handoff) lagging(versions map[string]int64, pods map[string]types.UID) []string {
+	var res []string
+	for _, o := range h.owners {
+		if h.released(o, pods) {
+			continue
+		}
+		if versions[o] < h.version {
+			res = append(res, o)
+		}
+	}
+	for _, o := range h.removed {
+		if !h.released(o, pods) {
+			res = append(res, o)
+		}
+	}
+
+	return res
+}
+
+func (h *handoff) released(worker string, pods map[string]types.UID) bool {
+	if _, ok := h.restarted[worker]; ok {
+		return true
+	}
+	_, running := pods[worker]
+
+	return !running
+}
+
+// restart records lagging owner pod on restart
+func (h *handoff) restart(worker string, pods map[string]types.UID) {
+	if h.restarted == nil {
+		h.restarted = map[string]types.UID{}
+	}
+	h.restarted[worker] = pods[worker]
+}
+
+// restarting returns restarted owners whose pod is still the restarted one, terminating pods keep running
+// its jobs until they are gone
+func (h *handoff) restarting(pods map[string]types.UID) []string {
+	var res []string
+	for w, uid := range h.restarted {
+		if current, ok := pods[w]; ok && current == uid {
+			res = append(res, w)
+		}
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// revoke builds target revocation workloads, jobs moved from previous owners get withheld from its new owner,
+// owners reports previous owners that keep being part of target, removed reports previous owners removed from
+// target, whose jobs get withheld until they are gone
+func revoke(running, target *config.Workloads) (*config.Workloads, []string, []string) {
+	plan := config.NewPlan(running, target)
+	withheld := map[config.Job]struct{}{}
+	releasing := map[string]struct{}{}
+	removing := map[string]struct{}{}
+	for _, m := range plan.Moves {
+		withheld[m.Job] = struct{}{}
+		if _, ok := target.Workloads[m.From]; !ok {
+			removing[m.From] = struct{}{}
+			continue
+		}
+		releasing[m.From] = struct{}{}
+	}
+
+	res := &config.Workloads{Version: target.Version, Workloads: make(map[string]*config.Workload, len(target.Workloads))}
+	for worker, w := range target.Workloads {
+		r := &config.Workload{Jobs: []config.Job{}}
+		if w == nil {
+			res.Workloads[worker] = r
+			continue
+		}
+		for _, j := range w.Jobs {
+			if _, ok := withheld[j]; !ok {
+				r.Jobs = append(r.Jobs, j)
+			}
+		}
+		if len(r.Jobs) == len(w.Jobs) {
+			r.Weight = w.Weight
+		}
+		res.Workloads[worker] = r
+	}
+
+	return res, sortedWorkers(releasing), sortedWorkers(removing)
+}
+
+func sortedWorkers(workers map[string]struct{}) []string {
+	res := make([]string, 0, len(workers))
+	for w := range workers {
+		res = append(res, w)
+	}
+	sort.Strings(res)
+
+	return res
+}
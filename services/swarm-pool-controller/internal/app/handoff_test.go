@@ -0,0 +1,196 @@
+package app
+
+import (
+	"context"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRevoke_ItWithholdsJobsMovedFromPreviousOwners(t *testing.T) {
+	running := handoffWorkloads(map[string][]config.Job{
+		"swarm-worker-0": {"a", "b"},
+		"swarm-worker-1": {"c"},
+		"swarm-worker-2": {"e"},
+	})
+	target := handoffWorkloads(map[string][]config.Job{
+		"swarm-worker-0": {"a"},
+		"swarm-worker-1": {"b", "c", "d", "e"},
+	})
+
+	revoked, owners, removed := revoke(running, target)
+
+	if expected, got := []string{"swarm-worker-0"}, owners; !reflect.DeepEqual(expected, got) {
+		t.Errorf("owners do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := []string{"swarm-worker-2"}, removed; !reflect.DeepEqual(expected, got) {
+		t.Errorf("removed owners do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := []config.Job{"a"}, revoked.Workloads["swarm-worker-0"].Jobs; !reflect.DeepEqual(expected, got) {
+		t.Errorf("previous owner jobs do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := []config.Job{"c", "d"}, revoked.Workloads["swarm-worker-1"].Jobs; !reflect.DeepEqual(expected, got) {
+		t.Errorf("new owner jobs do not match, expected %v got %v", expected, got)
+	}
+}
+
+func TestPool_ItAssignsMovedJobsOnceOwnersAcknowledgeRelease(t *testing.T) {
+	ctx := context.Background()
+	call := &fakeCaller{}
+	running := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a", "b"}, "swarm-worker-1": {"c"}})
+	target := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a"}, "swarm-worker-1": {"b", "c"}})
+	p := newHandoffPool(4, &fixedAssigner{workloads: target}, call, time.Minute, running)
+
+	if err := p.Dump(ctx, "", "swarm", "swarm-worker-config"); err != nil {
+		t.Fatalf("unable to dump pool %v", err)
+	}
+	if expected, got := []config.Job{"c"}, call.assignation.Workloads["swarm-worker-1"].Jobs; !reflect.DeepEqual(expected, got) {
+		t.Errorf("revoked new owner jobs do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := int64(4), call.assignation.Version; expected != got {
+		t.Errorf("revoked version does not match, expected %d got %d", expected, got)
+	}
+
+	pods := handoffPods("swarm-worker-0", "swarm-worker-1")
+	wait, err := p.Handoff(ctx, "", "swarm", "swarm-worker-config", map[string]int64{"swarm-worker-0": 3, "swarm-worker-1": 4}, pods)
+	if err != nil {
+		t.Fatalf("unexpected handoff error %v", err)
+	}
+	if wait <= 0 {
+		t.Fatalf("expected handoff waiting previous owner, got wait %s", wait)
+	}
+	if expected, got := int32(1), call.assigns; expected != got {
+		t.Errorf("total assigns do not match, expected %d got %d", expected, got)
+	}
+
+	if _, err := p.Handoff(ctx, "", "swarm", "swarm-worker-config", map[string]int64{"swarm-worker-0": 4}, pods); err != nil {
+		t.Fatalf("unexpected handoff error %v", err)
+	}
+	if expected, got := []config.Job{"b", "c"}, call.assignation.Workloads["swarm-worker-1"].Jobs; !reflect.DeepEqual(expected, got) {
+		t.Errorf("new owner jobs do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := int64(5), call.assignation.Version; expected != got {
+		t.Errorf("assigned version does not match, expected %d got %d", expected, got)
+	}
+	if p.Info().Handoff != nil {
+		t.Error("expected handoff completed")
+	}
+}
+
+func TestPool_ItRestartsLaggingOwnersOnHandoffTimeoutAndAssignsOnceRecreated(t *testing.T) {
+	ctx := context.Background()
+	call := &fakeCaller{}
+	running := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a", "b"}, "swarm-worker-1": {"c"}})
+	target := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a"}, "swarm-worker-1": {"b", "c"}})
+	p := newHandoffPool(4, &fixedAssigner{workloads: target}, call, time.Nanosecond, running)
+
+	if err := p.Dump(ctx, "", "swarm", "swarm-worker-config"); err != nil {
+		t.Fatalf("unable to dump pool %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	pods := handoffPods("swarm-worker-0", "swarm-worker-1")
+	wait, err := p.Handoff(ctx, "", "swarm", "swarm-worker-config", map[string]int64{}, pods)
+	if err != nil {
+		t.Fatalf("unexpected handoff error %v", err)
+	}
+	if wait <= 0 {
+		t.Errorf("expected handoff waiting restarted owner, got wait %s", wait)
+	}
+	if expected, got := []string{"swarm-worker-0"}, call.restarted; !reflect.DeepEqual(expected, got) {
+		t.Errorf("restarted workers do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := []config.Job{"c"}, call.assignation.Workloads["swarm-worker-1"].Jobs; !reflect.DeepEqual(expected, got) {
+		t.Errorf("new owner jobs do not match while restarting, expected %v got %v", expected, got)
+	}
+
+	// terminating pod keeps its uid
+	if _, err := p.Handoff(ctx, "", "swarm", "swarm-worker-config", map[string]int64{}, pods); err != nil {
+		t.Fatalf("unexpected handoff error %v", err)
+	}
+	if expected, got := int32(1), call.assigns; expected != got {
+		t.Errorf("total assigns do not match, expected %d got %d", expected, got)
+	}
+
+	pods["swarm-worker-0"] = "recreated"
+	if _, err := p.Handoff(ctx, "", "swarm", "swarm-worker-config", map[string]int64{}, pods); err != nil {
+		t.Fatalf("unexpected handoff error %v", err)
+	}
+	if expected, got := []string{"swarm-worker-0"}, call.restarted; !reflect.DeepEqual(expected, got) {
+		t.Errorf("restarted workers do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := []config.Job{"b", "c"}, call.assignation.Workloads["swarm-worker-1"].Jobs; !reflect.DeepEqual(expected, got) {
+		t.Errorf("new owner jobs do not match, expected %v got %v", expected, got)
+	}
+}
+
+func TestPool_ItAssignsJobsOfRemovedWorkersOnceTheyAreGone(t *testing.T) {
+	ctx := context.Background()
+	call := &fakeCaller{}
+	running := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a"}, "swarm-worker-1": {"b"}})
+	target := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a", "b"}})
+	p := newHandoffPool(4, &fixedAssigner{workloads: target}, call, time.Minute, running)
+
+	if err := p.Dump(ctx, "", "swarm", "swarm-worker-config"); err != nil {
+		t.Fatalf("unable to dump pool %v", err)
+	}
+	if expected, got := []config.Job{"a"}, call.assignation.Workloads["swarm-worker-0"].Jobs; !reflect.DeepEqual(expected, got) {
+		t.Errorf("revoked new owner jobs do not match, expected %v got %v", expected, got)
+	}
+
+	versions := map[string]int64{"swarm-worker-0": 4, "swarm-worker-1": 4}
+	wait, err := p.Handoff(ctx, "", "swarm", "swarm-worker-config", versions, handoffPods("swarm-worker-0", "swarm-worker-1"))
+	if err != nil {
+		t.Fatalf("unexpected handoff error %v", err)
+	}
+	if wait <= 0 {
+		t.Fatalf("expected handoff waiting removed worker, got wait %s", wait)
+	}
+	if expected, got := []string{"swarm-worker-1"}, p.Info().Handoff.Removed; !reflect.DeepEqual(expected, got) {
+		t.Errorf("removed workers do not match, expected %v got %v", expected, got)
+	}
+
+	if _, err := p.Handoff(ctx, "", "swarm", "swarm-worker-config", versions, handoffPods("swarm-worker-0")); err != nil {
+		t.Fatalf("unexpected handoff error %v", err)
+	}
+	if expected, got := []config.Job{"a", "b"}, call.assignation.Workloads["swarm-worker-0"].Jobs; !reflect.DeepEqual(expected, got) {
+		t.Errorf("new owner jobs do not match, expected %v got %v", expected, got)
+	}
+	if p.Info().Handoff != nil {
+		t.Error("expected handoff completed")
+	}
+}
+
+func handoffWorkloads(assignations map[string][]config.Job) *config.Workloads {
+	res := &config.Workloads{Workloads: map[string]*config.Workload{}}
+	for w, j := range assignations {
+		res.Workloads[w] = &config.Workload{Jobs: j}
+	}
+
+	return res
+}
+
+func handoffPods(workers ...string) map[string]types.UID {
+	res := map[string]types.UID{}
+	for _, w := range workers {
+		res[w] = types.UID(w)
+	}
+
+	return res
+}
+
+type fixedAssigner struct {
+	fakeAssigner
+	workloads *config.Workloads
+	workers   []string
+}
+
+func (a *fixedAssigner) Workloads() *config.Workloads {
+	return a.workloads
+}
+
+func (a *fixedAssigner) Workers() []string {
+	return a.workers
+}
@@ -4,39 +4,159 @@ import (
 	"context"
 	"fmt"
 	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	st "github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/balancer"
 	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/apis/swarm/v1alpha1"
 	v1alpha1Lister "github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/generated/listers/swarm/v1alpha1"
-	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
 	"sync"
+	"time"
 )
 
 type manager struct {
-	index       map[string]Pool
-	mutex       sync.RWMutex
-	delegated   delegated
-	swarmLister v1alpha1Lister.SwarmLister
+	index          map[string]Pool
+	slots          map[string]*slots
+	mutex          sync.RWMutex
+	delegated      delegated
+	swarmLister    v1alpha1Lister.SwarmLister
+	strategies     balancer.Registry
+	handoffTimeout time.Duration
+	unhealthyGrace time.Duration
 }
 
-func NewManager(d delegated, l v1alpha1Lister.SwarmLister) *manager {
+// storageResolver resolves empty swarm storage to the default one
+type storageResolver interface {
+	Storage(storage string) string
+}
+
+// NewManager instantiates swarm pools manager, swarm strategies get built from registry. Non zero handoff
+// timeout enables two phase rebalances, moved jobs get assigned once previous owners release them. Non zero
+// unhealthy grace moves jobs away from workers not ready for longer than it
+func NewManager(d delegated, l v1alpha1Lister.SwarmLister, r balancer.Registry, handoffTimeout, unhealthyGrace time.Duration) *manager {
 	return &manager{
-		index:       make(map[string]Pool),
-		delegated:   d,
-		swarmLister: l,
+		index:          make(map[string]Pool),
+		slots:          make(map[string]*slots),
+		delegated:      d,
+		swarmLister:    l,
+		strategies:     r,
+		handoffTimeout: handoffTimeout,
+		unhealthyGrace: unhealthyGrace,
 	}
 }
 
-func (m *manager) Process(ctx context.Context, namespace, name string, version int64, workloads []v1alpha1.Job) {
-	log.Infof("Adding swarm namespace %s name %s version %d total workloads %d", namespace, name, version, len(workloads))
+// Process registers swarm pool balanced by swarm strategy, updates keep previous pool assignations
+func (m *manager) Process(ctx context.Context, namespace, name string, spec v1alpha1.SwarmSpec) error {
+	if err := m.validateStorage(spec); err != nil {
+		return fmt.Errorf("invalid swarm %s/%s storage, error %v", namespace, name, err)
+	}
+
+	strategy, params := "", balancer.Params{}
+	if spec.Strategy != nil {
+		strategy = spec.Strategy.Name
+		for k, v := range spec.Strategy.Parameters {
+			params[k] = v
+		}
+	}
+
+	logger.FromContext(ctx).Infof("Adding swarm namespace %s name %s statefulset %s version %d total workloads %d weighted jobs %d strategy %s",
+		namespace, name, spec.StatefulSetName, spec.Version, len(spec.Workload), len(spec.Weights), strategy)
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	k := namespace + "/" + name
 	wp := []config.Job{}
-	for _, w := range workloads {
+	for _, w := range spec.Workload {
 		wp = append(wp, config.Job(w))
 	}
-	ast := newState(wp, k)
-	m.index[k] = newWorkerPool(version, ast, m.delegated)
+
+	var w balancer.Weights
+	if len(spec.Weights) > 0 {
+		w = balancer.Weights{}
+		for j, v := range spec.Weights {
+			w[config.Job(j)] = v
+		}
+	}
+
+	c := balancer.NewConstraints(wp, jobGroups(spec.Affinity), jobGroups(spec.AntiAffinity))
+	// invalid strategies keep previous pool
+	b, err := m.strategies.BuildConstrained(strategy, params, w, c)
+	if err != nil {
+		return fmt.Errorf("invalid swarm %s strategy, error %v", k, err)
+	}
+	if l := (balancer.Limits{MaxJobs: spec.MaxJobsPerWorker, MaxWeight: spec.MaxWeightPerWorker}); !l.Empty() {
+		b = balancer.NewCapped(b, l, w, c)
+	}
+
+	var previous, running, persisted *config.Workloads
+	var unhealthy map[string][]config.Job
+	var workerVersions map[string]int64
+	version := spec.Version
+	p, registered := m.index[k]
+	if registered {
+		info := p.Info()
+		previous, running, unhealthy = info.Workloads, p.Running(), p.Unhealthy()
+		persisted, workerVersions = p.Persisted(), info.WorkerVersions
+		if info.Version > version {
+			version = info.Version
+		}
+	}
+	// workers get named after statefulset pods, deployment and selector pools balance logical slots named
+	// after swarm, persisted on its current pods
+	setName, d := spec.StatefulSetName, m.delegated
+	if IsSlotPool(spec) {
+		if _, ok := m.slots[k]; !ok {
+			m.slots[k] = newSlots()
+		}
+		setName, d = name, &slotDelegated{delegated: m.delegated, slots: m.slots[k]}
+	} else {
+		delete(m.slots, k)
+	}
+
+	// pools registered after controller restarts know what workers may be running from its persisted workloads,
+	// sticky strategies keep its placements and unchanged workers its versions
+	if !registered {
+		if running, workerVersions = m.persisted(ctx, namespace, name, d); running != nil {
+			previous, persisted = running, running
+			if running.Version > version {
+				version = running.Version
+			}
+		}
+	}
+
+	st := newBalancedState(wp, setName, b, w, previous)
+	if len(unhealthy) > 0 {
+		st.unhealthy = unhealthy
+	}
+	m.index[k] = newPersistedPool(version, st, d, m.handoffTimeout, running, persisted, workerVersions)
+
+	return nil
+}
+
+// persisted loads swarm persisted workloads and worker versions, slot pools get its slots restored from swarm
+// status first
+func (m *manager) persisted(ctx context.Context, namespace, name string, d delegated) (*config.Workloads, map[string]int64) {
+	if m.swarmLister == nil {
+		return nil, nil
+	}
+
+	sw, err := m.swarmLister.Swarms(namespace).Get(name)
+	if err != nil {
+		return nil, nil
+	}
+	if sl, ok := m.slots[namespace+"/"+name]; ok {
+		sl.Seed(sw.Status.Slots)
+	}
+
+	storage, target := storageTarget(sw)
+	w, versions, err := d.Load(ctx, storage, namespace, target)
+	if err != nil {
+		logger.FromContext(ctx).Debugf("no persisted workloads on swarm %s %s, error %v", namespace, name, err)
+		return nil, nil
+	}
+
+	return w, versions
 }
 
 func (m *manager) UpdateSize(ctx context.Context, namespace, name string, size int) (int64, error) {
@@ -58,15 +178,142 @@ func (m *manager) UpdateSize(ctx context.Context, namespace, name string, size i
 		return 0, fmt.Errorf("unable to find swarm %s error %v", name, err)
 	}
 
-	if err := m.index[k].Dump(ctx, namespace, sw.Spec.ConfigMapName); err != nil {
+	storage, target := storageTarget(sw)
+	if err := m.index[k].Dump(ctx, storage, namespace, target); err != nil {
 		return v, fmt.Errorf("unable to dump swarm %s error %v", name, err)
 	}
 
 	return v, nil
 }
 
+// Handoff completes swarm pending handoff once previous owners acknowledge revoked version and removed or
+// restarted ones are gone, returns remaining time to wait for them
+func (m *manager) Handoff(ctx context.Context, namespace, name string, versions map[string]int64, pods map[string]types.UID) (time.Duration, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	k := namespace + "/" + name
+	p, ok := m.index[k]
+	if !ok || p.Info().Handoff == nil {
+		return 0, nil
+	}
+
+	sw, err := m.swarmLister.Swarms(namespace).Get(name)
+	if err != nil {
+		return 0, fmt.Errorf("unable to find swarm %s error %v", name, err)
+	}
+
+	storage, target := storageTarget(sw)
+
+	return p.Handoff(ctx, storage, namespace, target, versions, pods)
+}
+
+// UpdateSlots assigns slot pool slots to live pods, previous mapping seeds pools without one (controller
+// restarts). Slots whose pod changes get its workload persisted on the new pod
+func (m *manager) UpdateSlots(ctx context.Context, namespace, name string, pods []string, size int, previous map[string]string) (map[string]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	k := namespace + "/" + name
+	p, ok := m.index[k]
+	sl, slotted := m.slots[k]
+	if !ok || !slotted {
+		return nil, fmt.Errorf("no slot pool %s registered", k)
+	}
+
+	if !sl.Update(name, pods, size, previous) {
+		return sl.Index(), nil
+	}
+	logger.FromContext(ctx).Infof("Swarm %s slots updated %v", k, sl.Index())
+
+	// size changes get persisted on pool size update
+	if p.Size() != size {
+		return sl.Index(), nil
+	}
+
+	sw, err := m.swarmLister.Swarms(namespace).Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find swarm %s error %v", name, err)
+	}
+
+	storage, target := storageTarget(sw)
+	if err := p.Dump(ctx, storage, namespace, target); err != nil {
+		return nil, fmt.Errorf("unable to dump swarm %s error %v", name, err)
+	}
+
+	return sl.Index(), nil
+}
+
+// Slots returns slot pool slot to pod mapping, nil on statefulset pools
+func (m *manager) Slots(namespace, name string) map[string]string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	sl, ok := m.slots[namespace+"/"+name]
+	if !ok {
+		return nil
+	}
+
+	return sl.Index()
+}
+
+// UpdateHealth excludes pool workers not ready for longer than unhealthy grace from balancing, recovered
+// workers get its jobs back. Returns remaining time until next not ready worker grace expires
+func (m *manager) UpdateHealth(ctx context.Context, namespace, name string, notReady map[string]time.Time) (time.Duration, error) {
+	if m.unhealthyGrace <= 0 {
+		return 0, nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	k := namespace + "/" + name
+	p, ok := m.index[k]
+	if !ok {
+		return 0, nil
+	}
+
+	info := p.Info()
+	var unhealthy []string
+	var wait time.Duration
+	for w, since := range notReady {
+		if info.Workloads == nil || info.Workloads.Workloads[w] == nil {
+			continue
+		}
+		remaining := time.Until(since.Add(m.unhealthyGrace))
+		if remaining <= 0 {
+			unhealthy = append(unhealthy, w)
+			continue
+		}
+		if wait == 0 || remaining < wait {
+			wait = remaining
+		}
+	}
+	sort.Strings(unhealthy)
+
+	_, changed, err := p.UpdateUnhealthy(ctx, unhealthy)
+	if err != nil {
+		return 0, fmt.Errorf("unable to update swarm %s unhealthy workers error %v", name, err)
+	}
+	if !changed {
+		return wait, nil
+	}
+
+	sw, err := m.swarmLister.Swarms(namespace).Get(name)
+	if err != nil {
+		return 0, fmt.Errorf("unable to find swarm %s error %v", name, err)
+	}
+
+	storage, target := storageTarget(sw)
+	if err := p.Dump(ctx, storage, namespace, target); err != nil {
+		return 0, fmt.Errorf("unable to dump swarm %s error %v", name, err)
+	}
+
+	return wait, nil
+}
+
 func (m *manager) Delete(ctx context.Context, namespace, name string) {
-	log.Infof("Delete swarm namespace %s name %s", namespace, name)
+	logger.FromContext(ctx).Infof("Delete swarm namespace %s name %s", namespace, name)
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -76,4 +323,105 @@ func (m *manager) Delete(ctx context.Context, namespace, name string) {
 	}
 
 	delete(m.index, k)
+	delete(m.slots, k)
+}
+
+// Pool returns swarm pool info
+func (m *manager) Pool(namespace, name string) (PoolInfo, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	k := namespace + "/" + name
+	p, ok := m.index[k]
+	if !ok {
+		return PoolInfo{}, false
+	}
+
+	return m.info(k, p), true
+}
+
+// Pools returns registered pools info sorted by key
+func (m *manager) Pools() []PoolInfo {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	res := make([]PoolInfo, 0, len(m.index))
+	for k, p := range m.index {
+		res = append(res, m.info(k, p))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+
+	return res
+}
+
+// info returns pool info, jobs of slot pool slots without pod get reported as unassigned
+func (m *manager) info(k string, p Pool) PoolInfo {
+	info := p.Info()
+	info.Key = k
+	if sl, ok := m.slots[k]; ok && info.Workloads != nil {
+		if unplaced := sl.Unplaced(info.Workloads); len(unplaced) > 0 {
+			info.Unassigned = append(append([]config.Job{}, info.Unassigned...), unplaced...)
+		}
+	}
+
+	return info
+}
+
+// validateStorage rejects per worker configmaps on slot pools, its configmaps are named after statefulset pod
+// ordinals and deployment or selector pods have none
+func (m *manager) validateStorage(spec v1alpha1.SwarmSpec) error {
+	if !IsSlotPool(spec) {
+		return nil
+	}
+
+	var storage string
+	if spec.Storage != nil {
+		storage = spec.Storage.Type
+	}
+	if r, ok := m.delegated.(storageResolver); ok {
+		storage = r.Storage(storage)
+	}
+	if storage == st.WorkerConfigMap {
+		return fmt.Errorf("%s storage requires a statefulset worker pool", st.WorkerConfigMap)
+	}
+
+	return nil
+}
+
+// IsSlotPool checks if swarm workers are a deployment or label selector pods instead of a statefulset
+func IsSlotPool(spec v1alpha1.SwarmSpec) bool {
+	return spec.StatefulSetName == "" && (spec.DeploymentName != "" || spec.Selector != nil)
+}
+
+// storageTarget resolves swarm storage backend and target name, status storage targets swarm itself
+func storageTarget(sw *v1alpha1.Swarm) (storage, name string) {
+	name = sw.Spec.ConfigMapName
+	if sw.Spec.Storage == nil {
+		return "", name
+	}
+
+	storage = sw.Spec.Storage.Type
+	if storage == st.Status {
+		name = sw.Name
+	}
+	if sw.Spec.Storage.Name != "" {
+		name = sw.Spec.Storage.Name
+	}
+
+	return storage, name
+}
+
+func jobGroups(groups [][]v1alpha1.Job) [][]config.Job {
+	res := make([][]config.Job, 0, len(groups))
+	for _, g := range groups {
+		jobs := make([]config.Job, 0, len(g))
+		for _, j := range g {
+			jobs = append(jobs, config.Job(j))
+		}
+		res = append(res, jobs)
+	}
+
+	return res
 }
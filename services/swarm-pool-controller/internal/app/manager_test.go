@@ -1 +1,275 @@
 package app
+
+import (
+	"context"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/balancer"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/apis/swarm/v1alpha1"
+	v1alpha1Lister "github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/generated/listers/swarm/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestManager_ItKeepsPlacementsOnSwarmWorkloadUpdates(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(&fakeCaller{}, nil, balancer.NewRegistry(balancer.DefaultTolerance), 0, 0)
+	spec := v1alpha1.SwarmSpec{StatefulSetName: "swarm-worker", Workload: []v1alpha1.Job{}}
+	for _, j := range jobs {
+		spec.Workload = append(spec.Workload, v1alpha1.Job(j))
+	}
+
+	if err := m.Process(ctx, "swarm", "foo", spec); err != nil {
+		t.Fatalf("unable to process swarm %v", err)
+	}
+	if _, err := m.index["swarm/foo"].UpdateSize(ctx, 3); err != nil {
+		t.Fatalf("unable to update size %v", err)
+	}
+	before := jobOwners(t, m.Pools()[0].Workloads)
+
+	spec.Workload = append(spec.Workload, "stream:new")
+	if err := m.Process(ctx, "swarm", "foo", spec); err != nil {
+		t.Fatalf("unable to process swarm %v", err)
+	}
+	if _, err := m.index["swarm/foo"].UpdateSize(ctx, 3); err != nil {
+		t.Fatalf("unable to update size %v", err)
+	}
+	after := jobOwners(t, m.Pools()[0].Workloads)
+
+	if expected, got := len(jobs)+1, len(after); expected != got {
+		t.Fatalf("total assigned jobs do not match, expected %d got %d", expected, got)
+	}
+	for j, w := range before {
+		if after[j] != w {
+			t.Errorf("job %s moved from %s to %s", j, w, after[j])
+		}
+	}
+}
+
+func TestManager_ItMovesJobsAwayFromUnhealthyWorkersUntilTheyRecover(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(&fakeCaller{}, nil, balancer.NewRegistry(balancer.DefaultTolerance), 0, time.Minute)
+	spec := v1alpha1.SwarmSpec{StatefulSetName: "swarm-worker", Workload: []v1alpha1.Job{}}
+	for _, j := range jobs {
+		spec.Workload = append(spec.Workload, v1alpha1.Job(j))
+	}
+	if err := m.Process(ctx, "swarm", "foo", spec); err != nil {
+		t.Fatalf("unable to process swarm %v", err)
+	}
+	p := m.index["swarm/foo"]
+	if _, err := p.UpdateSize(ctx, 3); err != nil {
+		t.Fatalf("unable to update size %v", err)
+	}
+	before := jobOwners(t, m.Pools()[0].Workloads)
+
+	wait, err := m.UpdateHealth(ctx, "swarm", "foo", map[string]time.Time{"swarm-worker-1": time.Now()})
+	if err != nil {
+		t.Fatalf("unexpected error updating health %v", err)
+	}
+	if wait <= 0 || wait > time.Minute {
+		t.Errorf("expected waiting unhealthy grace, got %s", wait)
+	}
+	if len(m.Pools()[0].Unhealthy) != 0 {
+		t.Fatal("expected worker on grace period kept")
+	}
+
+	if _, changed, err := p.UpdateUnhealthy(ctx, []string{"swarm-worker-1"}); err != nil || !changed {
+		t.Fatalf("expected unhealthy workers update, changed %t error %v", changed, err)
+	}
+	during := jobOwners(t, m.Pools()[0].Workloads)
+	if expected, got := len(jobs), len(during); expected != got {
+		t.Fatalf("total assigned jobs do not match, expected %d got %d", expected, got)
+	}
+	for j, w := range during {
+		if w == "swarm-worker-1" {
+			t.Errorf("job %s kept on unhealthy worker", j)
+		}
+	}
+
+	if _, changed, err := p.UpdateUnhealthy(ctx, nil); err != nil || !changed {
+		t.Fatalf("expected unhealthy workers update, changed %t error %v", changed, err)
+	}
+	after := jobOwners(t, m.Pools()[0].Workloads)
+	for j, w := range before {
+		if after[j] != w {
+			t.Errorf("job %s not returned to %s, got %s", j, w, after[j])
+		}
+	}
+}
+
+func TestManager_ItKeepsPreviousPoolOnInvalidStrategies(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(&fakeCaller{}, nil, balancer.NewRegistry(balancer.DefaultTolerance), 0, 0)
+	spec := v1alpha1.SwarmSpec{StatefulSetName: "swarm-worker", Workload: []v1alpha1.Job{"foo", "bar"}}
+	if err := m.Process(ctx, "swarm", "foo", spec); err != nil {
+		t.Fatalf("unable to process swarm %v", err)
+	}
+	p := m.index["swarm/foo"]
+
+	spec.Strategy = &v1alpha1.Strategy{Name: "foo"}
+	if err := m.Process(ctx, "swarm", "foo", spec); err == nil {
+		t.Fatal("expected unknown strategy error")
+	}
+	if m.index["swarm/foo"] != p {
+		t.Error("expected previous pool kept")
+	}
+}
+
+func TestManager_ItRejectsWorkerConfigMapStorageOnSlotPools(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(&fakeCaller{}, nil, balancer.NewRegistry(balancer.DefaultTolerance), 0, 0)
+	spec := v1alpha1.SwarmSpec{
+		DeploymentName: "swarm-worker",
+		Workload:       []v1alpha1.Job{"foo", "bar"},
+		Storage:        &v1alpha1.Storage{Type: storage.WorkerConfigMap},
+	}
+	if err := m.Process(ctx, "swarm", "foo", spec); err == nil {
+		t.Fatal("expected worker configmap storage error on deployment pool")
+	}
+	if _, ok := m.index["swarm/foo"]; ok {
+		t.Error("unexpected pool registered")
+	}
+
+	spec.Storage = nil
+	ex, err := NewExecutor(storage.WorkerConfigMap, StorageRegistry{storage.WorkerConfigMap: storage.NewMemoryStorage()}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building executor, error %v", err)
+	}
+	m = NewManager(ex, nil, balancer.NewRegistry(balancer.DefaultTolerance), 0, 0)
+	spec.DeploymentName, spec.Selector = "", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "swarm-worker"}}
+	if err := m.Process(ctx, "swarm", "foo", spec); err == nil {
+		t.Fatal("expected default worker configmap storage error on selector pool")
+	}
+
+	spec.Selector, spec.StatefulSetName = nil, "swarm-worker"
+	if err := m.Process(ctx, "swarm", "foo", spec); err != nil {
+		t.Errorf("unexpected error on statefulset pool, error %v", err)
+	}
+}
+
+func TestManager_ItSeedsRunningWorkloadsFromPersistedOnesAfterRestarts(t *testing.T) {
+	ctx := context.Background()
+	spec := v1alpha1.SwarmSpec{StatefulSetName: "swarm-worker", Workload: []v1alpha1.Job{"a", "b", "c"}, Strategy: &v1alpha1.Strategy{Name: balancer.RoundRobin}}
+	call := &fakeCaller{persisted: handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"c"}, "swarm-worker-1": {"a", "b"}})}
+	call.persisted.Version = 7
+	m := NewManager(call, swarmLister(t, &v1alpha1.Swarm{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "swarm"}, Spec: spec}), balancer.NewRegistry(balancer.DefaultTolerance), time.Minute, 0)
+
+	if err := m.Process(ctx, "swarm", "foo", spec); err != nil {
+		t.Fatalf("unable to process swarm %v", err)
+	}
+	if _, err := m.UpdateSize(ctx, "swarm", "foo", 2); err != nil {
+		t.Fatalf("unable to update size %v", err)
+	}
+
+	info, _ := m.Pool("swarm", "foo")
+	if info.Handoff == nil {
+		t.Fatal("expected handoff revoking jobs moved from persisted owners")
+	}
+	if expected, got := int64(8), info.Version; expected != got {
+		t.Errorf("version does not match, expected %d got %d", expected, got)
+	}
+	for w, wl := range call.assignation.Workloads {
+		if len(wl.Jobs) != 0 {
+			t.Errorf("worker %s unexpected jobs %v before previous owners release them", w, wl.Jobs)
+		}
+	}
+}
+
+func TestManager_ItKeepsPersistedPlacementsAfterRestarts(t *testing.T) {
+	ctx := context.Background()
+	spec := v1alpha1.SwarmSpec{StatefulSetName: "swarm-worker", Workload: []v1alpha1.Job{"a", "b", "c", "d"}}
+	persisted := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a", "d"}, "swarm-worker-1": {"b", "c"}})
+	call := &fakeCaller{persisted: persisted}
+	m := NewManager(call, swarmLister(t, &v1alpha1.Swarm{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "swarm"}, Spec: spec}), balancer.NewRegistry(balancer.DefaultTolerance), 0, 0)
+
+	if err := m.Process(ctx, "swarm", "foo", spec); err != nil {
+		t.Fatalf("unable to process swarm %v", err)
+	}
+	if _, err := m.UpdateSize(ctx, "swarm", "foo", 2); err != nil {
+		t.Fatalf("unable to update size %v", err)
+	}
+
+	expected, got := jobOwners(t, persisted), jobOwners(t, call.assignation)
+	for j, w := range expected {
+		if got[j] != w {
+			t.Errorf("job %s moved from %s to %s", j, w, got[j])
+		}
+	}
+}
+
+func TestManager_ItKeepsWorkerVersionsOfUnchangedWorkloadsOnSwarmUpdates(t *testing.T) {
+	ctx := context.Background()
+	call := &fakeCaller{}
+	m := NewManager(call, nil, balancer.NewRegistry(balancer.DefaultTolerance), 0, 0)
+	spec := v1alpha1.SwarmSpec{StatefulSetName: "swarm-worker", Workload: []v1alpha1.Job{"a", "b", "c", "d"}}
+
+	if err := m.Process(ctx, "swarm", "foo", spec); err != nil {
+		t.Fatalf("unable to process swarm %v", err)
+	}
+	if _, err := m.index["swarm/foo"].UpdateSize(ctx, 2); err != nil {
+		t.Fatalf("unable to update size %v", err)
+	}
+	if err := m.index["swarm/foo"].Dump(ctx, "", "swarm", "foo-config"); err != nil {
+		t.Fatalf("unable to dump pool %v", err)
+	}
+
+	spec.Workload = append(spec.Workload, "e")
+	if err := m.Process(ctx, "swarm", "foo", spec); err != nil {
+		t.Fatalf("unable to process swarm %v", err)
+	}
+	if _, err := m.index["swarm/foo"].UpdateSize(ctx, 2); err != nil {
+		t.Fatalf("unable to update size %v", err)
+	}
+	if err := m.index["swarm/foo"].Dump(ctx, "", "swarm", "foo-config"); err != nil {
+		t.Fatalf("unable to dump pool %v", err)
+	}
+
+	info, _ := m.Pool("swarm", "foo")
+	changed := jobOwners(t, info.Workloads)["e"]
+	for w, v := range info.WorkerVersions {
+		expected := int64(1)
+		if w == changed {
+			expected = info.Version
+		}
+		if expected != v {
+			t.Errorf("worker %s version does not match, expected %d got %d", w, expected, v)
+		}
+	}
+}
+
+func TestManager_ItSeedsWorkerVersionsFromPersistedOnesAfterRestarts(t *testing.T) {
+	ctx := context.Background()
+	spec := v1alpha1.SwarmSpec{StatefulSetName: "swarm-worker", Workload: []v1alpha1.Job{"a", "b", "c", "d"}}
+	call := &fakeCaller{
+		persisted: handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a", "d"}, "swarm-worker-1": {"b", "c"}}),
+		versions:  map[string]int64{"swarm-worker-0": 3, "swarm-worker-1": 5},
+	}
+	call.persisted.Version = 5
+	m := NewManager(call, swarmLister(t, &v1alpha1.Swarm{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "swarm"}, Spec: spec}), balancer.NewRegistry(balancer.DefaultTolerance), 0, 0)
+
+	if err := m.Process(ctx, "swarm", "foo", spec); err != nil {
+		t.Fatalf("unable to process swarm %v", err)
+	}
+	if _, err := m.UpdateSize(ctx, "swarm", "foo", 2); err != nil {
+		t.Fatalf("unable to update size %v", err)
+	}
+
+	info, _ := m.Pool("swarm", "foo")
+	if !reflect.DeepEqual(call.versions, info.WorkerVersions) {
+		t.Errorf("worker versions do not match, expected %v got %v", call.versions, info.WorkerVersions)
+	}
+}
+
+func swarmLister(t *testing.T, sws ...*v1alpha1.Swarm) v1alpha1Lister.SwarmLister {
+	idx := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, sw := range sws {
+		if err := idx.Add(sw); err != nil {
+			t.Fatalf("unable to add swarm %v", err)
+		}
+	}
+
+	return v1alpha1Lister.NewSwarmLister(idx)
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// UnassignedJobsReason tags warning events on jobs that do not fit on swarm workers
+const UnassignedJobsReason = "UnassignedJobs"
+
+// UnhealthyWorkersReason tags warning events on workers excluded from balancing while not ready
+const UnhealthyWorkersReason = "UnhealthyWorkers"
+
+var unassignedJobs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "swarm_unassigned_jobs",
+	Help: "Swarm jobs without worker by namespace and swarm name",
+}, []string{"namespace", "swarm"})
+
+func init() {
+	prometheus.MustRegister(unassignedJobs)
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/marcosQuesada/k8s-lab/pkg/config"
-	log "github.com/sirupsen/logrus"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/balancer"
+	"k8s.io/apimachinery/pkg/types"
+	"sort"
 	"sync"
 	"time"
 )
@@ -13,27 +16,58 @@ const defaultTimeout = time.Second
 
 type Pool interface {
 	Size() int
+	Info() PoolInfo
 	UpdateSize(context.Context, int) (version int64, err error)
-	Dump(ctx context.Context, namespace, configMapName string) error
+	UpdateUnhealthy(ctx context.Context, workers []string) (version int64, changed bool, err error)
+	Unhealthy() map[string][]config.Job
+	Dump(ctx context.Context, storage, namespace, name string) error
+	Handoff(ctx context.Context, storage, namespace, name string, versions map[string]int64, pods map[string]types.UID) (time.Duration, error)
+	Running() *config.Workloads
+	Persisted() *config.Workloads
+}
+
+// PoolInfo reports pool version, size and current assignments, worker versions hold each worker last changed
+// workload version
+type PoolInfo struct {
+	Key            string                 `json:"key"`
+	Version        int64                  `json:"version"`
+	Size           int                    `json:"size"`
+	Workloads      *config.Workloads      `json:"workloads"`
+	Unsatisfied    []balancer.Unsatisfied `json:"unsatisfied,omitempty"`
+	Unassigned     []config.Job           `json:"unassigned,omitempty"`
+	Handoff        *HandoffInfo           `json:"handoff,omitempty"`
+	WorkerVersions map[string]int64       `json:"workerVersions,omitempty"`
+	Unhealthy      []string               `json:"unhealthy,omitempty"`
 }
 
 type workloadBalancer interface {
-	BalanceWorkload(totalWorkers int, version int64) (*config.Workloads, error)
+	BalanceWorkload(ctx context.Context, totalWorkers int, version int64) (*config.Workloads, error)
 	Workloads() *config.Workloads
+	Workers() []string
+	Unsatisfied() []balancer.Unsatisfied
+	Unassigned() []config.Job
+	UpdateUnhealthy(workers []string) bool
+	Unhealthy() map[string][]config.Job
 }
 
 // @TODO: Refactor and remove
 type delegated interface {
-	Assign(ctx context.Context, namespace, configMapName string, w *config.Workloads) error
+	Assign(ctx context.Context, storage, namespace, name string, w *config.Workloads) error
+	Load(ctx context.Context, storage, namespace, name string) (*config.Workloads, map[string]int64, error)
 	RestartWorker(ctx context.Context, namespace, name string) error
 }
 
 type pool struct {
-	state     workloadBalancer
-	delegated delegated
-	version   int64
-	size      int
-	mutex     sync.RWMutex
+	state          workloadBalancer
+	delegated      delegated
+	version        int64
+	size           int
+	handoffTimeout time.Duration
+	running        *config.Workloads
+	pending        *handoff
+	persisted      *config.Workloads
+	workerVersions map[string]int64
+	mutex          sync.RWMutex
 }
 
 // newWorkerPool instantiates workers pool
@@ -45,6 +79,26 @@ func newWorkerPool(version int64, cmp workloadBalancer, not delegated) Pool {
 	}
 }
 
+// newHandoffPool instantiates workers pool with two phase rebalances, running workloads are the ones workers
+// may still be running, zero timeout disables handoffs
+func newHandoffPool(version int64, cmp workloadBalancer, not delegated, timeout time.Duration, running *config.Workloads) Pool {
+	return newPersistedPool(version, cmp, not, timeout, running, nil, nil)
+}
+
+// newPersistedPool instantiates handoff pool from its last persisted workloads, workers keep its persisted
+// versions while its workload does not change
+func newPersistedPool(version int64, cmp workloadBalancer, not delegated, timeout time.Duration, running, persisted *config.Workloads, workerVersions map[string]int64) Pool {
+	return &pool{
+		version:        version,
+		state:          cmp,
+		delegated:      not,
+		handoffTimeout: timeout,
+		running:        running,
+		persisted:      persisted,
+		workerVersions: workerVersions,
+	}
+}
+
 // UpdateSize sets pool expected size
 func (p *pool) UpdateSize(ctx context.Context, newSize int) (version int64, err error) {
 	p.mutex.Lock()
@@ -58,21 +112,170 @@ func (p *pool) UpdateSize(ctx context.Context, newSize int) (version int64, err
 	p.size = newSize
 	p.version++
 
-	log.Infof("Pool Version Update %d Size From %d to %d", p.version, previousSize, newSize)
+	logger.FromContext(ctx).Infof("Pool Version Update %d Size From %d to %d", p.version, previousSize, newSize)
 
-	_, err = p.state.BalanceWorkload(newSize, p.version)
+	previous := p.state.Workloads().Normalize()
+	current, err := p.state.BalanceWorkload(ctx, newSize, p.version)
 	if err != nil {
 		return p.version, fmt.Errorf("err on balance workload %v", err)
 	}
 
+	p.logPlan(ctx, config.NewPlan(previous, current))
+
 	return p.version, nil
 }
 
-func (p *pool) Dump(ctx context.Context, namespace, configMapName string) error {
-	wkl := p.state.Workloads()
-	if err := p.delegated.Assign(ctx, namespace, configMapName, wkl); err != nil {
-		return fmt.Errorf("unable to dump workload on namespace %s configMapName %s error %v", namespace, configMapName, err)
+// UpdateUnhealthy rebalances pool without unhealthy workers, recovered workers get its jobs back. Version only
+// moves when unhealthy workers change
+func (p *pool) UpdateUnhealthy(ctx context.Context, workers []string) (int64, bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	previous := p.state.Workloads().Normalize()
+	if !p.state.UpdateUnhealthy(workers) {
+		return p.version, false, nil
+	}
+	p.version++
+
+	logger.FromContext(ctx).Infof("Pool Version Update %d unhealthy workers %v", p.version, workers)
+
+	current, err := p.state.BalanceWorkload(ctx, p.size, p.version)
+	if err != nil {
+		return p.version, true, fmt.Errorf("err on balance workload %v", err)
+	}
+
+	p.logPlan(ctx, config.NewPlan(previous, current))
+
+	return p.version, true, nil
+}
+
+// Unhealthy returns workers excluded from balancing and the jobs they had when excluded
+func (p *pool) Unhealthy() map[string][]config.Job {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.state.Unhealthy()
+}
+
+// Dump persists pool workloads, on handoff pools jobs moved between running workers get revoked first from
+// its previous owners and assigned once they acknowledge it
+func (p *pool) Dump(ctx context.Context, storage, namespace, name string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	wkl := p.state.Workloads().Normalize()
+	wkl.Version = p.version
+	if p.handoffTimeout > 0 && p.running != nil {
+		revoked, owners, removed := revoke(p.running, wkl)
+		if len(owners) > 0 || len(removed) > 0 {
+			if err := p.persist(ctx, storage, namespace, name, revoked, p.state.Workers()); err != nil {
+				return fmt.Errorf("unable to dump revoked workload on namespace %s name %s error %v", namespace, name, err)
+			}
+
+			deadline := time.Now().Add(p.handoffTimeout)
+			var restarted map[string]types.UID
+			if p.pending != nil && p.pending.version == revoked.Version {
+				deadline, restarted = p.pending.deadline, p.pending.restarted
+			}
+			p.pending = &handoff{version: revoked.Version, target: wkl, owners: owners, removed: removed, deadline: deadline, restarted: restarted}
+			logger.FromContext(ctx).Infof("Handoff version %d waiting workers %v to release moved jobs, removed workers %v to be gone", revoked.Version, owners, removed)
+
+			return nil
+		}
+	}
+
+	return p.assign(ctx, storage, namespace, name, wkl, p.state.Workers())
+}
+
+// Handoff assigns pending handoff target once all previous owners acknowledge revoked version and removed
+// owners pods are gone, expired handoffs restart lagging owners and wait them to be gone or recreated before
+// assigning it. Pods index worker pods uid, terminating ones included. Returns remaining time while waiting
+func (p *pool) Handoff(ctx context.Context, storage, namespace, name string, versions map[string]int64, pods map[string]types.UID) (time.Duration, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.pending == nil {
+		return 0, nil
+	}
+
+	lagging := p.pending.lagging(versions, pods)
+	if len(lagging) > 0 {
+		if wait := time.Until(p.pending.deadline); wait > 0 {
+			return wait, nil
+		}
+
+		logger.FromContext(ctx).Warnf("Handoff version %d timeout, restarting workers %v", p.pending.version, lagging)
+		for _, w := range lagging {
+			if err := p.delegated.RestartWorker(ctx, namespace, w); err != nil {
+				return 0, fmt.Errorf("unable to restart worker %s error %v", w, err)
+			}
+			p.pending.restart(w, pods)
+		}
+	}
+
+	// restarted pods keep running its jobs while terminating
+	if restarting := p.pending.restarting(pods); len(restarting) > 0 {
+		logger.FromContext(ctx).Infof("Handoff version %d waiting restarted workers %v to be gone", p.pending.version, restarting)
+		return defaultTimeout, nil
+	}
+
+	p.version++
+	target := p.pending.target
+	target.Version = p.version
+
+	// target workers got validated with its revocation version
+	return 0, p.assign(ctx, storage, namespace, name, target, nil)
+}
+
+// Running returns workloads that workers may be running
+func (p *pool) Running() *config.Workloads {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.running
+}
+
+// Persisted returns last persisted workloads
+func (p *pool) Persisted() *config.Workloads {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.persisted
+}
+
+func (p *pool) assign(ctx context.Context, storage, namespace, name string, wkl *config.Workloads, workers []string) error {
+	if err := p.persist(ctx, storage, namespace, name, wkl, workers); err != nil {
+		return fmt.Errorf("unable to dump workload on namespace %s name %s error %v", namespace, name, err)
+	}
+	p.running = wkl
+	p.pending = nil
+
+	return nil
+}
+
+// persist assigns workloads once validated against expected workers and last persisted version, workers whose
+// workload changed from last persisted one move to its version
+func (p *pool) persist(ctx context.Context, storage, namespace, name string, wkl *config.Workloads, workers []string) error {
+	if err := wkl.ValidateUpdate(p.persisted, workers); err != nil {
+		return fmt.Errorf("invalid workloads version %d, error %v", wkl.Version, err)
+	}
+
+	if err := p.delegated.Assign(ctx, storage, namespace, name, wkl); err != nil {
+		return err
+	}
+
+	versions := make(map[string]int64, len(wkl.Workloads))
+	for worker, w := range wkl.Workloads {
+		versions[worker] = wkl.Version
+		if p.persisted == nil {
+			continue
+		}
+		if v, ok := p.workerVersions[worker]; ok && w != nil && w.Equals(p.persisted.Workloads[worker]) {
+			versions[worker] = v
+		}
 	}
+	p.persisted = wkl
+	p.workerVersions = versions
 
 	return nil
 }
@@ -82,3 +285,44 @@ func (p *pool) Size() int {
 	defer p.mutex.RUnlock()
 	return p.size
 }
+
+// Info returns pool introspection info, key gets filled by manager
+func (p *pool) Info() PoolInfo {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return PoolInfo{
+		Version:        p.version,
+		Size:           p.size,
+		Workloads:      p.state.Workloads().Normalize(),
+		Unsatisfied:    p.state.Unsatisfied(),
+		Unassigned:     p.state.Unassigned(),
+		Handoff:        p.pending.info(),
+		WorkerVersions: p.workerVersions,
+		Unhealthy:      unhealthyWorkers(p.state.Unhealthy()),
+	}
+}
+
+func unhealthyWorkers(unhealthy map[string][]config.Job) []string {
+	var res []string
+	for w := range unhealthy {
+		res = append(res, w)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+func (p *pool) logPlan(ctx context.Context, plan *config.Plan) {
+	log := logger.FromContext(ctx)
+	log.Infof("Pool rebalance plan %s affected workers %v", plan, plan.AffectedWorkers())
+	for _, m := range plan.Moves {
+		log.Debugf("job moved %s", m)
+	}
+	for _, m := range plan.Added {
+		log.Debugf("job added %s", m)
+	}
+	for _, m := range plan.Removed {
+		log.Debugf("job removed %s", m)
+	}
+}
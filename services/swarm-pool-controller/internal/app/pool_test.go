@@ -2,10 +2,14 @@ package app
 
 import (
 	"context"
+	"errors"
 	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/balancer"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 var version int64 = 1
@@ -31,12 +35,62 @@ func TestOnWorkerPoolUpdateStateGetsBalancedAndVersionUpdated(t *testing.T) {
 	}
 }
 
+func TestPool_ItKeepsWorkerVersionsOfUnchangedWorkloads(t *testing.T) {
+	ctx := context.Background()
+	running := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a", "b"}, "swarm-worker-1": {"c"}})
+	target := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a"}, "swarm-worker-1": {"b", "c"}})
+	p := newHandoffPool(4, &fixedAssigner{workloads: target}, &fakeCaller{}, time.Minute, running)
+
+	if err := p.Dump(ctx, "", "swarm", "swarm-worker-config"); err != nil {
+		t.Fatalf("unable to dump pool %v", err)
+	}
+	if _, err := p.Handoff(ctx, "", "swarm", "swarm-worker-config", map[string]int64{"swarm-worker-0": 4}, handoffPods("swarm-worker-0", "swarm-worker-1")); err != nil {
+		t.Fatalf("unexpected handoff error %v", err)
+	}
+
+	expected := map[string]int64{"swarm-worker-0": 4, "swarm-worker-1": 5}
+	if got := p.Info().WorkerVersions; !reflect.DeepEqual(expected, got) {
+		t.Errorf("worker versions do not match, expected %v got %v", expected, got)
+	}
+}
+
+func TestPool_ItRejectsWorkloadsWithoutExpectedWorkers(t *testing.T) {
+	target := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a", "b"}})
+	call := &fakeCaller{}
+	p := newWorkerPool(version, &fixedAssigner{workloads: target, workers: []string{"swarm-worker-0", "swarm-worker-1"}}, call)
+
+	if err := p.Dump(context.Background(), "", "swarm", "swarm-worker-config"); err == nil {
+		t.Fatal("expected error dumping workloads without expected workers")
+	}
+	if expected, got := int32(0), atomic.LoadInt32(&call.assigns); expected != got {
+		t.Errorf("assigns do not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestPool_ItRejectsVersionRegressionFromPersistedWorkloads(t *testing.T) {
+	persisted := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a"}})
+	persisted.Version = 5
+	target := handoffWorkloads(map[string][]config.Job{"swarm-worker-0": {"a", "b"}})
+	call := &fakeCaller{}
+	p := newPersistedPool(3, &fixedAssigner{workloads: target}, call, 0, persisted, persisted, nil)
+
+	if err := p.Dump(context.Background(), "", "swarm", "swarm-worker-config"); err == nil {
+		t.Fatal("expected error dumping regressed version")
+	}
+	if expected, got := int32(0), atomic.LoadInt32(&call.assigns); expected != got {
+		t.Errorf("assigns do not match, expected %d got %d", expected, got)
+	}
+	if expected, got := int64(5), p.Persisted().Version; expected != got {
+		t.Errorf("persisted version does not match, expected %d got %d", expected, got)
+	}
+}
+
 type fakeAssigner struct {
 	balanceRequests int32
 	workloads       *config.Workloads
 }
 
-func (a *fakeAssigner) BalanceWorkload(totalWorkers int, version int64) (*config.Workloads, error) {
+func (a *fakeAssigner) BalanceWorkload(ctx context.Context, totalWorkers int, version int64) (*config.Workloads, error) {
 	atomic.AddInt32(&a.balanceRequests, 1)
 	return a.workloads, nil
 }
@@ -48,14 +102,37 @@ func (a *fakeAssigner) Workloads() *config.Workloads {
 	}
 }
 
+func (a *fakeAssigner) Workers() []string {
+	return nil
+}
+
+func (a *fakeAssigner) Unsatisfied() []balancer.Unsatisfied {
+	return nil
+}
+
+func (a *fakeAssigner) Unassigned() []config.Job {
+	return nil
+}
+
+func (a *fakeAssigner) UpdateUnhealthy(workers []string) bool {
+	return false
+}
+
+func (a *fakeAssigner) Unhealthy() map[string][]config.Job {
+	return nil
+}
+
 type fakeCaller struct {
 	assigns     int32
 	err         error
 	assignation *config.Workloads
+	persisted   *config.Workloads
+	versions    map[string]int64
+	restarted   []string
 	mutex       sync.RWMutex
 }
 
-func (f *fakeCaller) Assign(ctx context.Context, namespace, name string, w *config.Workloads) (err error) {
+func (f *fakeCaller) Assign(ctx context.Context, storage, namespace, name string, w *config.Workloads) (err error) {
 	atomic.AddInt32(&f.assigns, 1)
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
@@ -64,6 +141,18 @@ func (f *fakeCaller) Assign(ctx context.Context, namespace, name string, w *conf
 	return nil
 }
 
+func (f *fakeCaller) Load(ctx context.Context, storage, namespace, name string) (*config.Workloads, map[string]int64, error) {
+	if f.persisted == nil {
+		return nil, nil, errors.New("not found")
+	}
+
+	return f.persisted, f.versions, nil
+}
+
 func (f *fakeCaller) RestartWorker(ctx context.Context, namespace, name string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.restarted = append(f.restarted, name)
+
 	return nil
 }
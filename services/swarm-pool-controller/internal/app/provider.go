@@ -7,34 +7,34 @@ import (
 	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/generated/listers/swarm/v1alpha1"
 	log "github.com/sirupsen/logrus"
 	api "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	appv1 "k8s.io/client-go/listers/apps/v1"
 	v1 "k8s.io/client-go/listers/core/v1"
+	"time"
 )
 
 type provider struct {
 	swarmLister       v1alpha1.SwarmLister
 	statefulSetLister appv1.StatefulSetLister
+	deploymentLister  appv1.DeploymentLister
 	podLister         v1.PodLister
 }
 
-func NewProvider(swl v1alpha1.SwarmLister, stsl appv1.StatefulSetLister, pl v1.PodLister) *provider {
+func NewProvider(swl v1alpha1.SwarmLister, stsl appv1.StatefulSetLister, dl appv1.DeploymentLister, pl v1.PodLister) *provider {
 	return &provider{
 		swarmLister:       swl,
 		statefulSetLister: stsl,
+		deploymentLister:  dl,
 		podLister:         pl,
 	}
 }
 func (c *provider) PodNamesFromSelector(namespace string, ls *metav1.LabelSelector) ([]string, error) {
-	selector, err := metav1.LabelSelectorAsSelector(ls)
-	if err != nil {
-		return nil, fmt.Errorf("unable to get label selector, error %v", err)
-	}
-
-	pods, err := c.podLister.Pods(namespace).List(selector)
+	pods, err := c.pods(namespace, ls)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get pods from selector, error %v", err)
+		return nil, err
 	}
 
 	var names []string
@@ -94,3 +94,101 @@ func (c *provider) SwarmNameFromStatefulSetName(namespace, name string) (string,
 
 	return "", fmt.Errorf("unable to find swarm name from statefulset %s", name)
 }
+
+// SlotPool resolves deployment and selector swarm pools selector and size, selector pools size is its total
+// live pods
+func (c *provider) SlotPool(namespace string, spec swapi.SwarmSpec) (*metav1.LabelSelector, int, error) {
+	if spec.DeploymentName == "" {
+		names, err := c.PodNamesFromSelector(namespace, spec.Selector)
+		if err != nil {
+			return nil, 0, err
+		}
+		return spec.Selector, len(names), nil
+	}
+
+	d, err := c.deploymentLister.Deployments(namespace).Get(spec.DeploymentName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to get deployment on namespace %s name %s error %v", namespace, spec.DeploymentName, err)
+	}
+
+	size := 1
+	if d.Spec.Replicas != nil {
+		size = int(*d.Spec.Replicas)
+	}
+
+	return d.Spec.Selector, size, nil
+}
+
+// AppliedVersions returns workload versions acknowledged by selector pods, indexed by pod name
+func (c *provider) AppliedVersions(namespace string, ls *metav1.LabelSelector) (map[string]int64, error) {
+	pods, err := c.pods(namespace, ls)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]int64{}
+	for _, pd := range pods {
+		if v, ok := pod.AppliedVersion(pd); ok {
+			res[pd.Name] = v
+		}
+	}
+
+	return res, nil
+}
+
+// NotReadyPods returns selector pods not ready, indexed by pod name, with the time they are not ready since
+func (c *provider) NotReadyPods(namespace string, ls *metav1.LabelSelector) (map[string]time.Time, error) {
+	pods, err := c.pods(namespace, ls)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]time.Time{}
+	for _, pd := range pods {
+		if pod.IsReady(pd) || pod.HasDeletionTimestamp(pd) {
+			continue
+		}
+		since := pd.CreationTimestamp.Time
+		for _, cond := range pd.Status.Conditions {
+			if cond.Type == corev1.PodReady && !cond.LastTransitionTime.IsZero() {
+				since = cond.LastTransitionTime.Time
+			}
+		}
+		res[pd.Name] = since
+	}
+
+	return res, nil
+}
+
+// PodUIDs returns selector pods uid indexed by pod name, terminating pods keep running so they get included
+func (c *provider) PodUIDs(namespace string, ls *metav1.LabelSelector) (map[string]types.UID, error) {
+	pods, err := c.pods(namespace, ls)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]types.UID{}
+	for _, pd := range pods {
+		if pod.IsTerminated(pd) {
+			continue
+		}
+		res[pd.Name] = pd.UID
+	}
+
+	return res, nil
+}
+
+// pods lists selector pods from pod lister
+func (c *provider) pods(namespace string, ls *metav1.LabelSelector) ([]*corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(ls)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get label selector, error %v", err)
+	}
+
+	pods, err := c.podLister.Pods(namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get pods from selector, error %v", err)
+	}
+
+	return pods, nil
+}
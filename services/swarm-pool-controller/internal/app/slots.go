@@ -0,0 +1,196 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"sort"
+	"sync"
+)
+
+// slots maps deployment and selector pools logical slots to its current pod, slots are named as statefulset
+// pods so that balancing does not depend on pod names
+type slots struct {
+	index map[string]string
+	mutex sync.RWMutex
+}
+
+func newSlots() *slots {
+	return &slots{index: map[string]string{}}
+}
+
+// Update assigns pool size slots to live pods, slots keep its pod while alive and only slots whose pod
+// disappeared get a free one, previous mapping seeds empty ones. Returns true when mapping changes
+func (s *slots) Update(name string, pods []string, size int, previous map[string]string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current := s.index
+	if len(current) == 0 {
+		current = previous
+	}
+	res := assignSlots(current, name, pods, size)
+	if equalSlots(s.index, res) {
+		return false
+	}
+	s.index = res
+
+	return true
+}
+
+// Seed sets previous mapping on empty slots, controller restarts restore it from swarm status
+func (s *slots) Seed(previous map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.index) > 0 {
+		return
+	}
+	for slot, pd := range previous {
+		s.index[slot] = pd
+	}
+}
+
+// Pod returns slot pod
+func (s *slots) Pod(slot string) (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	pd, ok := s.index[slot]
+	return pd, ok
+}
+
+// Index returns slot to pod mapping copy
+func (s *slots) Index() map[string]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	res := make(map[string]string, len(s.index))
+	for slot, pd := range s.index {
+		res[slot] = pd
+	}
+
+	return res
+}
+
+// Unplaced returns jobs of slots without pod, sorted
+func (s *slots) Unplaced(w *config.Workloads) []config.Job {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var res []config.Job
+	for slot, wl := range w.Workloads {
+		if _, ok := s.index[slot]; ok || wl == nil {
+			continue
+		}
+		res = append(res, wl.Jobs...)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+
+	return res
+}
+
+func assignSlots(previous map[string]string, name string, pods []string, size int) map[string]string {
+	alive := map[string]struct{}{}
+	for _, pd := range pods {
+		alive[pd] = struct{}{}
+	}
+
+	res := map[string]string{}
+	taken := map[string]struct{}{}
+	for i := 0; i < size; i++ {
+		slot := fmt.Sprintf("%s-%d", name, i)
+		pd, ok := previous[slot]
+		if !ok {
+			continue
+		}
+		if _, ok := alive[pd]; !ok {
+			continue
+		}
+		if _, ok := taken[pd]; ok {
+			continue
+		}
+		res[slot] = pd
+		taken[pd] = struct{}{}
+	}
+
+	var free []string
+	for _, pd := range pods {
+		if _, ok := taken[pd]; !ok {
+			free = append(free, pd)
+		}
+	}
+	sort.Strings(free)
+
+	for i := 0; i < size && len(free) > 0; i++ {
+		slot := fmt.Sprintf("%s-%d", name, i)
+		if _, ok := res[slot]; ok {
+			continue
+		}
+		res[slot], free = free[0], free[1:]
+	}
+
+	return res
+}
+
+func equalSlots(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for slot, pd := range a {
+		if b[slot] != pd {
+			return false
+		}
+	}
+
+	return true
+}
+
+// slotDelegated persists slot workloads on its current pods, slots without pod do not get persisted and its
+// jobs get reported as unassigned
+type slotDelegated struct {
+	delegated delegated
+	slots     *slots
+}
+
+func (d *slotDelegated) Assign(ctx context.Context, storage, namespace, name string, w *config.Workloads) error {
+	res := &config.Workloads{Version: w.Version, Workloads: map[string]*config.Workload{}}
+	for slot, wl := range w.Workloads {
+		if pd, ok := d.slots.Pod(slot); ok {
+			res.Workloads[pd] = wl
+		}
+	}
+
+	return d.delegated.Assign(ctx, storage, namespace, name, res)
+}
+
+// Load returns persisted workloads and versions by slot, workloads of pods without slot get skipped
+func (d *slotDelegated) Load(ctx context.Context, storage, namespace, name string) (*config.Workloads, map[string]int64, error) {
+	w, versions, err := d.delegated.Load(ctx, storage, namespace, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bySlot := map[string]string{}
+	for slot, pd := range d.slots.Index() {
+		bySlot[pd] = slot
+	}
+	res := &config.Workloads{Version: w.Version, Workloads: map[string]*config.Workload{}}
+	resVersions := map[string]int64{}
+	for pd, wl := range w.Workloads {
+		if slot, ok := bySlot[pd]; ok {
+			res.Workloads[slot], resVersions[slot] = wl, versions[pd]
+		}
+	}
+
+	return res, resVersions, nil
+}
+
+func (d *slotDelegated) RestartWorker(ctx context.Context, namespace, name string) error {
+	pd, ok := d.slots.Pod(name)
+	if !ok {
+		return nil
+	}
+
+	return d.delegated.RestartWorker(ctx, namespace, pd)
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"reflect"
+	"testing"
+)
+
+func TestAssignSlots_ItOnlyReassignsSlotsWhosePodDisappeared(t *testing.T) {
+	previous := map[string]string{"foo-0": "worker-a", "foo-1": "worker-b", "foo-2": "worker-c"}
+
+	res := assignSlots(previous, "foo", []string{"worker-d", "worker-c", "worker-a"}, 3)
+
+	expected := map[string]string{"foo-0": "worker-a", "foo-1": "worker-d", "foo-2": "worker-c"}
+	if !reflect.DeepEqual(expected, res) {
+		t.Errorf("slots do not match, expected %v got %v", expected, res)
+	}
+
+	res = assignSlots(res, "foo", []string{"worker-d", "worker-c", "worker-a"}, 2)
+	expected = map[string]string{"foo-0": "worker-a", "foo-1": "worker-d"}
+	if !reflect.DeepEqual(expected, res) {
+		t.Errorf("slots do not match, expected %v got %v", expected, res)
+	}
+}
+
+func TestSlotDelegated_ItPersistsSlotWorkloadsOnItsPodsReportingUnplacedOnes(t *testing.T) {
+	sl := newSlots()
+	if !sl.Update("foo", []string{"worker-b", "worker-a"}, 3, nil) {
+		t.Fatal("expected slots update")
+	}
+	call := &fakeCaller{}
+	d := &slotDelegated{delegated: call, slots: sl}
+
+	w := handoffWorkloads(map[string][]config.Job{"foo-0": {"a"}, "foo-1": {"b"}, "foo-2": {"c"}})
+	if err := d.Assign(context.Background(), "", "swarm", "foo-config", w); err != nil {
+		t.Fatalf("unexpected error assigning workloads %v", err)
+	}
+
+	expected := handoffWorkloads(map[string][]config.Job{"worker-a": {"a"}, "worker-b": {"b"}})
+	if !expected.Equals(call.assignation) {
+		t.Errorf("assignation does not match, expected %v got %v", expected, call.assignation)
+	}
+	if expected, got := []config.Job{"c"}, sl.Unplaced(w); !reflect.DeepEqual(expected, got) {
+		t.Errorf("unplaced jobs do not match, expected %v got %v", expected, got)
+	}
+
+	if err := d.RestartWorker(context.Background(), "swarm", "foo-1"); err != nil {
+		t.Fatalf("unexpected error restarting worker %v", err)
+	}
+	if expected, got := []string{"worker-b"}, call.restarted; !reflect.DeepEqual(expected, got) {
+		t.Errorf("restarted workers do not match, expected %v got %v", expected, got)
+	}
+}
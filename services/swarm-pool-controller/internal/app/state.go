@@ -1,9 +1,11 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"github.com/marcosQuesada/k8s-lab/pkg/config"
-	log "github.com/sirupsen/logrus"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/balancer"
 	"sync"
 )
 
@@ -16,64 +18,174 @@ const (
 )
 
 type state struct {
-	setName string
-	jobs    []config.Job
-	config  *config.Workloads
-	mutex   sync.RWMutex
+	setName     string
+	jobs        []config.Job
+	weights     balancer.Weights
+	config      *config.Workloads
+	balancer    balancer.Balancer
+	unsatisfied []balancer.Unsatisfied
+	unassigned  []config.Job
+	unhealthy   map[string][]config.Job
+	workers     []string
+	mutex       sync.RWMutex
 }
 
-// newState holds workload assignations in the workers pool
+// newState holds workload assignations in the workers pool, workers are named as statefulset pods
 func newState(keySet []config.Job, setName string) *state {
 	return &state{
-		jobs:    keySet,
-		setName: setName,
-		config:  &config.Workloads{Workloads: map[string]*config.Workload{}},
+		jobs:     keySet,
+		setName:  setName,
+		config:   &config.Workloads{Workloads: map[string]*config.Workload{}},
+		balancer: balancer.NewRendezvous(balancer.DefaultLoadFactor),
 	}
 }
 
-// BalanceWorkload balances configured workload between workers
-func (s *state) BalanceWorkload(totalWorkers int, version int64) (*config.Workloads, error) {
+// newBalancedState holds workload assignations balanced by strategy balancer, previous assignations seed
+// rebalancing strategies
+func newBalancedState(keySet []config.Job, setName string, b balancer.Balancer, w balancer.Weights, previous *config.Workloads) *state {
+	s := newState(keySet, setName)
+	s.weights = w
+	s.balancer = b
+	if previous != nil {
+		s.config = previous
+	}
+
+	return s
+}
+
+// BalanceWorkload balances configured workload between workers, each job gets assigned to a single worker
+// and worker set changes move about 1/N of the jobs, sticky balancers start from previous assignations
+func (s *state) BalanceWorkload(ctx context.Context, totalWorkers int, version int64) (*config.Workloads, error) {
+	log := logger.FromContext(ctx)
 	log.Infof("State balance started, Recalculate assignations total workers: %d", totalWorkers)
 
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if totalWorkers == 0 {
-		s.cleanAssignations(0)
-		return s.config, nil
+	var workers, excluded []string
+	s.workers = make([]string, 0, totalWorkers)
+	for i := 0; i < totalWorkers; i++ {
+		w := fmt.Sprintf("%s-%d", s.setName, i)
+		s.workers = append(s.workers, w)
+		if _, ok := s.unhealthy[w]; ok {
+			excluded = append(excluded, w)
+			continue
+		}
+		workers = append(workers, w)
+	}
+	// jobs stay on unhealthy workers when there is no healthy one to take them
+	if len(workers) == 0 {
+		workers, excluded = excluded, nil
+	}
+
+	var assignations map[string][]config.Job
+	if r, ok := s.balancer.(balancer.Rebalancer); ok {
+		assignations = r.Rebalance(s.config, s.jobs, workers)
+	} else {
+		assignations = s.balancer.Balance(s.jobs, workers)
 	}
 
-	partSize := len(s.jobs) / totalWorkers
-	modulePartSize := len(s.jobs) % totalWorkers
-	for i := 0; i < totalWorkers; i++ {
-		workerName := fmt.Sprintf("%s-%d", s.setName, i)
-		if _, ok := s.config.Workloads[workerName]; !ok {
-			s.config.Workloads[workerName] = &config.Workload{}
+	s.unassigned = balancer.Unassigned(s.jobs, assignations)
+	if len(s.unassigned) > 0 {
+		log.Warnf("total unassigned jobs %d on %d workers", len(s.unassigned), totalWorkers)
+	}
+
+	s.unsatisfied = nil
+	if r, ok := s.balancer.(balancer.Reporter); ok {
+		s.unsatisfied = r.Unsatisfied(assignations)
+		for _, u := range s.unsatisfied {
+			log.Warnf("unsatisfied %s constraint jobs %v, %s", u.Type, u.Jobs, u.Reason)
+		}
+	}
+
+	wl := &config.Workloads{Workloads: map[string]*config.Workload{}, Version: version}
+	for workerName, jobs := range assignations {
+		wl.Workloads[workerName] = &config.Workload{Jobs: jobs, Weight: s.weights.Load(jobs)}
+		log.Infof("worker %s total jobs %d weight %d", workerName, len(jobs), s.weights.Load(jobs))
+	}
+	for _, w := range excluded {
+		wl.Workloads[w] = &config.Workload{Jobs: []config.Job{}}
+		log.Infof("unhealthy worker %s without jobs", w)
+	}
+	s.config = wl
+
+	return s.config, nil
+}
+
+// UpdateUnhealthy sets workers excluded from balancing, returns true when they change. Excluded workers keep its
+// jobs record, recovered workers get them back on previous assignations so that sticky balancers return them
+func (s *state) UpdateUnhealthy(workers []string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current := map[string]struct{}{}
+	for _, w := range workers {
+		current[w] = struct{}{}
+	}
+
+	changed := false
+	for w, jobs := range s.unhealthy {
+		if _, ok := current[w]; ok {
+			continue
 		}
+		s.restore(w, jobs)
+		delete(s.unhealthy, w)
+		changed = true
+	}
 
-		size := partSize
-		if i < modulePartSize {
-			size++
+	for _, w := range workers {
+		if _, ok := s.unhealthy[w]; ok {
+			continue
+		}
+		if s.unhealthy == nil {
+			s.unhealthy = map[string][]config.Job{}
 		}
-		start := i * size
-		end := (i + 1) * size
-		if end > len(s.jobs) {
-			end = len(s.jobs)
+		var jobs []config.Job
+		if wl, ok := s.config.Workloads[w]; ok && wl != nil {
+			jobs = append(jobs, wl.Jobs...)
 		}
+		s.unhealthy[w] = jobs
+		changed = true
+	}
 
-		s.config.Workloads[workerName] = &config.Workload{Jobs: s.jobs[start:end]}
+	return changed
+}
 
-		log.Infof("worker %s total jobs %d", workerName, len(s.jobs[start:end]))
+// Unhealthy returns excluded workers and the jobs they had when excluded
+func (s *state) Unhealthy() map[string][]config.Job {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	res := make(map[string][]config.Job, len(s.unhealthy))
+	for w, jobs := range s.unhealthy {
+		res[w] = jobs
 	}
 
-	s.config.Version = version
+	return res
+}
 
-	// on Downscaling
-	if totalWorkers < len(s.config.Workloads) {
-		s.cleanAssignations(totalWorkers)
+// restore moves recovered worker jobs back on current assignations
+func (s *state) restore(worker string, jobs []config.Job) {
+	owned := map[config.Job]struct{}{}
+	for _, j := range jobs {
+		owned[j] = struct{}{}
 	}
 
-	return s.config, nil
+	wl := &config.Workloads{Version: s.config.Version, Workloads: map[string]*config.Workload{}}
+	for w, c := range s.config.Workloads {
+		r := &config.Workload{Jobs: []config.Job{}}
+		if c != nil {
+			for _, j := range c.Jobs {
+				if _, ok := owned[j]; !ok {
+					r.Jobs = append(r.Jobs, j)
+				}
+			}
+		}
+		r.Weight = s.weights.Load(r.Jobs)
+		wl.Workloads[w] = r
+	}
+	wl.Workloads[worker] = &config.Workload{Jobs: jobs, Weight: s.weights.Load(jobs)}
+	s.config = wl
 }
 
 // Workloads returns last computed workloads assignations
@@ -84,6 +196,30 @@ func (s *state) Workloads() *config.Workloads {
 	return s.config
 }
 
+// Workers returns worker names of last computed assignations, unhealthy ones included
+func (s *state) Workers() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.workers
+}
+
+// Unsatisfied returns constraints not honoured by last computed assignations
+func (s *state) Unsatisfied() []balancer.Unsatisfied {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.unsatisfied
+}
+
+// Unassigned returns jobs without worker on last computed assignations
+func (s *state) Unassigned() []config.Job {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.unassigned
+}
+
 // Workload returns concrete workload
 func (s *state) Workload(workerIdx int) (*config.Workload, error) {
 	s.mutex.RLock()
@@ -97,13 +233,6 @@ func (s *state) Workload(workerIdx int) (*config.Workload, error) {
 	return asg, nil
 }
 
-func (s *state) cleanAssignations(totalWorkers int) {
-	orgSize := len(s.config.Workloads)
-	for i := totalWorkers; i < orgSize; i++ {
-		delete(s.config.Workloads, fmt.Sprintf("%s-%d", s.setName, i))
-	}
-}
-
 func (s *state) size() int {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -1,7 +1,11 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	config2 "github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/balancer"
+	"sort"
 	"testing"
 )
 
@@ -59,7 +63,7 @@ func TestDiffCalculationOnStaticSet(t *testing.T) {
 	app := newState(set, fakeWorkerName)
 	totalWorkers := 3
 	var version int64 = 1
-	if _, err := app.BalanceWorkload(totalWorkers, version); err != nil {
+	if _, err := app.BalanceWorkload(context.Background(), totalWorkers, version); err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
 
@@ -90,26 +94,12 @@ func TestDiffCalculationOnNounStaticSet(t *testing.T) {
 	app := newState(set, fakeWorkerName)
 	totalWorkers := 2
 	var version int64 = 1
-	if _, err := app.BalanceWorkload(totalWorkers, version); err != nil {
+	if _, err := app.BalanceWorkload(context.Background(), totalWorkers, version); err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
 
-	asg, err := app.Workload(0)
-	if err != nil {
-		t.Fatalf("unable to complete balance %v", err)
-	}
-
-	if expected, got := 5, len(asg.Jobs); expected != got {
-		t.Fatalf("unexpected total entries on subset 0, expected %d got %d", expected, got)
-	}
-
-	asg, err = app.Workload(1)
-	if err != nil {
-		t.Fatalf("unable to complete balance %v", err)
-	}
-
-	if expected, got := 4, len(asg.Jobs); expected != got {
-		t.Fatalf("unexpected total entries on subset 0, expected %d got %d", expected, got)
+	if expected, got := []int{4, 5}, workerLoads(t, app, totalWorkers); fmt.Sprint(expected) != fmt.Sprint(got) {
+		t.Fatalf("unexpected worker loads, expected %v got %v", expected, got)
 	}
 }
 
@@ -117,7 +107,7 @@ func TestShardingByTotalWorkersOnASingleNodeComposition(t *testing.T) {
 	app := newState(jobs, fakeWorkerName)
 	totalWorkers := 1
 	var version int64 = 1
-	if _, err := app.BalanceWorkload(totalWorkers, version); err != nil {
+	if _, err := app.BalanceWorkload(context.Background(), totalWorkers, version); err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
 
@@ -135,7 +125,7 @@ func TestOnScalingUpWorkersOnceAssigned(t *testing.T) {
 	app := newState(jobs, fakeWorkerName)
 	totalWorkers := 2
 	var version int64 = 1
-	if _, err := app.BalanceWorkload(totalWorkers, version); err != nil {
+	if _, err := app.BalanceWorkload(context.Background(), totalWorkers, version); err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
 	a0, err := app.Workload(0)
@@ -158,7 +148,7 @@ func TestOnScalingUpWorkersOnceAssigned(t *testing.T) {
 
 	totalWorkers = 3
 	version = 2
-	if _, err := app.BalanceWorkload(totalWorkers, version); err != nil {
+	if _, err := app.BalanceWorkload(context.Background(), totalWorkers, version); err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
 
@@ -183,7 +173,7 @@ func TestOnScalingDownWorkersOnceAssigned(t *testing.T) {
 	totalWorkers := 3
 	var version int64 = 1
 
-	wl, err := app.BalanceWorkload(totalWorkers, version)
+	wl, err := app.BalanceWorkload(context.Background(), totalWorkers, version)
 	if err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
@@ -194,7 +184,7 @@ func TestOnScalingDownWorkersOnceAssigned(t *testing.T) {
 
 	totalWorkers = 2
 	version = 2
-	_, err = app.BalanceWorkload(totalWorkers, version)
+	_, err = app.BalanceWorkload(context.Background(), totalWorkers, version)
 	if err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
@@ -227,13 +217,13 @@ func TestOnScalingToZeroWorkersOnceAssigned(t *testing.T) {
 	app := newState(jobs, fakeWorkerName)
 	totalWorkers := 3
 	var version int64 = 1
-	if _, err := app.BalanceWorkload(totalWorkers, version); err != nil {
+	if _, err := app.BalanceWorkload(context.Background(), totalWorkers, version); err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
 
 	totalWorkers = 0
 	version = 2
-	if _, err := app.BalanceWorkload(totalWorkers, version); err != nil {
+	if _, err := app.BalanceWorkload(context.Background(), totalWorkers, version); err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
 
@@ -276,29 +266,98 @@ func TestScalingUpOnNounWorkloadSizeAssignsExpectedJobs(t *testing.T) {
 	app := newState(realScenarioBug, fakeWorkerName)
 	totalWorkers := 2
 	var version int64 = 1
-	if _, err := app.BalanceWorkload(totalWorkers, version); err != nil {
+	if _, err := app.BalanceWorkload(context.Background(), totalWorkers, version); err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
 
 	totalWorkers = 12
 	version = 2
-	if _, err := app.BalanceWorkload(totalWorkers, version); err != nil {
+	if _, err := app.BalanceWorkload(context.Background(), totalWorkers, version); err != nil {
 		t.Fatalf("unable to balance keys %v", err)
 	}
-	a0, err := app.Workload(0)
+	loads := workerLoads(t, app, totalWorkers)
+	if expected, got := 3, loads[len(loads)-1]; got != expected {
+		t.Errorf("max load does not match, expected %d got %d", expected, got)
+	}
+	if expected, got := 2, loads[0]; got != expected {
+		t.Errorf("min load does not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestBalanceAssignsEachJobOnceAndKeepsAssignationsOnScaling(t *testing.T) {
+	app := newState(jobs, "swarm-worker")
+	if _, err := app.BalanceWorkload(context.Background(), 3, 1); err != nil {
+		t.Fatalf("unable to balance keys %v", err)
+	}
+	before := jobOwners(t, app.Workloads())
+
+	wl, err := app.BalanceWorkload(context.Background(), 4, 2)
 	if err != nil {
-		t.Fatalf("unexpected error %v", err)
+		t.Fatalf("unable to balance keys %v", err)
 	}
+	after := jobOwners(t, wl)
 
-	if expected, got := 3, len(a0.Jobs); got != expected {
-		t.Errorf("Includes do not match, expected %d got %d", expected, got)
+	if expected, got := len(jobs), len(after); expected != got {
+		t.Fatalf("total assigned jobs do not match, expected %d got %d", expected, got)
+	}
+	if _, ok := wl.Workloads["swarm-worker-3"]; !ok {
+		t.Fatal("expected worker named after statefulset pod")
 	}
-	a3, err := app.Workload(3)
+
+	moved := 0
+	for j, w := range before {
+		if after[j] != w {
+			moved++
+		}
+	}
+	if max := len(jobs) / 2; moved > max {
+		t.Errorf("too many moved jobs scaling up, expected at most %d got %d", max, moved)
+	}
+}
+
+func workerLoads(t *testing.T, app *state, totalWorkers int) []int {
+	t.Helper()
+	res := []int{}
+	for i := 0; i < totalWorkers; i++ {
+		asg, err := app.Workload(i)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		res = append(res, len(asg.Jobs))
+	}
+	sort.Ints(res)
+
+	return res
+}
+
+func jobOwners(t *testing.T, wl *config2.Workloads) map[config2.Job]string {
+	t.Helper()
+	res := map[config2.Job]string{}
+	for w, asg := range wl.Workloads {
+		for _, j := range asg.Jobs {
+			if prev, ok := res[j]; ok {
+				t.Fatalf("job %s assigned twice, workers %s and %s", j, prev, w)
+			}
+			res[j] = w
+		}
+	}
+
+	return res
+}
+
+func TestWeightedBalanceReportsWorkerWeights(t *testing.T) {
+	set := []config2.Job{"stream:hd1", "stream:hd2", "audio:1", "audio:2", "audio:3", "audio:4"}
+	weights := balancer.Weights{"stream:hd1": 10, "stream:hd2": 10}
+	app := newBalancedState(set, "swarm-worker", balancer.NewWeighted(weights), weights, nil)
+
+	wl, err := app.BalanceWorkload(context.Background(), 2, 1)
 	if err != nil {
-		t.Fatalf("unexpected error %v", err)
+		t.Fatalf("unable to balance keys %v", err)
 	}
 
-	if expected, got := 2, len(a3.Jobs); got != expected {
-		t.Errorf("Includes do not match, expected %d got %d", expected, got)
+	for worker, w := range wl.Workloads {
+		if expected, got := int64(12), w.Weight; expected != got {
+			t.Errorf("worker %s weight does not match, expected %d got %d", worker, expected, got)
+		}
 	}
 }
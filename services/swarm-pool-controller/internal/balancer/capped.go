@@ -0,0 +1,191 @@
+package balancer
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"sort"
+)
+
+// Limits bounds worker total jobs and weight, zero values disable them
+type Limits struct {
+	MaxJobs   int
+	MaxWeight int64
+}
+
+// Empty checks if there is any limit to apply
+func (l Limits) Empty() bool {
+	return l.MaxJobs <= 0 && l.MaxWeight <= 0
+}
+
+func (l Limits) fits(jobs int, weight int64) bool {
+	return (l.MaxJobs <= 0 || jobs <= l.MaxJobs) && (l.MaxWeight <= 0 || weight <= l.MaxWeight)
+}
+
+// capped bounds base balancer assignations, overflowed affinity units move to workers with free capacity
+// and stay unassigned when none fits them
+type capped struct {
+	base        Balancer
+	limits      Limits
+	weights     Weights
+	constraints *Constraints
+}
+
+// NewCapped instantiates capacity limited balancer, constraints keep affinity units together and anti
+// affine units apart on relocations, nil constraints handle jobs independently
+func NewCapped(b Balancer, l Limits, w Weights, c *Constraints) Rebalancer {
+	return &capped{base: b, limits: l, weights: w, constraints: c}
+}
+
+// Balance assigns jobs without previous assignations
+func (b *capped) Balance(jobs []config.Job, workers []string) map[string][]config.Job {
+	return b.Rebalance(nil, jobs, workers)
+}
+
+// Rebalance assigns jobs within worker limits, jobs placed on previous assignations get removed last
+func (b *capped) Rebalance(previous *config.Workloads, jobs []config.Job, workers []string) map[string][]config.Job {
+	var res map[string][]config.Job
+	if r, ok := b.base.(Rebalancer); ok {
+		res = r.Rebalance(previous, jobs, workers)
+	} else {
+		res = b.base.Balance(jobs, workers)
+	}
+
+	sorted := make([]string, len(workers))
+	copy(sorted, workers)
+	sort.Strings(sorted)
+
+	members := map[config.Job][]config.Job{}
+	owners := map[config.Job]string{}
+	units := map[string][]config.Job{}
+	for _, w := range sorted {
+		for _, j := range res[w] {
+			u := b.constraints.Unit(j)
+			if _, ok := members[u]; !ok {
+				units[w] = append(units[w], u)
+				owners[u] = w
+			}
+			members[u] = append(members[u], j)
+		}
+	}
+
+	placed := map[config.Job]string{}
+	if previous != nil {
+		for w, wl := range previous.Workloads {
+			if wl == nil {
+				continue
+			}
+			for _, j := range wl.Jobs {
+				placed[b.constraints.Unit(j)] = w
+			}
+		}
+	}
+
+	size := func(u config.Job) (int, int64) {
+		return len(members[u]), b.weights.Load(members[u])
+	}
+	load := func(w string) (int, int64) {
+		var jobs int
+		var weight int64
+		for _, u := range units[w] {
+			j, wg := size(u)
+			jobs, weight = jobs+j, weight+wg
+		}
+		return jobs, weight
+	}
+
+	// overflowed units, new placements get removed first
+	overflow := []config.Job{}
+	for _, w := range sorted {
+		us := units[w]
+		sort.SliceStable(us, func(i, j int) bool {
+			pi, pj := placed[us[i]] == w, placed[us[j]] == w
+			if pi != pj {
+				return pi
+			}
+			return us[i] < us[j]
+		})
+		for {
+			jobs, weight := load(w)
+			if len(us) == 0 || b.limits.fits(jobs, weight) {
+				break
+			}
+			u := us[len(us)-1]
+			us = us[:len(us)-1]
+			units[w] = us
+			delete(owners, u)
+			overflow = append(overflow, u)
+		}
+	}
+
+	sort.Slice(overflow, func(i, j int) bool {
+		_, wi := size(overflow[i])
+		_, wj := size(overflow[j])
+		if wi == wj {
+			return overflow[i] < overflow[j]
+		}
+		return wi > wj
+	})
+	for _, u := range overflow {
+		target := ""
+		var targetWeight int64
+		for _, w := range sorted {
+			jobs, weight := load(w)
+			uj, uw := size(u)
+			if !b.limits.fits(jobs+uj, weight+uw) || !b.constraints.Allowed(u, w, owners) {
+				continue
+			}
+			if target == "" || weight < targetWeight {
+				target, targetWeight = w, weight
+			}
+		}
+		if target == "" {
+			continue
+		}
+		units[target] = append(units[target], u)
+		owners[u] = target
+	}
+
+	for _, w := range sorted {
+		res[w] = []config.Job{}
+		for _, u := range units[w] {
+			res[w] = append(res[w], members[u]...)
+		}
+		sort.Slice(res[w], func(i, j int) bool { return res[w][i] < res[w][j] })
+	}
+
+	return res
+}
+
+// Unsatisfied reports base balancer unsatisfied constraints
+func (b *capped) Unsatisfied(assignations map[string][]config.Job) []Unsatisfied {
+	if r, ok := b.base.(Reporter); ok {
+		return r.Unsatisfied(assignations)
+	}
+
+	return nil
+}
+
+// Unassigned returns jobs without worker, sorted
+func Unassigned(jobs []config.Job, assignations map[string][]config.Job) []config.Job {
+	assigned := map[config.Job]struct{}{}
+	for _, js := range assignations {
+		for _, j := range js {
+			assigned[j] = struct{}{}
+		}
+	}
+
+	res := []config.Job{}
+	seen := map[config.Job]struct{}{}
+	for _, j := range jobs {
+		if _, ok := assigned[j]; ok {
+			continue
+		}
+		if _, ok := seen[j]; ok {
+			continue
+		}
+		seen[j] = struct{}{}
+		res = append(res, j)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+
+	return res
+}
@@ -0,0 +1,64 @@
+package balancer
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"reflect"
+	"testing"
+)
+
+func TestCapped_ItLeavesOverflowedJobsUnassigned(t *testing.T) {
+	jobs := fakeJobs(10)
+	b := NewCapped(NewRendezvous(DefaultLoadFactor), Limits{MaxJobs: 3}, nil, nil)
+	res := b.Balance(jobs, fakeWorkers(3))
+
+	for w, j := range res {
+		if expected, got := 3, len(j); expected != got {
+			t.Errorf("worker %s total jobs do not match, expected %d got %d", w, expected, got)
+		}
+	}
+	if expected, got := 1, len(Unassigned(jobs, res)); expected != got {
+		t.Errorf("total unassigned jobs do not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestCapped_ItRelocatesOverflowedJobsWithinMaxWeight(t *testing.T) {
+	w := Weights{"hd1": 4, "hd2": 4, "hd3": 4}
+	previous := workloads(map[string][]config.Job{
+		"swarm-worker-0": {"hd1", "hd2", "hd3"},
+		"swarm-worker-1": {"a"},
+	})
+	jobs := []config.Job{"hd1", "hd2", "hd3", "a", "b"}
+	sticky := NewSticky(NewWeighted(w), w, 100)
+
+	res := NewCapped(sticky, Limits{MaxWeight: 8}, w, nil).Rebalance(previous, jobs, fakeWorkers(2))
+
+	assertAssignedOnce(t, jobs, res)
+	if expected, got := []config.Job{"hd1", "hd2"}, res["swarm-worker-0"]; !reflect.DeepEqual(expected, got) {
+		t.Errorf("worker jobs do not match, expected %v got %v", expected, got)
+	}
+}
+
+func TestCapped_ItKeepsPreviousPlacementsAndAffinityUnits(t *testing.T) {
+	jobs := []config.Job{"a", "b", "c", "d", "e"}
+	c := NewConstraints(jobs, [][]config.Job{{"d", "e"}}, nil)
+	previous := workloads(map[string][]config.Job{"swarm-worker-0": {"a", "b"}})
+	b, _ := NewRegistry(100).BuildConstrained(Sticky, nil, nil, c)
+
+	res := NewCapped(b, Limits{MaxJobs: 3}, nil, c).Rebalance(previous, jobs, fakeWorkers(1))
+
+	if expected, got := []config.Job{"a", "b", "c"}, res["swarm-worker-0"]; !reflect.DeepEqual(expected, got) {
+		t.Errorf("worker jobs do not match, expected %v got %v", expected, got)
+	}
+	if expected, got := []config.Job{"d", "e"}, Unassigned(jobs, res); !reflect.DeepEqual(expected, got) {
+		t.Errorf("unassigned jobs do not match, expected %v got %v", expected, got)
+	}
+}
+
+func TestUnassigned_ItReportsAllJobsWithoutWorkers(t *testing.T) {
+	jobs := []config.Job{"b", "a", "b"}
+	res := NewSticky(NewRendezvous(DefaultLoadFactor), nil, DefaultTolerance).Balance(jobs, nil)
+
+	if expected, got := []config.Job{"a", "b"}, Unassigned(jobs, res); !reflect.DeepEqual(expected, got) {
+		t.Errorf("unassigned jobs do not match, expected %v got %v", expected, got)
+	}
+}
@@ -0,0 +1,390 @@
+package balancer
+
+import (
+	"fmt"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"sort"
+)
+
+const (
+	// AffinityConstraint groups jobs that must share worker
+	AffinityConstraint = "affinity"
+	// AntiAffinityConstraint groups jobs that must never share worker
+	AntiAffinityConstraint = "anti-affinity"
+)
+
+// Unsatisfied describes a constraint that balanced assignations do not honour
+type Unsatisfied struct {
+	Type   string       `json:"type"`
+	Jobs   []config.Job `json:"jobs"`
+	Reason string       `json:"reason"`
+}
+
+// Reporter reports unsatisfied constraints on balanced assignations
+type Reporter interface {
+	Unsatisfied(assignations map[string][]config.Job) []Unsatisfied
+}
+
+// Constraints indexes workload affinity and anti-affinity groups, overlapping affinity groups get merged
+// in a single unit represented by its lowest job, jobs out of workload get ignored
+type Constraints struct {
+	units     map[config.Job]config.Job
+	members   map[config.Job][]config.Job
+	anti      [][]config.Job
+	separated map[config.Job]map[config.Job]struct{}
+	conflicts map[int]Unsatisfied
+}
+
+// NewConstraints builds workload constraints, anti-affinity groups including co-located jobs can not be satisfied
+func NewConstraints(jobs []config.Job, affinity, antiAffinity [][]config.Job) *Constraints {
+	present := make(map[config.Job]struct{}, len(jobs))
+	for _, j := range jobs {
+		present[j] = struct{}{}
+	}
+
+	c := &Constraints{
+		units:     map[config.Job]config.Job{},
+		members:   map[config.Job][]config.Job{},
+		separated: map[config.Job]map[config.Job]struct{}{},
+		conflicts: map[int]Unsatisfied{},
+	}
+	for _, g := range affinity {
+		g = filter(g, present)
+		if len(g) < 2 {
+			continue
+		}
+		for _, j := range g[1:] {
+			c.union(g[0], j)
+		}
+	}
+	for j := range c.units {
+		rep := c.find(j)
+		c.members[rep] = append(c.members[rep], j)
+	}
+	for rep := range c.members {
+		sort.Slice(c.members[rep], func(i, j int) bool { return c.members[rep][i] < c.members[rep][j] })
+	}
+
+	for _, g := range antiAffinity {
+		g = filter(g, present)
+		if len(g) < 2 {
+			continue
+		}
+		c.anti = append(c.anti, g)
+		idx := len(c.anti) - 1
+
+		seen := map[config.Job]config.Job{}
+		for _, j := range g {
+			rep := c.Unit(j)
+			if prev, ok := seen[rep]; ok {
+				c.conflicts[idx] = Unsatisfied{
+					Type:   AntiAffinityConstraint,
+					Jobs:   g,
+					Reason: fmt.Sprintf("jobs %s and %s must be co-located", prev, j),
+				}
+				break
+			}
+			seen[rep] = j
+		}
+
+		for _, a := range g {
+			for _, b := range g {
+				ua, ub := c.Unit(a), c.Unit(b)
+				if ua == ub {
+					continue
+				}
+				if _, ok := c.separated[ua]; !ok {
+					c.separated[ua] = map[config.Job]struct{}{}
+				}
+				c.separated[ua][ub] = struct{}{}
+			}
+		}
+	}
+
+	return c
+}
+
+// Empty checks if there is any constraint to honour
+func (c *Constraints) Empty() bool {
+	return c == nil || (len(c.members) == 0 && len(c.anti) == 0)
+}
+
+// Unit returns job unit representative, unconstrained jobs represent themselves
+func (c *Constraints) Unit(j config.Job) config.Job {
+	if c == nil {
+		return j
+	}
+	if _, ok := c.units[j]; !ok {
+		return j
+	}
+
+	return c.find(j)
+}
+
+// Allowed checks if unit can be placed on worker without sharing it with anti affine units, owners indexes units worker
+func (c *Constraints) Allowed(u config.Job, worker string, owners map[config.Job]string) bool {
+	if c == nil {
+		return true
+	}
+	for o := range c.separated[u] {
+		if owners[o] == worker {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Weights returns unit weights, units weigh as all their members
+func (c *Constraints) Weights(w Weights) Weights {
+	if len(c.members) == 0 {
+		return w
+	}
+
+	res := Weights{}
+	for j, v := range w {
+		if _, ok := c.units[j]; !ok {
+			res[j] = v
+		}
+	}
+	for rep, members := range c.members {
+		res[rep] = w.Load(members)
+	}
+
+	return res
+}
+
+func (c *Constraints) union(a, b config.Job) {
+	ra, rb := c.Unit(a), c.Unit(b)
+	c.units[a], c.units[b] = ra, rb
+	if ra == rb {
+		return
+	}
+	if rb < ra {
+		ra, rb = rb, ra
+	}
+	c.units[rb] = ra
+}
+
+func (c *Constraints) find(j config.Job) config.Job {
+	for c.units[j] != j {
+		c.units[j] = c.units[c.units[j]]
+		j = c.units[j]
+	}
+
+	return j
+}
+
+// constrained balances affinity units through its base balancer, then moves units sharing worker with
+// anti-affine units to the least loaded worker without them
+type constrained struct {
+	base        Balancer
+	constraints *Constraints
+	weights     Weights
+}
+
+// NewConstrained instantiates constraints balancer, base balancer gets unit representatives weighted by
+// constraints unit weights
+func NewConstrained(b Balancer, c *Constraints, w Weights) Rebalancer {
+	return &constrained{base: b, constraints: c, weights: c.Weights(w)}
+}
+
+// Balance assigns jobs without previous assignations
+func (b *constrained) Balance(jobs []config.Job, workers []string) map[string][]config.Job {
+	return b.Rebalance(nil, jobs, workers)
+}
+
+// Rebalance assigns jobs honouring constraints, previous assignations apply on rebalancing base balancers
+func (b *constrained) Rebalance(previous *config.Workloads, jobs []config.Job, workers []string) map[string][]config.Job {
+	units := b.units(jobs)
+
+	var res map[string][]config.Job
+	if r, ok := b.base.(Rebalancer); ok {
+		res = r.Rebalance(b.previousUnits(previous), units, workers)
+	} else {
+		res = b.base.Balance(units, workers)
+	}
+
+	b.separate(res, workers)
+
+	present := make(map[config.Job]struct{}, len(jobs))
+	for _, j := range jobs {
+		present[j] = struct{}{}
+	}
+	for w, us := range res {
+		expanded := []config.Job{}
+		for _, u := range us {
+			members, ok := b.constraints.members[u]
+			if !ok {
+				expanded = append(expanded, u)
+				continue
+			}
+			expanded = append(expanded, filter(members, present)...)
+		}
+		sort.Slice(expanded, func(i, j int) bool { return expanded[i] < expanded[j] })
+		res[w] = expanded
+	}
+
+	return res
+}
+
+// Unsatisfied reports constraints violated by assignations
+func (b *constrained) Unsatisfied(assignations map[string][]config.Job) []Unsatisfied {
+	owners := map[config.Job]string{}
+	for w, jobs := range assignations {
+		for _, j := range jobs {
+			owners[j] = w
+		}
+	}
+
+	res := []Unsatisfied{}
+	for i, g := range b.constraints.anti {
+		if u, ok := b.constraints.conflicts[i]; ok {
+			res = append(res, u)
+			continue
+		}
+
+		used := map[string]config.Job{}
+		for _, j := range g {
+			w, ok := owners[j]
+			if !ok {
+				continue
+			}
+			if prev, ok := used[w]; ok {
+				reason := fmt.Sprintf("jobs %s and %s share worker %s, no worker available", prev, j, w)
+				if len(g) > len(assignations) {
+					reason = fmt.Sprintf("%d jobs can not be separated on %d workers", len(g), len(assignations))
+				}
+				res = append(res, Unsatisfied{Type: AntiAffinityConstraint, Jobs: g, Reason: reason})
+				break
+			}
+			used[w] = j
+		}
+	}
+
+	return res
+}
+
+func (b *constrained) units(jobs []config.Job) []config.Job {
+	seen := map[config.Job]struct{}{}
+	res := make([]config.Job, 0, len(jobs))
+	for _, j := range jobs {
+		u := b.constraints.Unit(j)
+		if _, ok := seen[u]; ok {
+			continue
+		}
+		seen[u] = struct{}{}
+		res = append(res, u)
+	}
+
+	return res
+}
+
+func (b *constrained) previousUnits(previous *config.Workloads) *config.Workloads {
+	if previous == nil {
+		return nil
+	}
+
+	res := &config.Workloads{Version: previous.Version, Workloads: make(map[string]*config.Workload, len(previous.Workloads))}
+	for w, wl := range previous.Workloads {
+		if wl == nil {
+			continue
+		}
+		res.Workloads[w] = &config.Workload{Jobs: b.units(wl.Jobs)}
+	}
+
+	return res
+}
+
+// separate moves anti-affine units sharing worker, a unit moves only to workers without any unit it must be separated from
+func (b *constrained) separate(res map[string][]config.Job, workers []string) {
+	if len(b.constraints.anti) == 0 || len(workers) == 0 {
+		return
+	}
+
+	owners := map[config.Job]string{}
+	loads := make(map[string]int64, len(workers))
+	for w, us := range res {
+		for _, u := range us {
+			owners[u] = w
+			loads[w] += b.weights.Of(u)
+		}
+	}
+
+	sorted := make([]string, len(workers))
+	copy(sorted, workers)
+	sort.Strings(sorted)
+
+	us := make([]config.Job, 0, len(b.constraints.separated))
+	for u := range b.constraints.separated {
+		us = append(us, u)
+	}
+	sort.Slice(us, func(i, j int) bool { return us[i] < us[j] })
+	for _, u := range us {
+		from, ok := owners[u]
+		if !ok || b.constraints.Allowed(u, from, owners) {
+			continue
+		}
+
+		target := ""
+		for _, w := range sorted {
+			if w == from || !b.constraints.Allowed(u, w, owners) {
+				continue
+			}
+			if target == "" || loads[w] < loads[target] {
+				target = w
+			}
+		}
+		if target == "" {
+			continue
+		}
+
+		res[from] = remove(res[from], u)
+		res[target] = append(res[target], u)
+		owners[u] = target
+		loads[from] -= b.weights.Of(u)
+		loads[target] += b.weights.Of(u)
+	}
+}
+
+// BuildConstrained instantiates named strategy balancer honouring constraints
+func (r Registry) BuildConstrained(name string, p Params, w Weights, c *Constraints) (Balancer, error) {
+	if c.Empty() {
+		return r.Build(name, p, w)
+	}
+
+	b, err := r.Build(name, p, c.Weights(w))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConstrained(b, c, w), nil
+}
+
+func filter(jobs []config.Job, present map[config.Job]struct{}) []config.Job {
+	seen := map[config.Job]struct{}{}
+	res := []config.Job{}
+	for _, j := range jobs {
+		if _, ok := present[j]; !ok {
+			continue
+		}
+		if _, ok := seen[j]; ok {
+			continue
+		}
+		seen[j] = struct{}{}
+		res = append(res, j)
+	}
+
+	return res
+}
+
+func remove(jobs []config.Job, j config.Job) []config.Job {
+	res := make([]config.Job, 0, len(jobs))
+	for _, v := range jobs {
+		if v != j {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,89 @@
+package balancer
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"testing"
+)
+
+func TestConstrained_ItColocatesAffinityGroups(t *testing.T) {
+	jobs := fakeJobs(30)
+	affinity := [][]config.Job{{"stream:1", "stream:2"}, {"stream:2", "stream:3"}, {"stream:10", "stream:20", "foo"}}
+	c := NewConstraints(jobs, affinity, nil)
+	r := NewRegistry(DefaultTolerance)
+	for _, name := range r.Names() {
+		b, err := r.BuildConstrained(name, nil, nil, c)
+		if err != nil {
+			t.Fatalf("unexpected error building strategy %s %v", name, err)
+		}
+		res := b.Balance(jobs, fakeWorkers(4))
+
+		assertAssignedOnce(t, jobs, res)
+		o := owners(res)
+		if o["stream:1"] != o["stream:2"] || o["stream:2"] != o["stream:3"] {
+			t.Errorf("strategy %s expected merged affinity groups co-located, got %v", name, o)
+		}
+		if o["stream:10"] != o["stream:20"] {
+			t.Errorf("strategy %s expected affinity group co-located, got %v", name, o)
+		}
+		if u := b.(Reporter).Unsatisfied(res); len(u) != 0 {
+			t.Errorf("strategy %s unexpected unsatisfied constraints %v", name, u)
+		}
+	}
+}
+
+func TestConstrained_ItSeparatesAntiAffinityGroups(t *testing.T) {
+	jobs := fakeJobs(30)
+	anti := [][]config.Job{{"stream:1", "stream:2", "stream:3"}, {"stream:3", "stream:4"}}
+	c := NewConstraints(jobs, [][]config.Job{{"stream:4", "stream:5"}}, anti)
+	r := NewRegistry(DefaultTolerance)
+	for _, name := range r.Names() {
+		b, _ := r.BuildConstrained(name, nil, nil, c)
+		res := b.Balance(jobs, fakeWorkers(3))
+
+		assertAssignedOnce(t, jobs, res)
+		o := owners(res)
+		if o["stream:1"] == o["stream:2"] || o["stream:1"] == o["stream:3"] || o["stream:2"] == o["stream:3"] {
+			t.Errorf("strategy %s expected anti affinity group separated, got %v", name, o)
+		}
+		if o["stream:3"] == o["stream:4"] || o["stream:4"] != o["stream:5"] {
+			t.Errorf("strategy %s expected anti affinity with affinity unit honoured, got %v", name, o)
+		}
+		if u := b.(Reporter).Unsatisfied(res); len(u) != 0 {
+			t.Errorf("strategy %s unexpected unsatisfied constraints %v", name, u)
+		}
+	}
+}
+
+func TestConstrained_ItReportsUnsatisfiableConstraints(t *testing.T) {
+	jobs := fakeJobs(10)
+	anti := [][]config.Job{{"stream:1", "stream:2", "stream:3"}, {"stream:4", "stream:5"}}
+	c := NewConstraints(jobs, [][]config.Job{{"stream:4", "stream:5"}}, anti)
+	b, _ := NewRegistry(DefaultTolerance).BuildConstrained(Sticky, nil, nil, c)
+	res := b.Balance(jobs, fakeWorkers(2))
+
+	assertAssignedOnce(t, jobs, res)
+	u := b.(Reporter).Unsatisfied(res)
+	if expected, got := 2, len(u); expected != got {
+		t.Fatalf("total unsatisfied constraints do not match, expected %d got %d %v", expected, got, u)
+	}
+	for i, reason := range []string{"3 jobs can not be separated on 2 workers", "jobs stream:4 and stream:5 must be co-located"} {
+		if expected, got := reason, u[i].Reason; expected != got {
+			t.Errorf("reason does not match, expected %s got %s", expected, got)
+		}
+	}
+}
+
+func TestConstraints_ItAggregatesUnitWeights(t *testing.T) {
+	c := NewConstraints([]config.Job{"a", "b", "c"}, [][]config.Job{{"b", "a", "x"}}, nil)
+
+	w := c.Weights(Weights{"a": 3, "c": 2})
+	if expected, got := int64(4), w.Of(c.Unit("b")); expected != got {
+		t.Errorf("unit weight does not match, expected %d got %d", expected, got)
+	}
+	if expected, got := config.Job("a"), c.Unit("b"); expected != got {
+		t.Errorf("unit does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := int64(2), w.Of("c"); expected != got {
+		t.Errorf("weight does not match, expected %d got %d", expected, got)
+	}
+}
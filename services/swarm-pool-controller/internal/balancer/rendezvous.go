@@ -0,0 +1,144 @@
+package balancer
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// DefaultLoadFactor keeps even load, workers differ at most by one job
+const DefaultLoadFactor = 1.0
+
+// Balancer assigns each job to exactly one worker
+type Balancer interface {
+	Balance(jobs []config.Job, workers []string) map[string][]config.Job
+}
+
+// rendezvous implements highest random weight hashing with bounded load, each job ranks workers by
+// its job-worker hash and goes to the highest ranked worker with free capacity. Worker set changes
+// only move jobs whose ranking gets affected, about 1/N jobs on single worker scaling.
+type rendezvous struct {
+	loadFactor float64
+}
+
+// NewRendezvous instantiates rendezvous balancer, worker capacity is ceil(loadFactor * jobs / workers),
+// higher load factors move less jobs on scaling at the cost of uneven load, load factors lower than 1
+// get raised to 1 as all jobs must fit
+func NewRendezvous(loadFactor float64) Balancer {
+	if loadFactor < 1 {
+		loadFactor = 1
+	}
+
+	return &rendezvous{loadFactor: loadFactor}
+}
+
+// Balance assigns deduplicated jobs to workers, all workers get an entry even without jobs
+func (r *rendezvous) Balance(jobs []config.Job, workers []string) map[string][]config.Job {
+	res := make(map[string][]config.Job, len(workers))
+	for _, w := range workers {
+		res[w] = []config.Job{}
+	}
+	if len(workers) == 0 {
+		return res
+	}
+
+	unique := sortedByHash(jobs)
+	capacity := Capacity(len(unique), len(workers), r.loadFactor)
+	// on tight capacities only some workers can get full, the rest must stay one job below so that all jobs fit
+	maxFull := len(unique) - len(workers)*(capacity-1)
+	full := 0
+	for _, j := range unique {
+		for _, w := range Rank(j, workers) {
+			l := len(res[w])
+			if l >= capacity || (maxFull > 0 && l == capacity-1 && full >= maxFull) {
+				continue
+			}
+			if l == capacity-1 {
+				full++
+			}
+			res[w] = append(res[w], j)
+			break
+		}
+	}
+
+	for _, w := range workers {
+		sort.Slice(res[w], func(i, j int) bool { return res[w][i] < res[w][j] })
+	}
+
+	return res
+}
+
+// Capacity returns max jobs by worker
+func Capacity(jobs, workers int, loadFactor float64) int {
+	if workers == 0 {
+		return 0
+	}
+
+	return int(math.Ceil(loadFactor * float64(jobs) / float64(workers)))
+}
+
+// Rank returns workers sorted by job preference, highest score first
+func Rank(j config.Job, workers []string) []string {
+	scores := make(map[string]uint64, len(workers))
+	ranked := make([]string, len(workers))
+	copy(ranked, workers)
+	for _, w := range ranked {
+		scores[w] = Score(j, w)
+	}
+	sort.Slice(ranked, func(a, b int) bool {
+		if scores[ranked[a]] == scores[ranked[b]] {
+			return ranked[a] < ranked[b]
+		}
+		return scores[ranked[a]] > scores[ranked[b]]
+	})
+
+	return ranked
+}
+
+// Score returns job worker rendezvous weight
+func Score(j config.Job, worker string) uint64 {
+	return mix(hash(worker + "/" + string(j)))
+}
+
+// sortedByHash deduplicates jobs, processing order depends only on job hash so that
+// capacity overflows stay stable between worker set changes
+func sortedByHash(jobs []config.Job) []config.Job {
+	seen := make(map[config.Job]struct{}, len(jobs))
+	res := make([]config.Job, 0, len(jobs))
+	for _, j := range jobs {
+		if _, ok := seen[j]; ok {
+			continue
+		}
+		seen[j] = struct{}{}
+		res = append(res, j)
+	}
+
+	sort.Slice(res, func(a, b int) bool {
+		ha, hb := hash(string(res[a])), hash(string(res[b]))
+		if ha == hb {
+			return res[a] < res[b]
+		}
+		return ha < hb
+	})
+
+	return res
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	return h.Sum64()
+}
+
+// mix spreads fnv output bits (splitmix64 finalizer), similar worker names would rank too close otherwise
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
@@ -0,0 +1,152 @@
+package balancer
+
+import (
+	"fmt"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"testing"
+)
+
+func TestRendezvous_ItAssignsEveryJobExactlyOnce(t *testing.T) {
+	b := NewRendezvous(DefaultLoadFactor)
+	for _, totalJobs := range []int{0, 1, 7, 27, 100, 1001} {
+		for totalWorkers := 1; totalWorkers <= 13; totalWorkers++ {
+			jobs := fakeJobs(totalJobs)
+			res := b.Balance(jobs, fakeWorkers(totalWorkers))
+
+			if expected, got := totalWorkers, len(res); expected != got {
+				t.Fatalf("total workers do not match, expected %d got %d", expected, got)
+			}
+			assertAssignedOnce(t, jobs, res)
+			min, max := totalJobs/totalWorkers, totalJobs/totalWorkers
+			if totalJobs%totalWorkers != 0 {
+				max++
+			}
+			for w, j := range res {
+				if len(j) < min || len(j) > max {
+					t.Errorf("worker %s load %d out of even bounds %d-%d", w, len(j), min, max)
+				}
+			}
+		}
+	}
+}
+
+func TestRendezvous_ItBoundsLoadByLoadFactor(t *testing.T) {
+	loadFactor := 1.5
+	jobs := fakeJobs(100)
+	res := NewRendezvous(loadFactor).Balance(jobs, fakeWorkers(7))
+
+	assertAssignedOnce(t, jobs, res)
+	capacity := Capacity(len(jobs), 7, loadFactor)
+	for w, j := range res {
+		if len(j) > capacity {
+			t.Errorf("worker %s exceeds capacity %d with %d jobs", w, capacity, len(j))
+		}
+	}
+}
+
+func TestRendezvous_ItDeduplicatesJobs(t *testing.T) {
+	res := NewRendezvous(DefaultLoadFactor).Balance([]config.Job{"foo", "bar", "foo"}, fakeWorkers(2))
+
+	total := 0
+	for _, j := range res {
+		total += len(j)
+	}
+	if expected, got := 2, total; expected != got {
+		t.Fatalf("total jobs do not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestRendezvous_ItIsIndependentFromJobsOrder(t *testing.T) {
+	b := NewRendezvous(DefaultLoadFactor)
+	jobs := fakeJobs(50)
+	reversed := make([]config.Job, len(jobs))
+	for i, j := range jobs {
+		reversed[len(jobs)-1-i] = j
+	}
+
+	first, second := b.Balance(jobs, fakeWorkers(4)), b.Balance(reversed, fakeWorkers(4))
+	for w, j := range first {
+		if expected, got := fmt.Sprint(j), fmt.Sprint(second[w]); expected != got {
+			t.Fatalf("worker %s assignment does not match, expected %s got %s", w, expected, got)
+		}
+	}
+}
+
+func TestRendezvous_ItMovesAboutOneNthOfJobsOnScaling(t *testing.T) {
+	b := NewRendezvous(DefaultLoadFactor)
+	jobs := fakeJobs(1000)
+	for _, sc := range []struct{ from, to int }{{3, 4}, {4, 3}, {9, 10}, {10, 9}} {
+		before := owners(b.Balance(jobs, fakeWorkers(sc.from)))
+		after := owners(b.Balance(jobs, fakeWorkers(sc.to)))
+
+		moved := 0
+		for j, w := range before {
+			if after[j] != w {
+				moved++
+			}
+		}
+
+		n := sc.from
+		if sc.to > n {
+			n = sc.to
+		}
+		// ideal movement is 1/N, bounded load adds some overflow moves
+		if max := len(jobs) * 13 / (10 * n); moved > max {
+			t.Errorf("scaling %d to %d moved %d jobs, expected at most %d", sc.from, sc.to, moved, max)
+		}
+	}
+}
+
+func TestRendezvous_ItLeavesWorkersEmptyWithoutJobs(t *testing.T) {
+	res := NewRendezvous(DefaultLoadFactor).Balance(nil, fakeWorkers(3))
+	for w, j := range res {
+		if len(j) != 0 {
+			t.Errorf("worker %s expected without jobs, got %v", w, j)
+		}
+	}
+}
+
+func assertAssignedOnce(t *testing.T, jobs []config.Job, res map[string][]config.Job) {
+	t.Helper()
+	seen := map[config.Job]string{}
+	for w, js := range res {
+		for _, j := range js {
+			if prev, ok := seen[j]; ok {
+				t.Fatalf("job %s assigned twice, workers %s and %s", j, prev, w)
+			}
+			seen[j] = w
+		}
+	}
+	if expected, got := len(jobs), len(seen); expected != got {
+		t.Fatalf("total assigned jobs do not match, expected %d got %d", expected, got)
+	}
+}
+
+func owners(res map[string][]config.Job) map[config.Job]string {
+	o := map[config.Job]string{}
+	for w, js := range res {
+		for _, j := range js {
+			o[j] = w
+		}
+	}
+
+	return o
+}
+
+func fakeJobs(total int) []config.Job {
+	res := make([]config.Job, total)
+	for i := 0; i < total; i++ {
+		res[i] = config.Job(fmt.Sprintf("stream:%d", i))
+	}
+
+	return res
+}
+
+func fakeWorkers(total int) []string {
+	res := make([]string, total)
+	for i := 0; i < total; i++ {
+		res[i] = fmt.Sprintf("swarm-worker-%d", i)
+	}
+
+	return res
+}
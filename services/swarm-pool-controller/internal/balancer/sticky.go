@@ -0,0 +1,169 @@
+package balancer
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"sort"
+)
+
+// DefaultTolerance allows workers to differ by one job before moving placed jobs
+const DefaultTolerance int64 = 1
+
+// Rebalancer balances jobs starting from previous assignations
+type Rebalancer interface {
+	Balancer
+	Rebalance(previous *config.Workloads, jobs []config.Job, workers []string) map[string][]config.Job
+}
+
+// sticky keeps previous job placements, only new and orphaned jobs get placed, on the least loaded
+// worker, placed jobs move only when max worker load difference exceeds tolerance
+type sticky struct {
+	base      Balancer
+	weights   Weights
+	tolerance int64
+}
+
+// NewSticky instantiates sticky balancer, base balancer computes initial assignations, worker loads
+// and tolerance get measured on job weights, nil weights count jobs
+func NewSticky(base Balancer, w Weights, tolerance int64) Rebalancer {
+	if tolerance < 0 {
+		tolerance = 0
+	}
+
+	return &sticky{base: base, weights: w, tolerance: tolerance}
+}
+
+// Balance assigns jobs without previous assignations
+func (s *sticky) Balance(jobs []config.Job, workers []string) map[string][]config.Job {
+	return s.Rebalance(nil, jobs, workers)
+}
+
+// Rebalance assigns deduplicated jobs keeping previous placements on remaining workers
+func (s *sticky) Rebalance(previous *config.Workloads, jobs []config.Job, workers []string) map[string][]config.Job {
+	if !s.placed(previous, workers) {
+		return s.base.Balance(jobs, workers)
+	}
+
+	res := make(map[string][]config.Job, len(workers))
+	loads := make(map[string]int64, len(workers))
+	pending := map[config.Job]struct{}{}
+	for _, j := range jobs {
+		pending[j] = struct{}{}
+	}
+
+	sorted := make([]string, len(workers))
+	copy(sorted, workers)
+	sort.Strings(sorted)
+	for _, w := range sorted {
+		res[w] = []config.Job{}
+		wl, ok := previous.Workloads[w]
+		if !ok || wl == nil {
+			continue
+		}
+		for _, j := range wl.Normalize().Jobs {
+			if _, ok := pending[j]; !ok {
+				continue
+			}
+			delete(pending, j)
+			res[w] = append(res[w], j)
+			loads[w] += s.weights.Of(j)
+		}
+	}
+
+	// new and orphaned jobs, heaviest first
+	unplaced := make([]config.Job, 0, len(pending))
+	for _, j := range sortedByHash(jobs) {
+		if _, ok := pending[j]; ok {
+			unplaced = append(unplaced, j)
+		}
+	}
+	sort.SliceStable(unplaced, func(i, j int) bool {
+		return s.weights.Of(unplaced[i]) > s.weights.Of(unplaced[j])
+	})
+	for _, j := range unplaced {
+		w := s.leastLoaded(sorted, res, loads)
+		res[w] = append(res[w], j)
+		loads[w] += s.weights.Of(j)
+	}
+
+	s.spread(sorted, res, loads)
+
+	for _, w := range sorted {
+		sort.Slice(res[w], func(i, j int) bool { return res[w][i] < res[w][j] })
+	}
+
+	return res
+}
+
+// spread moves jobs from the most to the least loaded worker while load difference exceeds tolerance,
+// each move strictly reduces load variance, so it always ends
+func (s *sticky) spread(workers []string, res map[string][]config.Job, loads map[string]int64) {
+	for {
+		hi, lo := s.mostLoaded(workers, res, loads), s.leastLoaded(workers, res, loads)
+		diff := loads[hi] - loads[lo]
+		if diff <= s.tolerance {
+			return
+		}
+
+		// job closest to half the difference evens loads the most, moves under diff always improve
+		idx := -1
+		var best int64
+		for i, j := range res[hi] {
+			w := s.weights.Of(j)
+			if w >= diff {
+				continue
+			}
+			gap := diff - 2*w
+			if gap < 0 {
+				gap = -gap
+			}
+			if idx == -1 || gap < best || (gap == best && j < res[hi][idx]) {
+				idx, best = i, gap
+			}
+		}
+		if idx == -1 {
+			return
+		}
+
+		j := res[hi][idx]
+		res[hi] = append(res[hi][:idx], res[hi][idx+1:]...)
+		res[lo] = append(res[lo], j)
+		loads[hi] -= s.weights.Of(j)
+		loads[lo] += s.weights.Of(j)
+	}
+}
+
+func (s *sticky) leastLoaded(workers []string, res map[string][]config.Job, loads map[string]int64) string {
+	target := workers[0]
+	for _, w := range workers[1:] {
+		if loads[w] < loads[target] || (loads[w] == loads[target] && len(res[w]) < len(res[target])) {
+			target = w
+		}
+	}
+
+	return target
+}
+
+func (s *sticky) mostLoaded(workers []string, res map[string][]config.Job, loads map[string]int64) string {
+	target := workers[0]
+	for _, w := range workers[1:] {
+		if loads[w] > loads[target] || (loads[w] == loads[target] && len(res[w]) > len(res[target])) {
+			target = w
+		}
+	}
+
+	return target
+}
+
+// placed checks if any remaining worker has previous assignations
+func (s *sticky) placed(previous *config.Workloads, workers []string) bool {
+	if previous == nil || len(workers) == 0 {
+		return false
+	}
+	for _, w := range workers {
+		if wl, ok := previous.Workloads[w]; ok && wl != nil && len(wl.Jobs) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
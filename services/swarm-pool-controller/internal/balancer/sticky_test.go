@@ -0,0 +1,125 @@
+package balancer
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"testing"
+)
+
+func TestSticky_ItKeepsPlacementsOnAddedJobs(t *testing.T) {
+	b := NewSticky(NewRendezvous(DefaultLoadFactor), nil, DefaultTolerance)
+	jobs := fakeJobs(30)
+	workers := fakeWorkers(3)
+	previous := workloads(b.Balance(jobs, workers))
+
+	updated := append(append([]config.Job{}, jobs...), "stream:new")
+	res := b.Rebalance(previous, updated, workers)
+
+	assertAssignedOnce(t, updated, res)
+	if expected, got := 0, moved(previous, res); expected != got {
+		t.Errorf("moved jobs do not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestSticky_ItPlacesOrphanedJobsOnRemainingWorkers(t *testing.T) {
+	b := NewSticky(NewRendezvous(DefaultLoadFactor), nil, DefaultTolerance)
+	jobs := fakeJobs(30)
+	previous := workloads(b.Balance(jobs, fakeWorkers(3)))
+
+	res := b.Rebalance(previous, jobs, fakeWorkers(2))
+
+	assertAssignedOnce(t, jobs, res)
+	if expected, got := 0, moved(previous, res); expected != got {
+		t.Errorf("moved jobs do not match, expected %d got %d", expected, got)
+	}
+	for w, j := range res {
+		if expected, got := 15, len(j); expected != got {
+			t.Errorf("worker %s total jobs do not match, expected %d got %d", w, expected, got)
+		}
+	}
+}
+
+func TestSticky_ItMovesJobsOnlyWhenSkewExceedsTolerance(t *testing.T) {
+	previous := workloads(map[string][]config.Job{
+		"swarm-worker-0": {"a", "b", "c", "d"},
+		"swarm-worker-1": {"e", "f"},
+	})
+	jobs := []config.Job{"a", "b", "c", "d", "e", "f"}
+
+	res := NewSticky(NewRendezvous(DefaultLoadFactor), nil, 2).Rebalance(previous, jobs, fakeWorkers(2))
+	if expected, got := 0, moved(previous, res); expected != got {
+		t.Errorf("moved jobs within tolerance do not match, expected %d got %d", expected, got)
+	}
+
+	res = NewSticky(NewRendezvous(DefaultLoadFactor), nil, 1).Rebalance(previous, jobs, fakeWorkers(2))
+	if expected, got := 1, moved(previous, res); expected != got {
+		t.Errorf("moved jobs over tolerance do not match, expected %d got %d", expected, got)
+	}
+	assertAssignedOnce(t, jobs, res)
+}
+
+func TestSticky_ItMovesMinimalJobsOnScaleUp(t *testing.T) {
+	b := NewSticky(NewRendezvous(DefaultLoadFactor), nil, DefaultTolerance)
+	jobs := fakeJobs(1000)
+	previous := workloads(b.Balance(jobs, fakeWorkers(3)))
+
+	res := b.Rebalance(previous, jobs, fakeWorkers(4))
+
+	assertAssignedOnce(t, jobs, res)
+	if expected, got := 250, moved(previous, res); expected != got {
+		t.Errorf("moved jobs do not match, expected %d got %d", expected, got)
+	}
+	for w, j := range res {
+		if expected, got := 250, len(j); expected != got {
+			t.Errorf("worker %s total jobs do not match, expected %d got %d", w, expected, got)
+		}
+	}
+}
+
+func TestSticky_ItMeasuresSkewOnJobWeights(t *testing.T) {
+	w := Weights{"hd1": 10, "hd2": 10}
+	previous := workloads(map[string][]config.Job{
+		"swarm-worker-0": {"hd1", "hd2"},
+		"swarm-worker-1": {"a", "b"},
+	})
+	jobs := []config.Job{"hd1", "hd2", "a", "b"}
+
+	res := NewSticky(NewWeighted(w), w, DefaultTolerance).Rebalance(previous, jobs, fakeWorkers(2))
+
+	assertAssignedOnce(t, jobs, res)
+	for worker, j := range res {
+		if expected, got := int64(11), w.Load(j); expected != got {
+			t.Errorf("worker %s load does not match, expected %d got %d", worker, expected, got)
+		}
+	}
+}
+
+func workloads(assignations map[string][]config.Job) *config.Workloads {
+	res := &config.Workloads{Workloads: map[string]*config.Workload{}}
+	for w, j := range assignations {
+		res.Workloads[w] = &config.Workload{Jobs: j}
+	}
+
+	return res
+}
+
+func moved(previous *config.Workloads, res map[string][]config.Job) int {
+	owners := map[config.Job]string{}
+	for w, wl := range previous.Workloads {
+		for _, j := range wl.Jobs {
+			owners[j] = w
+		}
+	}
+
+	total := 0
+	for w, js := range res {
+		for _, j := range js {
+			if o, ok := owners[j]; ok && o != w {
+				if _, exists := res[o]; exists {
+					total++
+				}
+			}
+		}
+	}
+
+	return total
+}
@@ -0,0 +1,184 @@
+package balancer
+
+import (
+	"fmt"
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"sort"
+	"strconv"
+)
+
+const (
+	// RoundRobin splits jobs in even contiguous chunks following jobs order
+	RoundRobin = "round-robin"
+	// Hashing assigns jobs by rendezvous hashing with bounded load, parameter load-factor
+	Hashing = "hashing"
+	// Weighted bin packs weighted jobs
+	Weighted = "weighted"
+	// Sticky keeps previous placements, parameters tolerance and base (initial assignations strategy)
+	Sticky = "sticky"
+
+	// DefaultStrategy applies on swarms without strategy, it keeps balancing from before strategies got
+	// selectable: sticky placements over hashing, or over weighted bin packing on weighted swarms
+	DefaultStrategy = Sticky
+)
+
+const (
+	loadFactorParam = "load-factor"
+	toleranceParam  = "tolerance"
+	baseParam       = "base"
+)
+
+// Params defines strategy specific parameters
+type Params map[string]string
+
+// Float returns float parameter, missing parameters get default value
+func (p Params) Float(key string, def float64) (float64, error) {
+	v, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s parameter %s, error %v", key, v, err)
+	}
+
+	return f, nil
+}
+
+// Int returns integer parameter, missing parameters get default value
+func (p Params) Int(key string, def int64) (int64, error) {
+	v, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s parameter %s, error %v", key, v, err)
+	}
+
+	return i, nil
+}
+
+// Factory builds strategy balancer from its parameters and job weights
+type Factory func(r Registry, p Params, w Weights) (Balancer, error)
+
+// Registry indexes balancing strategies by name
+type Registry map[string]Factory
+
+// NewRegistry instantiates registry with default strategies, tolerance applies on sticky strategies without tolerance parameter
+func NewRegistry(tolerance int64) Registry {
+	return Registry{
+		RoundRobin: func(_ Registry, _ Params, _ Weights) (Balancer, error) {
+			return NewRoundRobin(), nil
+		},
+		Hashing: func(_ Registry, p Params, _ Weights) (Balancer, error) {
+			lf, err := p.Float(loadFactorParam, DefaultLoadFactor)
+			if err != nil {
+				return nil, err
+			}
+
+			return NewRendezvous(lf), nil
+		},
+		Weighted: func(_ Registry, _ Params, w Weights) (Balancer, error) {
+			return NewWeighted(w), nil
+		},
+		Sticky: func(r Registry, p Params, w Weights) (Balancer, error) {
+			t, err := p.Int(toleranceParam, tolerance)
+			if err != nil {
+				return nil, err
+			}
+
+			base := Hashing
+			if len(w) > 0 {
+				base = Weighted
+			}
+			if v, ok := p[baseParam]; ok {
+				base = v
+			}
+			if base == Sticky {
+				return nil, fmt.Errorf("invalid %s parameter %s", baseParam, base)
+			}
+
+			b, err := r.Build(base, p, w)
+			if err != nil {
+				return nil, err
+			}
+
+			return NewSticky(b, w, t), nil
+		},
+	}
+}
+
+// Build instantiates named strategy balancer, empty name builds default strategy
+func (r Registry) Build(name string, p Params, w Weights) (Balancer, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+
+	f, ok := r[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %s, available %v", name, r.Names())
+	}
+
+	b, err := f(r, p, w)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build strategy %s, error %v", name, err)
+	}
+
+	return b, nil
+}
+
+// Names returns registered strategies sorted by name
+func (r Registry) Names() []string {
+	res := make([]string, 0, len(r))
+	for name := range r {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// roundRobin splits deduplicated jobs in contiguous chunks keeping jobs order, first workers get the extra jobs
+type roundRobin struct{}
+
+// NewRoundRobin instantiates round robin chunks balancer
+func NewRoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+// Balance assigns jobs chunks to workers, all workers get an entry even without jobs
+func (b *roundRobin) Balance(jobs []config.Job, workers []string) map[string][]config.Job {
+	res := make(map[string][]config.Job, len(workers))
+	for _, w := range workers {
+		res[w] = []config.Job{}
+	}
+	if len(workers) == 0 {
+		return res
+	}
+
+	seen := make(map[config.Job]struct{}, len(jobs))
+	unique := make([]config.Job, 0, len(jobs))
+	for _, j := range jobs {
+		if _, ok := seen[j]; ok {
+			continue
+		}
+		seen[j] = struct{}{}
+		unique = append(unique, j)
+	}
+
+	size, extra := len(unique)/len(workers), len(unique)%len(workers)
+	from := 0
+	for i, w := range workers {
+		to := from + size
+		if i < extra {
+			to++
+		}
+		res[w] = append(res[w], unique[from:to]...)
+		from = to
+	}
+
+	return res
+}
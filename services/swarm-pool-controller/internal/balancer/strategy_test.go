@@ -0,0 +1,79 @@
+package balancer
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry_ItBuildsRegisteredStrategies(t *testing.T) {
+	r := NewRegistry(DefaultTolerance)
+	jobs := fakeJobs(20)
+	for _, name := range append(r.Names(), "") {
+		b, err := r.Build(name, Params{}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error building strategy %s %v", name, err)
+		}
+		assertAssignedOnce(t, jobs, b.Balance(jobs, fakeWorkers(3)))
+	}
+
+	b, _ := r.Build("", nil, nil)
+	if _, ok := b.(Rebalancer); !ok {
+		t.Errorf("expected sticky default strategy, got %T", b)
+	}
+}
+
+func TestRegistry_ItKeepsPreviousBalancingAsDefaultStrategy(t *testing.T) {
+	r := NewRegistry(DefaultTolerance)
+	jobs := fakeJobs(20)
+	w := Weights{}
+	for i, j := range jobs {
+		w[j] = int64(i%4 + 1)
+	}
+
+	for _, sc := range []struct {
+		weights  Weights
+		previous Balancer
+	}{
+		{nil, NewSticky(NewRendezvous(DefaultLoadFactor), nil, DefaultTolerance)},
+		{w, NewSticky(NewWeighted(w), w, DefaultTolerance)},
+	} {
+		b, err := r.Build("", nil, sc.weights)
+		if err != nil {
+			t.Fatalf("unexpected error building default strategy %v", err)
+		}
+		if expected, got := sc.previous.Balance(jobs, fakeWorkers(3)), b.Balance(jobs, fakeWorkers(3)); !reflect.DeepEqual(expected, got) {
+			t.Errorf("default strategy assignations do not match, expected %v got %v", expected, got)
+		}
+	}
+}
+
+func TestRegistry_ItFailsOnUnknownStrategiesAndInvalidParams(t *testing.T) {
+	r := NewRegistry(DefaultTolerance)
+	for _, sc := range []struct {
+		name   string
+		params Params
+	}{
+		{"foo", nil},
+		{Hashing, Params{loadFactorParam: "foo"}},
+		{Sticky, Params{toleranceParam: "1.5"}},
+		{Sticky, Params{baseParam: Sticky}},
+		{Sticky, Params{baseParam: "foo"}},
+	} {
+		if _, err := r.Build(sc.name, sc.params, nil); err == nil {
+			t.Errorf("expected error building strategy %s params %v", sc.name, sc.params)
+		}
+	}
+}
+
+func TestRoundRobin_ItSplitsJobsInOrderedChunks(t *testing.T) {
+	jobs := []config.Job{"e", "d", "c", "b", "a", "c"}
+	res := NewRoundRobin().Balance(jobs, fakeWorkers(2))
+
+	if expected, got := []config.Job{"e", "d", "c"}, res["swarm-worker-0"]; !reflect.DeepEqual(expected, got) {
+		t.Errorf("first chunk does not match, expected %v got %v", expected, got)
+	}
+	if expected, got := []config.Job{"b", "a"}, res["swarm-worker-1"]; !reflect.DeepEqual(expected, got) {
+		t.Errorf("second chunk does not match, expected %v got %v", expected, got)
+	}
+}
@@ -0,0 +1,80 @@
+package balancer
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"sort"
+)
+
+// DefaultWeight applies to jobs without explicit weight
+const DefaultWeight int64 = 1
+
+// Weights indexes job weights
+type Weights map[config.Job]int64
+
+// Of returns job weight, missing and non positive weights get default weight
+func (w Weights) Of(j config.Job) int64 {
+	if v, ok := w[j]; ok && v > 0 {
+		return v
+	}
+
+	return DefaultWeight
+}
+
+// Load returns jobs total weight
+func (w Weights) Load(jobs []config.Job) int64 {
+	var res int64
+	for _, j := range jobs {
+		res += w.Of(j)
+	}
+
+	return res
+}
+
+// weighted implements longest processing time first bin packing, heaviest jobs get placed first on the
+// least loaded worker, max worker load stays within 4/3 of the optimal one
+type weighted struct {
+	weights Weights
+}
+
+// NewWeighted instantiates weighted balancer
+func NewWeighted(w Weights) Balancer {
+	return &weighted{weights: w}
+}
+
+// Balance assigns deduplicated jobs minimising max worker load
+func (b *weighted) Balance(jobs []config.Job, workers []string) map[string][]config.Job {
+	res := make(map[string][]config.Job, len(workers))
+	for _, w := range workers {
+		res[w] = []config.Job{}
+	}
+	if len(workers) == 0 {
+		return res
+	}
+
+	unique := sortedByHash(jobs)
+	sort.SliceStable(unique, func(i, j int) bool {
+		wi, wj := b.weights.Of(unique[i]), b.weights.Of(unique[j])
+		if wi == wj {
+			return unique[i] < unique[j]
+		}
+		return wi > wj
+	})
+
+	loads := make(map[string]int64, len(workers))
+	for _, j := range unique {
+		target := workers[0]
+		for _, w := range workers[1:] {
+			if loads[w] < loads[target] || (loads[w] == loads[target] && len(res[w]) < len(res[target])) {
+				target = w
+			}
+		}
+		res[target] = append(res[target], j)
+		loads[target] += b.weights.Of(j)
+	}
+
+	for _, w := range workers {
+		sort.Slice(res[w], func(i, j int) bool { return res[w][i] < res[w][j] })
+	}
+
+	return res
+}
@@ -0,0 +1,58 @@
+package balancer
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/config"
+	"testing"
+)
+
+func TestWeighted_ItMinimisesMaxWorkerLoad(t *testing.T) {
+	w := Weights{"stream:hd1": 10, "stream:hd2": 10}
+	jobs := []config.Job{"stream:hd1", "stream:hd2", "audio:1", "audio:2", "audio:3", "audio:4"}
+	res := NewWeighted(w).Balance(jobs, fakeWorkers(2))
+
+	assertAssignedOnce(t, jobs, res)
+	for worker, j := range res {
+		if expected, got := int64(12), w.Load(j); expected != got {
+			t.Errorf("worker %s load does not match, expected %d got %d", worker, expected, got)
+		}
+	}
+}
+
+func TestWeighted_ItAssignsEveryJobExactlyOnceWithinLPTBound(t *testing.T) {
+	jobs := fakeJobs(200)
+	w := Weights{}
+	var total, heaviest int64
+	for i, j := range jobs {
+		w[j] = int64(i%7 + 1)
+		total += w[j]
+		if w[j] > heaviest {
+			heaviest = w[j]
+		}
+	}
+
+	for totalWorkers := 1; totalWorkers <= 9; totalWorkers++ {
+		res := NewWeighted(w).Balance(jobs, fakeWorkers(totalWorkers))
+		assertAssignedOnce(t, jobs, res)
+
+		// lpt max load never exceeds mean load plus heaviest job
+		bound := total/int64(totalWorkers) + heaviest
+		for worker, j := range res {
+			if load := w.Load(j); load > bound {
+				t.Errorf("worker %s load %d exceeds bound %d", worker, load, bound)
+			}
+		}
+	}
+}
+
+func TestWeighted_ItAppliesDefaultWeightToUnweightedJobs(t *testing.T) {
+	if expected, got := DefaultWeight, (Weights{"foo": 0}).Of("foo"); expected != got {
+		t.Errorf("weight does not match, expected %d got %d", expected, got)
+	}
+
+	res := NewWeighted(nil).Balance(fakeJobs(9), fakeWorkers(3))
+	for worker, j := range res {
+		if expected, got := 3, len(j); expected != got {
+			t.Errorf("worker %s total jobs do not match, expected %d got %d", worker, expected, got)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/configmap"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+// EnvAliases keeps legacy env var names, any other flag is read from its uppercased env name
+var EnvAliases = map[string]string{
+	"label":              "WATCHED_LABEL",
+	"configmap":          "WORKERS_CONFIGMAP_NAME",
+	"configmap-format":   "WORKERS_CONFIGMAP_FORMAT",
+	"configmap-key":      "WORKERS_CONFIGMAP_KEY",
+	"configmap-binary":   "WORKERS_CONFIGMAP_BINARY",
+	"configmap-sharding": "WORKERS_CONFIGMAP_SHARDING",
+	"storage":            "WORKLOAD_STORAGE",
+	"storage-path":       "WORKLOAD_STORAGE_PATH",
+}
+
+// SetFlags defines swarm pool controller flags
+func SetFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("namespace", "swarm", "namespace to listen")
+	cmd.PersistentFlags().String("label", "swarm-worker", "label to watch statefulsets and pods")
+	cmd.PersistentFlags().String("configmap", "swarm-worker-config", "workers configmap name")
+	cmd.PersistentFlags().String("configmap-format", "yaml", "workers configmap format (yaml, json, toml)")
+	cmd.PersistentFlags().String("configmap-key", "config.yml", "workers configmap key")
+	cmd.PersistentFlags().Bool("configmap-binary", false, "write workers config on configmap binary data")
+	cmd.PersistentFlags().String("configmap-sharding", "", "split workers config on multiple configmaps (worker, size)")
+	cmd.PersistentFlags().String("storage", storage.ConfigMap, "default workload storage (configmap, worker-configmap, secret, status, annotations, memory, file)")
+	cmd.PersistentFlags().String("storage-path", "", "workload file storage base path")
+	cmd.PersistentFlags().Int64("balance-tolerance", 1, "max worker load difference before moving already placed jobs")
+	cmd.PersistentFlags().Duration("handoff-timeout", 0, "moved jobs release acknowledgement timeout on two phase rebalances, lagging workers get restarted, zero disables handoffs")
+	cmd.PersistentFlags().Duration("job-lease-duration", 0, "per job lease duration enforcing job ownership, zero disables job leases")
+	cmd.PersistentFlags().Duration("unhealthy-grace", 0, "not ready worker grace period before moving its jobs to healthy workers, zero disables it")
+	cmd.PersistentFlags().Duration("health-heartbeat-timeout", time.Minute, "max runner heartbeat age while processing entries before liveness fails")
+	cmd.PersistentFlags().Int("health-max-queue-depth", 100, "max runner queue depth before readiness fails")
+	cmd.PersistentFlags().Bool("leader-elect", false, "run controllers only while holding leader lease, standby replicas are not ready")
+	cmd.PersistentFlags().String("leader-elect-lease", "swarm-pool-controller", "leader election lease name on watched namespace")
+	cmd.PersistentFlags().Duration("leader-elect-lease-duration", 15*time.Second, "leader election lease duration")
+}
+
+// Config defines swarm pool controller config
+type Config struct {
+	cfg.Core          `mapstructure:",squash"`
+	cfg.Runner        `mapstructure:",squash"`
+	Namespace         string        `mapstructure:"namespace"`
+	WatchLabel        string        `mapstructure:"label"`
+	ConfigMapName     string        `mapstructure:"configmap"`
+	ConfigMapFormat   string        `mapstructure:"configmap-format"`
+	ConfigMapKey      string        `mapstructure:"configmap-key"`
+	ConfigMapBinary   bool          `mapstructure:"configmap-binary"`
+	ConfigMapSharding string        `mapstructure:"configmap-sharding"`
+	Storage           string        `mapstructure:"storage"`
+	StoragePath       string        `mapstructure:"storage-path"`
+	BalanceTolerance  int64         `mapstructure:"balance-tolerance"`
+	HandoffTimeout    time.Duration `mapstructure:"handoff-timeout"`
+	JobLeaseDuration  time.Duration `mapstructure:"job-lease-duration"`
+	UnhealthyGrace    time.Duration `mapstructure:"unhealthy-grace"`
+	HeartbeatTimeout  time.Duration `mapstructure:"health-heartbeat-timeout"`
+	MaxQueueDepth     int           `mapstructure:"health-max-queue-depth"`
+	LeaderElect       bool          `mapstructure:"leader-elect"`
+	LeaderLease       string        `mapstructure:"leader-elect-lease"`
+	LeaderLeaseTime   time.Duration `mapstructure:"leader-elect-lease-duration"`
+}
+
+// Validate checks config consistency
+func (c *Config) Validate() error {
+	if err := c.Core.Validate(); err != nil {
+		return err
+	}
+	if err := c.Runner.Validate(); err != nil {
+		return err
+	}
+	if c.Namespace == "" {
+		return errors.New("empty namespace")
+	}
+	if c.WatchLabel == "" {
+		return errors.New("empty watched label")
+	}
+	if c.ConfigMapName == "" {
+		return errors.New("empty workers configmap name")
+	}
+	if _, err := cfg.NewCodec(c.ConfigMapFormat); err != nil {
+		return err
+	}
+
+	if c.BalanceTolerance < 0 {
+		return fmt.Errorf("invalid balance tolerance %d", c.BalanceTolerance)
+	}
+	if c.HandoffTimeout < 0 {
+		return fmt.Errorf("invalid handoff timeout %s", c.HandoffTimeout)
+	}
+	if c.JobLeaseDuration < 0 || (c.JobLeaseDuration > 0 && c.JobLeaseDuration < time.Second) {
+		return fmt.Errorf("invalid job lease duration %s", c.JobLeaseDuration)
+	}
+	if c.UnhealthyGrace < 0 {
+		return fmt.Errorf("invalid unhealthy grace %s", c.UnhealthyGrace)
+	}
+	if c.HeartbeatTimeout <= 0 {
+		return fmt.Errorf("invalid health heartbeat timeout %s", c.HeartbeatTimeout)
+	}
+	if c.MaxQueueDepth <= 0 {
+		return fmt.Errorf("invalid health max queue depth %d", c.MaxQueueDepth)
+	}
+	if c.LeaderElect && c.LeaderLease == "" {
+		return errors.New("empty leader election lease name")
+	}
+	if c.LeaderElect && c.LeaderLeaseTime < 3*time.Second {
+		return fmt.Errorf("invalid leader election lease duration %s", c.LeaderLeaseTime)
+	}
+
+	switch configmap.ShardMode(c.ConfigMapSharding) {
+	case "", configmap.ShardByWorker, configmap.ShardBySize:
+	default:
+		return fmt.Errorf("unsupported configmap sharding %s", c.ConfigMapSharding)
+	}
+
+	switch c.Storage {
+	case storage.ConfigMap, storage.WorkerConfigMap, storage.Secret, storage.Status, storage.Annotations, storage.Memory:
+	case storage.File:
+		if c.StoragePath == "" {
+			return errors.New("file storage requires storage path")
+		}
+	default:
+		return fmt.Errorf("unsupported storage %s", c.Storage)
+	}
+
+	return nil
+}
+
+// RequiresRestart checks if updated config changes fields that can not be hot reloaded,
+// log levels and runner tuning are the only ones applied at runtime
+func (c *Config) RequiresRestart(n *Config) bool {
+	cp := *c
+	cp.LogLevel = n.LogLevel
+	cp.LogComponents = n.LogComponents
+	cp.Runner = n.Runner
+
+	return cp != *n
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	"github.com/spf13/cobra"
+	"testing"
+	"time"
+)
+
+func TestConfig_ItLoadsLegacyEnvVarsAndDefaults(t *testing.T) {
+	t.Setenv("NAMESPACE", "foo")
+	t.Setenv("WORKERS_CONFIGMAP_NAME", "bar-config")
+	t.Setenv("WORKERS_CONFIGMAP_BINARY", "true")
+
+	c, err := load(t, "--config-path", t.TempDir(), "--label", "bar")
+	if err != nil {
+		t.Fatalf("unexpected error loading config, error %v", err)
+	}
+
+	if expected, got := "foo", c.Namespace; expected != got {
+		t.Errorf("namespace does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := "bar-config", c.ConfigMapName; expected != got {
+		t.Errorf("configmap name does not match, expected %s got %s", expected, got)
+	}
+	if !c.ConfigMapBinary {
+		t.Error("expected configmap binary enabled")
+	}
+	if expected, got := "bar", c.WatchLabel; expected != got {
+		t.Errorf("label does not match, expected %s got %s", expected, got)
+	}
+	if expected, got := "configmap", c.Storage; expected != got {
+		t.Errorf("storage does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestConfig_ItRejectsInvalidValues(t *testing.T) {
+	for _, args := range [][]string{
+		{"--configmap-format", "xml"},
+		{"--configmap-sharding", "foo"},
+		{"--storage", "foo"},
+		{"--storage", "file"},
+		{"--runner-timeout", "0s"},
+		{"--namespace", ""},
+		{"--leader-elect", "--leader-elect-lease-duration", "1s"},
+		{"--leader-elect", "--leader-elect-lease", ""},
+	} {
+		if _, err := load(t, append([]string{"--config-path", t.TempDir()}, args...)...); err == nil {
+			t.Errorf("expected error loading config with args %v", args)
+		}
+	}
+}
+
+func TestConfig_ItOnlyHotReloadsLogLevelAndRunnerTuning(t *testing.T) {
+	c, err := load(t, "--config-path", t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error loading config, error %v", err)
+	}
+
+	n := *c
+	n.LogLevel = "debug"
+	n.HandleTimeout = time.Second * 5
+	if c.RequiresRestart(&n) {
+		t.Error("unexpected restart on hot reloaded fields")
+	}
+
+	n.Namespace = "foo"
+	if !c.RequiresRestart(&n) {
+		t.Error("expected restart on namespace update")
+	}
+}
+
+func load(t *testing.T, args ...string) (*Config, error) {
+	cmd := &cobra.Command{Use: "fake"}
+	cfg.SetCoreFlags(cmd, "fake")
+	cfg.SetRunnerFlags(cmd)
+	SetFlags(cmd)
+	if err := cmd.PersistentFlags().Parse(args); err != nil {
+		t.Fatalf("unable to parse flags, error %v", err)
+	}
+
+	l, err := cfg.NewLoader(cmd, EnvAliases)
+	if err != nil {
+		t.Fatalf("unexpected error building loader, error %v", err)
+	}
+
+	c := &Config{}
+	return c, l.Load(c)
+}
@@ -19,29 +19,98 @@ const (
 	PhaseRunning  = "RUNNING"
 	PhaseUpdating = "UPDATING"
 	PhaseDone     = "DONE"
+	PhaseDegraded = "DEGRADED"
 )
 
 type Job string
 
-// Status defines the observed state of Worker
+// Status defines the observed state of Swarm, owned by the controller through status subresource
 type Status struct {
-	Phase string `json:"phase,omitempty"`
+	Phase              string `json:"phase,omitempty"`
+	Message            string `json:"message,omitempty"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+	// Version and Size report current assignment
+	Version int64 `json:"version,omitempty"`
+	Size    int   `json:"size,omitempty"`
+	// Members reports jobs assigned to each worker and their applied version
+	Members []Worker `json:"members,omitempty"`
+	// Converged gets true once all members acknowledge current version
+	Converged bool `json:"converged,omitempty"`
+	// PendingLeases lists jobs whose lease is still held by a previous worker
+	PendingLeases []Job `json:"pendingLeases,omitempty"`
+	// Unhealthy lists not ready workers whose jobs got moved to healthy ones
+	Unhealthy []string `json:"unhealthy,omitempty"`
+	// Slots maps deployment and selector pools logical slots to its current pod
+	Slots       map[string]string       `json:"slots,omitempty"`
+	Assignment  *Assignment             `json:"assignment,omitempty"`
+	Unsatisfied []UnsatisfiedConstraint `json:"unsatisfied,omitempty"`
+	Unassigned  []Job                   `json:"unassigned,omitempty"`
+}
+
+// UnsatisfiedConstraint reports a job constraint that current assignment does not honour
+type UnsatisfiedConstraint struct {
+	Type   string `json:"type"`
+	Jobs   []Job  `json:"jobs"`
+	Reason string `json:"reason"`
+}
+
+// Assignment defines workload assignation persisted on swarm status storage
+type Assignment struct {
+	Version int64              `json:"version"`
+	Workers []WorkerAssignment `json:"workers,omitempty"`
+}
+
+// WorkerAssignment defines jobs assigned to a worker
+type WorkerAssignment struct {
+	Name   string `json:"name"`
+	Jobs   []Job  `json:"jobs"`
+	Weight int64  `json:"weight,omitempty"`
+}
+
+// Storage defines where workload assignations get persisted, name defaults to configmap name
+type Storage struct {
+	Type string `json:"type,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// Strategy selects swarm balancing strategy by name, parameters are strategy specific
+type Strategy struct {
+	Name       string            `json:"name"`
+	Parameters map[string]string `json:"parameters,omitempty"`
 }
 
 type Worker struct {
-	Name      string `json:"name"`
-	Jobs      []Job  `json:"jobs"`
-	CreatedAt int64  `json:"created_at"`
+	Name           string `json:"name"`
+	Jobs           []Job  `json:"jobs"`
+	CreatedAt      int64  `json:"created_at"`
+	AppliedVersion int64  `json:"appliedVersion,omitempty"`
 }
 
 // SwarmSpec defines the desired state of Swarm
 type SwarmSpec struct {
-	Version         int64    `json:"version"`
-	StatefulSetName string   `json:"statefulset-name"`
-	ConfigMapName   string   `json:"configmap-name"`
-	Workload        []Job    `json:"workload"`
-	Size            int      `json:"size, omitempty"`
-	Members         []Worker `json:"members,omitempty"`
+	// Version sets initial assignment version
+	Version int64 `json:"version"`
+	// Worker pool is a statefulset, a deployment or any pods label selector, deployment and selector pools
+	// get stable logical slots assigned to its live pods
+	StatefulSetName string                `json:"statefulset-name,omitempty"`
+	DeploymentName  string                `json:"deployment-name,omitempty"`
+	Selector        *metav1.LabelSelector `json:"selector,omitempty"`
+	ConfigMapName   string                `json:"configmap-name"`
+	Workload        []Job                 `json:"workload"`
+	// Weights sets job weights, jobs without weight get default weight 1
+	Weights map[string]int64 `json:"weights,omitempty"`
+	// Deprecated: Size and Members are reported on status
+	Size    int      `json:"size,omitempty"`
+	Members []Worker `json:"members,omitempty"`
+	Storage *Storage `json:"storage,omitempty"`
+	// Strategy defaults to sticky, the balancing swarms had before strategies got selectable
+	Strategy *Strategy `json:"strategy,omitempty"`
+	// Affinity groups jobs that must share worker, AntiAffinity groups jobs that must never share one
+	Affinity     [][]Job `json:"affinity,omitempty"`
+	AntiAffinity [][]Job `json:"anti-affinity,omitempty"`
+	// Jobs over worker limits stay unassigned
+	MaxJobsPerWorker   int   `json:"max-jobs-per-worker,omitempty"`
+	MaxWeightPerWorker int64 `json:"max-weight-per-worker,omitempty"`
 }
 
 // +genclient
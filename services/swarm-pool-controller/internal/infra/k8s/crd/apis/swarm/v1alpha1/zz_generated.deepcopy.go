@@ -22,12 +22,77 @@ limitations under the License.
 package v1alpha1
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Assignment) DeepCopyInto(out *Assignment) {
+	*out = *in
+	if in.Workers != nil {
+		in, out := &in.Workers, &out.Workers
+		*out = make([]WorkerAssignment, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Assignment.
+func (in *Assignment) DeepCopy() *Assignment {
+	if in == nil {
+		return nil
+	}
+	out := new(Assignment)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Status) DeepCopyInto(out *Status) {
 	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]Worker, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingLeases != nil {
+		in, out := &in.PendingLeases, &out.PendingLeases
+		*out = make([]Job, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unhealthy != nil {
+		in, out := &in.Unhealthy, &out.Unhealthy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Slots != nil {
+		in, out := &in.Slots, &out.Slots
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Assignment != nil {
+		in, out := &in.Assignment, &out.Assignment
+		*out = new(Assignment)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Unsatisfied != nil {
+		in, out := &in.Unsatisfied, &out.Unsatisfied
+		*out = make([]UnsatisfiedConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Unassigned != nil {
+		in, out := &in.Unassigned, &out.Unassigned
+		*out = make([]Job, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -41,13 +106,52 @@ func (in *Status) DeepCopy() *Status {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Storage) DeepCopyInto(out *Storage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Storage.
+func (in *Storage) DeepCopy() *Storage {
+	if in == nil {
+		return nil
+	}
+	out := new(Storage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Strategy) DeepCopyInto(out *Strategy) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Strategy.
+func (in *Strategy) DeepCopy() *Strategy {
+	if in == nil {
+		return nil
+	}
+	out := new(Strategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Swarm) DeepCopyInto(out *Swarm) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -105,11 +209,23 @@ func (in *SwarmList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SwarmSpec) DeepCopyInto(out *SwarmSpec) {
 	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Workload != nil {
 		in, out := &in.Workload, &out.Workload
 		*out = make([]Job, len(*in))
 		copy(*out, *in)
 	}
+	if in.Weights != nil {
+		in, out := &in.Weights, &out.Weights
+		*out = make(map[string]int64, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Members != nil {
 		in, out := &in.Members, &out.Members
 		*out = make([]Worker, len(*in))
@@ -117,6 +233,38 @@ func (in *SwarmSpec) DeepCopyInto(out *SwarmSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(Storage)
+		**out = **in
+	}
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(Strategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = make([][]Job, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make([]Job, len(*in))
+				copy(*out, *in)
+			}
+		}
+	}
+	if in.AntiAffinity != nil {
+		in, out := &in.AntiAffinity, &out.AntiAffinity
+		*out = make([][]Job, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make([]Job, len(*in))
+				copy(*out, *in)
+			}
+		}
+	}
 	return
 }
 
@@ -130,6 +278,27 @@ func (in *SwarmSpec) DeepCopy() *SwarmSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnsatisfiedConstraint) DeepCopyInto(out *UnsatisfiedConstraint) {
+	*out = *in
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = make([]Job, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnsatisfiedConstraint.
+func (in *UnsatisfiedConstraint) DeepCopy() *UnsatisfiedConstraint {
+	if in == nil {
+		return nil
+	}
+	out := new(UnsatisfiedConstraint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Worker) DeepCopyInto(out *Worker) {
 	*out = *in
@@ -150,3 +319,24 @@ func (in *Worker) DeepCopy() *Worker {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerAssignment) DeepCopyInto(out *WorkerAssignment) {
+	*out = *in
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = make([]Job, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerAssignment.
+func (in *WorkerAssignment) DeepCopy() *WorkerAssignment {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerAssignment)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -30,7 +30,7 @@ func NewHandler(c controller) *Handler {
 
 func (h *Handler) Create(ctx context.Context, o runtime.Object) error {
 	sw := o.(*v1alpha1.Swarm)
-	log.Infof("Create Swarm Namespace %s name %s StatefulSet Name %s size %d status %s", sw.Namespace, sw.Name, sw.Spec.StatefulSetName, sw.Spec.Size, sw.Status)
+	log.Infof("Create Swarm Namespace %s name %s StatefulSet Name %s size %d status %s", sw.Namespace, sw.Name, sw.Spec.StatefulSetName, sw.Status.Size, sw.Status.Phase)
 
 	if err := h.controller.Create(ctx, sw.Namespace, sw.Name); err != nil {
 		return fmt.Errorf("unable to process swarm %s %s error %v", sw.Namespace, sw.Name, err)
@@ -42,9 +42,10 @@ func (h *Handler) Create(ctx context.Context, o runtime.Object) error {
 func (h *Handler) Update(ctx context.Context, o, n runtime.Object) error {
 	osw := o.(*v1alpha1.Swarm)
 	nsw := n.(*v1alpha1.Swarm)
-	log.Infof("Create Swarm Namespace %s name %s StatefulSet Name %s size %d status %s", osw.Namespace, osw.Name, osw.Spec.StatefulSetName, osw.Spec.Size, osw.Status)
+	log.Infof("Update Swarm Namespace %s name %s StatefulSet Name %s size %d status %s", osw.Namespace, osw.Name, osw.Spec.StatefulSetName, osw.Status.Size, osw.Status.Phase)
 
-	if Equals(osw, nsw) {
+	// status updates keep generation, spec ones bump it
+	if osw.Generation == nsw.Generation && Equals(osw, nsw) {
 		return nil
 	}
 
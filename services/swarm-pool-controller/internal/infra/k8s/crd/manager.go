@@ -19,6 +19,27 @@ func NewManager(i crd.Initializer) *manager {
 	}
 }
 
+// minWeight rejects non positive job weights
+var minWeight = 1.0
+
+// minLimit rejects negative worker limits, zero disables them
+var minLimit = 0.0
+
+// jobGroups defines affinity and anti affinity job groups
+var jobGroups = v1.JSONSchemaProps{
+	Type: "array",
+	Items: &v1.JSONSchemaPropsOrArray{
+		Schema: &v1.JSONSchemaProps{
+			Type: "array",
+			Items: &v1.JSONSchemaPropsOrArray{
+				Schema: &v1.JSONSchemaProps{
+					Type: "string",
+				},
+			},
+		},
+	},
+}
+
 func (m *manager) Create(ctx context.Context) error {
 	cr := &v1.CustomResourceDefinition{
 		ObjectMeta: metav1.ObjectMeta{
@@ -42,9 +63,40 @@ func (m *manager) Create(ctx context.Context) error {
 									Type: "object",
 									Properties: map[string]v1.JSONSchemaProps{
 										"statefulset-name": {Type: "string"},
-										"configmap-name":   {Type: "string"},
-										"version":          {Type: "integer"},
-										"size":             {Type: "integer"},
+										"deployment-name":  {Type: "string"},
+										"selector": {
+											Type: "object",
+											Properties: map[string]v1.JSONSchemaProps{
+												"matchLabels": {
+													Type: "object",
+													AdditionalProperties: &v1.JSONSchemaPropsOrBool{
+														Schema: &v1.JSONSchemaProps{Type: "string"},
+													},
+												},
+												"matchExpressions": {
+													Type: "array",
+													Items: &v1.JSONSchemaPropsOrArray{
+														Schema: &v1.JSONSchemaProps{
+															Type: "object",
+															Properties: map[string]v1.JSONSchemaProps{
+																"key":      {Type: "string"},
+																"operator": {Type: "string"},
+																"values": {
+																	Type: "array",
+																	Items: &v1.JSONSchemaPropsOrArray{
+																		Schema: &v1.JSONSchemaProps{Type: "string"},
+																	},
+																},
+															},
+															Required: []string{"key", "operator"},
+														},
+													},
+												},
+											},
+										},
+										"configmap-name": {Type: "string"},
+										"version":        {Type: "integer"},
+										"size":           {Type: "integer"},
 										"workload": {
 											Type: "array",
 											Items: &v1.JSONSchemaPropsOrArray{
@@ -53,6 +105,15 @@ func (m *manager) Create(ctx context.Context) error {
 												},
 											},
 										},
+										"weights": {
+											Type: "object",
+											AdditionalProperties: &v1.JSONSchemaPropsOrBool{
+												Schema: &v1.JSONSchemaProps{
+													Type:    "integer",
+													Minimum: &minWeight,
+												},
+											},
+										},
 										"members": {
 											Type: "array",
 											Items: &v1.JSONSchemaPropsOrArray{
@@ -82,8 +143,35 @@ func (m *manager) Create(ctx context.Context) error {
 											},
 											Required: []string{"created_at"},
 										},
+										"storage": {
+											Type: "object",
+											Properties: map[string]v1.JSONSchemaProps{
+												"type": {Type: "string"},
+												"name": {Type: "string"},
+											},
+										},
+										"strategy": {
+											Type:    "object",
+											Default: &v1.JSON{Raw: []byte(`{"name":"sticky"}`)},
+											Properties: map[string]v1.JSONSchemaProps{
+												"name": {Type: "string"},
+												"parameters": {
+													Type: "object",
+													AdditionalProperties: &v1.JSONSchemaPropsOrBool{
+														Schema: &v1.JSONSchemaProps{
+															Type: "string",
+														},
+													},
+												},
+											},
+											Required: []string{"name"},
+										},
+										"affinity":              jobGroups,
+										"anti-affinity":         jobGroups,
+										"max-jobs-per-worker":   {Type: "integer", Minimum: &minLimit},
+										"max-weight-per-worker": {Type: "integer", Minimum: &minLimit},
 									},
-									Required: []string{"statefulset-name", "configmap-name", "workload"},
+									Required: []string{"configmap-name", "workload"},
 								},
 								"status": {
 									Type: "object",
@@ -91,6 +179,110 @@ func (m *manager) Create(ctx context.Context) error {
 										"phase": {
 											Type: "string",
 										},
+										"message": {
+											Type: "string",
+										},
+										"observedGeneration": {Type: "integer"},
+										"version":            {Type: "integer"},
+										"size":               {Type: "integer"},
+										"members": {
+											Type: "array",
+											Items: &v1.JSONSchemaPropsOrArray{
+												Schema: &v1.JSONSchemaProps{
+													Type: "object",
+													Properties: map[string]v1.JSONSchemaProps{
+														"name": {Type: "string"},
+														"jobs": {
+															Type: "array",
+															Items: &v1.JSONSchemaPropsOrArray{
+																Schema: &v1.JSONSchemaProps{
+																	Type: "string",
+																},
+															},
+														},
+														"created_at":     {Type: "integer"},
+														"appliedVersion": {Type: "integer"},
+													},
+												},
+											},
+										},
+										"converged": {Type: "boolean"},
+										"pendingLeases": {
+											Type: "array",
+											Items: &v1.JSONSchemaPropsOrArray{
+												Schema: &v1.JSONSchemaProps{
+													Type: "string",
+												},
+											},
+										},
+										"unhealthy": {
+											Type: "array",
+											Items: &v1.JSONSchemaPropsOrArray{
+												Schema: &v1.JSONSchemaProps{
+													Type: "string",
+												},
+											},
+										},
+										"slots": {
+											Type: "object",
+											AdditionalProperties: &v1.JSONSchemaPropsOrBool{
+												Schema: &v1.JSONSchemaProps{Type: "string"},
+											},
+										},
+										"assignment": {
+											Type: "object",
+											Properties: map[string]v1.JSONSchemaProps{
+												"version": {Type: "integer"},
+												"workers": {
+													Type: "array",
+													Items: &v1.JSONSchemaPropsOrArray{
+														Schema: &v1.JSONSchemaProps{
+															Type: "object",
+															Properties: map[string]v1.JSONSchemaProps{
+																"name": {Type: "string"},
+																"jobs": {
+																	Type: "array",
+																	Items: &v1.JSONSchemaPropsOrArray{
+																		Schema: &v1.JSONSchemaProps{
+																			Type: "string",
+																		},
+																	},
+																},
+																"weight": {Type: "integer"},
+															},
+														},
+													},
+												},
+											},
+										},
+										"unassigned": {
+											Type: "array",
+											Items: &v1.JSONSchemaPropsOrArray{
+												Schema: &v1.JSONSchemaProps{
+													Type: "string",
+												},
+											},
+										},
+										"unsatisfied": {
+											Type: "array",
+											Items: &v1.JSONSchemaPropsOrArray{
+												Schema: &v1.JSONSchemaProps{
+													Type: "object",
+													Properties: map[string]v1.JSONSchemaProps{
+														"type":   {Type: "string"},
+														"reason": {Type: "string"},
+														"jobs": {
+															Type: "array",
+															Items: &v1.JSONSchemaPropsOrArray{
+																Schema: &v1.JSONSchemaProps{
+																	Type: "string",
+																},
+															},
+														},
+													},
+												},
+											},
+										},
 									},
 								},
 							},
@@ -102,6 +294,11 @@ func (m *manager) Create(ctx context.Context) error {
 							Type:     "string",
 							JSONPath: ".spec.statefulset-name",
 						},
+						{
+							Name:     "Deployment",
+							Type:     "string",
+							JSONPath: ".spec.deployment-name",
+						},
 						{
 							Name:     "ConfigMap",
 							Type:     "string",
@@ -110,12 +307,17 @@ func (m *manager) Create(ctx context.Context) error {
 						{
 							Name:     "Version",
 							Type:     "integer",
-							JSONPath: ".spec.version",
+							JSONPath: ".status.version",
 						},
 						{
 							Name:     "Size",
 							Type:     "integer",
-							JSONPath: ".spec.size",
+							JSONPath: ".status.size",
+						},
+						{
+							Name:     "Converged",
+							Type:     "boolean",
+							JSONPath: ".status.converged",
 						},
 						{
 							Name:     "Age",
@@ -2,7 +2,6 @@ package crd
 
 import (
 	"context"
-	"github.com/davecgh/go-spew/spew"
 	"github.com/marcosQuesada/k8s-lab/pkg/operator"
 	"github.com/marcosQuesada/k8s-lab/pkg/operator/crd"
 	"testing"
@@ -16,7 +15,7 @@ func TestItRecognizedCreatedCrdDevelopment(t *testing.T) {
 
 	err := m.Create(context.Background())
 
-	spew.Dump(err)
+	t.Logf("error %v", err)
 }
 
 func TestItChecksCRDAcceptedDevelopment(t *testing.T) {
@@ -27,5 +26,5 @@ func TestItChecksCRDAcceptedDevelopment(t *testing.T) {
 
 	e, err := m.IsAccepted(context.Background())
 
-	spew.Dump(e, err)
+	t.Logf("accepted %v error %v", e, err)
 }
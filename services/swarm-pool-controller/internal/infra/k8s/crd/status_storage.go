@@ -0,0 +1,85 @@
+package crd
+
+import (
+	"context"
+	"fmt"
+	cfg "github.com/marcosQuesada/k8s-lab/pkg/config"
+	logger "github.com/marcosQuesada/k8s-lab/pkg/log"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/apis/swarm/v1alpha1"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/generated/clientset/versioned"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+)
+
+// StatusStorage persists workload assignations on swarm status subresource
+type StatusStorage struct {
+	client versioned.Interface
+}
+
+// NewStatusStorage instantiates swarm status storage
+func NewStatusStorage(cl versioned.Interface) *StatusStorage {
+	return &StatusStorage{
+		client: cl,
+	}
+}
+
+// Set updates swarm status assignment
+func (s *StatusStorage) Set(ctx context.Context, namespace, name string, a *cfg.Workloads) error {
+	sw, err := s.client.K8slabV1alpha1().Swarms(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to get swarm %s error %v", name, err)
+	}
+
+	asg := &v1alpha1.Assignment{Version: a.Version}
+	for worker, w := range a.Workloads {
+		wa := v1alpha1.WorkerAssignment{Name: worker, Jobs: []v1alpha1.Job{}}
+		if w != nil {
+			wa.Weight = w.Weight
+			for _, job := range w.Jobs {
+				wa.Jobs = append(wa.Jobs, v1alpha1.Job(job))
+			}
+		}
+		asg.Workers = append(asg.Workers, wa)
+	}
+	sort.Slice(asg.Workers, func(i, j int) bool {
+		return asg.Workers[i].Name < asg.Workers[j].Name
+	})
+
+	updated := sw.DeepCopy()
+	updated.Status.Assignment = asg
+	if _, err := s.client.K8slabV1alpha1().Swarms(namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("unable to update swarm %s status error %v", name, err)
+	}
+
+	logger.FromContext(ctx).Debugf("swarm %s status assignment updated on namespace %s version %d", name, namespace, a.Version)
+	return nil
+}
+
+// Get returns workload assignations from swarm status
+func (s *StatusStorage) Get(ctx context.Context, namespace, name string) (*cfg.Workloads, error) {
+	sw, err := s.client.K8slabV1alpha1().Swarms(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get swarm %s error %v", name, err)
+	}
+
+	res := &cfg.Workloads{Workloads: map[string]*cfg.Workload{}}
+	if sw.Status.Assignment == nil {
+		return res, nil
+	}
+
+	res.Version = sw.Status.Assignment.Version
+	for _, wa := range sw.Status.Assignment.Workers {
+		w := &cfg.Workload{Weight: wa.Weight}
+		for _, job := range wa.Jobs {
+			w.Jobs = append(w.Jobs, cfg.Job(job))
+		}
+		res.Workloads[wa.Name] = w
+	}
+
+	return res, nil
+}
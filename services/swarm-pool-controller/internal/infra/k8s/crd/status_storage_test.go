@@ -0,0 +1,28 @@
+package crd
+
+import (
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/storage/storagetest"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/apis/swarm/v1alpha1"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/generated/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestStatusStorage_Conformance(t *testing.T) {
+	namespace := "swarm"
+	name := "swarm-config"
+	sw := &v1alpha1.Swarm{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: v1alpha1.SwarmSpec{
+			StatefulSetName: "swarm-worker",
+			ConfigMapName:   "swarm-worker-config",
+		},
+	}
+
+	s := NewStatusStorage(fake.NewSimpleClientset(sw))
+
+	storagetest.Run(t, s, namespace, name)
+}
@@ -0,0 +1,99 @@
+package pod
+
+import (
+	"context"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/pod"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/statefulset"
+	log "github.com/sirupsen/logrus"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PoolController refreshes pool status from its worker pods, deployment and selector pools get its slots synced
+type PoolController interface {
+	RefreshPool(ctx context.Context, namespace, name string) error
+	SyncSlots(ctx context.Context, namespace, name string) error
+}
+
+// Handler handles worker pods applied version acknowledgements and readiness changes, pods without statefulset
+// owner sync the slot pools they belong to on any lifecycle change
+type Handler struct {
+	controller PoolController
+	selector   statefulset.SelectorStore
+}
+
+// NewHandler instantiates worker pod handler
+func NewHandler(c PoolController, s statefulset.SelectorStore) *Handler {
+	return &Handler{
+		controller: c,
+		selector:   s,
+	}
+}
+
+func (h *Handler) Create(ctx context.Context, o runtime.Object) error {
+	pd := o.(*api.Pod)
+	if !ownedByStatefulSet(pd) {
+		return h.syncSlots(ctx, pd)
+	}
+	if _, ok := pod.AppliedVersion(pd); !ok && pod.IsReady(pd) {
+		return nil
+	}
+
+	return h.refresh(ctx, pd)
+}
+
+func (h *Handler) Update(ctx context.Context, o, n runtime.Object) error {
+	opd := o.(*api.Pod)
+	npd := n.(*api.Pod)
+	if !ownedByStatefulSet(npd) && pod.HasDeletionTimestamp(opd) != pod.HasDeletionTimestamp(npd) {
+		return h.syncSlots(ctx, npd)
+	}
+	if opd.Annotations[pod.AppliedVersionAnnotation] == npd.Annotations[pod.AppliedVersionAnnotation] && pod.IsReady(opd) == pod.IsReady(npd) {
+		return nil
+	}
+
+	return h.refresh(ctx, npd)
+}
+
+// Delete refreshes pools on worker pods deletion, pending handoffs wait previous owners pods to be gone.
+// Statefulset replicas updates rebalance the pool
+func (h *Handler) Delete(ctx context.Context, o runtime.Object) error {
+	pd, ok := o.(*api.Pod)
+	if !ok {
+		return nil
+	}
+
+	return h.refresh(ctx, pd)
+}
+
+func (h *Handler) refresh(ctx context.Context, pd *api.Pod) error {
+	if !ownedByStatefulSet(pd) {
+		return h.syncSlots(ctx, pd)
+	}
+
+	owner := metav1.GetControllerOf(pd)
+	if !h.selector.IsRegistered(pd.Namespace, owner.Name) {
+		return nil
+	}
+
+	log.Infof("Worker pod %s namespace %s applied version %s ready %t", pd.Name, pd.Namespace, pd.Annotations[pod.AppliedVersionAnnotation], pod.IsReady(pd))
+
+	return h.controller.RefreshPool(ctx, pd.Namespace, owner.Name)
+}
+
+func (h *Handler) syncSlots(ctx context.Context, pd *api.Pod) error {
+	for _, name := range h.selector.Matching(pd.Namespace, pd.Labels) {
+		log.Infof("Worker pod %s namespace %s slot pool %s sync", pd.Name, pd.Namespace, name)
+		if err := h.controller.SyncSlots(ctx, pd.Namespace, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ownedByStatefulSet(pd *api.Pod) bool {
+	owner := metav1.GetControllerOf(pd)
+	return owner != nil && owner.Kind == "StatefulSet"
+}
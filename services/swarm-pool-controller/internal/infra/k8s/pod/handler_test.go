@@ -0,0 +1,122 @@
+package pod
+
+import (
+	"context"
+	"github.com/marcosQuesada/k8s-lab/pkg/operator/pod"
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/statefulset"
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"testing"
+)
+
+func TestHandler_ItRefreshesRegisteredPoolsOnAppliedVersionUpdates(t *testing.T) {
+	ss := statefulset.NewSelectorStore()
+	if err := ss.Register("swarm", "swarm-worker", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "swarm-worker"}}); err != nil {
+		t.Fatalf("unable to register selector %v", err)
+	}
+	c := &fakeController{}
+	h := NewHandler(c, ss)
+
+	old := workerPod("swarm-worker", "1")
+	for _, n := range []*api.Pod{workerPod("swarm-worker", "1"), workerPod("swarm-worker", "2"), workerPod("foo", "3")} {
+		if err := h.Update(context.Background(), old, n); err != nil {
+			t.Fatalf("unexpected error handling update %v", err)
+		}
+	}
+
+	if expected, got := 1, c.refreshed; expected != got {
+		t.Errorf("total refreshes do not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestHandler_ItRefreshesRegisteredPoolsOnReadinessChanges(t *testing.T) {
+	ss := statefulset.NewSelectorStore()
+	if err := ss.Register("swarm", "swarm-worker", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "swarm-worker"}}); err != nil {
+		t.Fatalf("unable to register selector %v", err)
+	}
+	c := &fakeController{}
+	h := NewHandler(c, ss)
+
+	old := workerPod("swarm-worker", "1")
+	n := workerPod("swarm-worker", "1")
+	n.Status.Conditions = []api.PodCondition{{Type: api.PodReady, Status: api.ConditionTrue}}
+	if err := h.Update(context.Background(), old, n); err != nil {
+		t.Fatalf("unexpected error handling update %v", err)
+	}
+	if err := h.Update(context.Background(), n, n); err != nil {
+		t.Fatalf("unexpected error handling update %v", err)
+	}
+
+	if expected, got := 1, c.refreshed; expected != got {
+		t.Errorf("total refreshes do not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestHandler_ItRefreshesRegisteredPoolsOnWorkerPodDeletion(t *testing.T) {
+	ss := statefulset.NewSelectorStore()
+	if err := ss.Register("swarm", "swarm-worker", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "swarm-worker"}}); err != nil {
+		t.Fatalf("unable to register selector %v", err)
+	}
+	c := &fakeController{}
+	h := NewHandler(c, ss)
+
+	for _, pd := range []*api.Pod{workerPod("swarm-worker", "1"), workerPod("foo", "1")} {
+		if err := h.Delete(context.Background(), pd); err != nil {
+			t.Fatalf("unexpected error handling delete %v", err)
+		}
+	}
+
+	if expected, got := 1, c.refreshed; expected != got {
+		t.Errorf("total refreshes do not match, expected %d got %d", expected, got)
+	}
+}
+
+func TestHandler_ItSyncsSlotPoolsOnDeploymentPodsLifecycle(t *testing.T) {
+	ss := statefulset.NewSelectorStore()
+	if err := ss.Register("swarm", "foo", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "deployment-worker"}}); err != nil {
+		t.Fatalf("unable to register selector %v", err)
+	}
+	c := &fakeController{}
+	h := NewHandler(c, ss)
+
+	pd := &api.Pod{ObjectMeta: metav1.ObjectMeta{Name: "deployment-worker-5d8f-x2k9", Namespace: "swarm", Labels: map[string]string{"app": "deployment-worker"}}}
+	if err := h.Create(context.Background(), pd); err != nil {
+		t.Fatalf("unexpected error handling create %v", err)
+	}
+	if err := h.Delete(context.Background(), pd); err != nil {
+		t.Fatalf("unexpected error handling delete %v", err)
+	}
+	if err := h.Delete(context.Background(), workerPod("swarm-worker", "1")); err != nil {
+		t.Fatalf("unexpected error handling delete %v", err)
+	}
+
+	if expected, got := []string{"foo", "foo"}, c.synced; !reflect.DeepEqual(expected, got) {
+		t.Errorf("synced slot pools do not match, expected %v got %v", expected, got)
+	}
+}
+
+func workerPod(owner, version string) *api.Pod {
+	ctl := true
+	return &api.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            owner + "-0",
+		Namespace:       "swarm",
+		Annotations:     map[string]string{pod.AppliedVersionAnnotation: version},
+		OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: owner, Controller: &ctl}},
+	}}
+}
+
+type fakeController struct {
+	refreshed int
+	synced    []string
+}
+
+func (f *fakeController) RefreshPool(ctx context.Context, namespace, name string) error {
+	f.refreshed++
+	return nil
+}
+
+func (f *fakeController) SyncSlots(ctx context.Context, namespace, name string) error {
+	f.synced = append(f.synced, name)
+	return nil
+}
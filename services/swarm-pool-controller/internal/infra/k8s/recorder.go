@@ -0,0 +1,19 @@
+package k8s
+
+import (
+	"github.com/marcosQuesada/k8s-lab/services/swarm-pool-controller/internal/infra/k8s/crd/generated/clientset/versioned/scheme"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// NewRecorder instantiates event recorder, swarm events get published on swarm namespace
+func NewRecorder(cl kubernetes.Interface, component string) record.EventRecorder {
+	b := record.NewBroadcaster()
+	b.StartLogging(log.Debugf)
+	b.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: cl.CoreV1().Events("")})
+
+	return b.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component})
+}
@@ -46,7 +46,8 @@ func (h *Handler) Update(ctx context.Context, o, n runtime.Object) error {
 		return nil
 	}
 
-	if oss.Spec.Replicas == nss.Spec.Replicas {
+	// ready replicas changes refresh swarm phase
+	if *oss.Spec.Replicas == *nss.Spec.Replicas && oss.Status.ReadyReplicas == nss.Status.ReadyReplicas {
 		return nil
 	}
 
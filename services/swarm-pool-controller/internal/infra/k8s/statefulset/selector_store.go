@@ -5,6 +5,8 @@ import (
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"sort"
+	"strings"
 
 	"sync"
 )
@@ -14,6 +16,8 @@ type SelectorStore interface {
 	UnRegister(namespace, name string)
 	Matches(namespace, name string, l map[string]string) bool
 	IsRegistered(namespace, name string) bool
+	Matching(namespace string, l map[string]string) []string
+	Selectors() map[string]string
 }
 
 type selectorStore struct {
@@ -27,6 +31,7 @@ func NewSelectorStore() SelectorStore {
 	}
 }
 
+// Register indexes name selector, registered names get its selector replaced when it changes
 func (s *selectorStore) Register(namespace, name string, ls *metav1.LabelSelector) error {
 	selector, err := metav1.LabelSelectorAsSelector(ls)
 	if err != nil {
@@ -37,7 +42,7 @@ func (s *selectorStore) Register(namespace, name string, ls *metav1.LabelSelecto
 	defer s.mutex.Unlock()
 
 	k := namespace + "/" + name
-	if _, ok := s.index[k]; ok {
+	if current, ok := s.index[k]; ok && current.String() == selector.String() {
 		return nil
 	}
 	s.index[k] = selector
@@ -73,6 +78,36 @@ func (s *selectorStore) IsRegistered(namespace, name string) bool {
 	return ok
 }
 
+// Matching returns namespace registered names whose selector matches labels, sorted
+func (s *selectorStore) Matching(namespace string, l map[string]string) []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var res []string
+	for k, sl := range s.index {
+		if !strings.HasPrefix(k, namespace+"/") || !sl.Matches(labels.Set(l)) {
+			continue
+		}
+		res = append(res, strings.TrimPrefix(k, namespace+"/"))
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// Selectors returns registered selectors by statefulset key
+func (s *selectorStore) Selectors() map[string]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	res := make(map[string]string, len(s.index))
+	for k, sl := range s.index {
+		res[k] = sl.String()
+	}
+
+	return res
+}
+
 func (s *selectorStore) len() int {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
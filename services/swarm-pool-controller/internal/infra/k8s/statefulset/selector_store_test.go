@@ -3,6 +3,7 @@ package statefulset
 import (
 	apiv1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
 	"testing"
 )
 
@@ -83,3 +84,46 @@ func getFakePod(namespace, name string, labels map[string]string) apiv1.Pod {
 		},
 	}
 }
+
+func TestSelectorStore_ItListsRegisteredSelectors(t *testing.T) {
+	ss := NewSelectorStore()
+	if err := ss.Register("default", "foo-workers", fakeSelector("app", "foo")); err != nil {
+		t.Fatalf("unable to register, error %v", err)
+	}
+
+	if expected, got := "app=foo", ss.Selectors()["default/foo-workers"]; expected != got {
+		t.Fatalf("selector does not match, expected %s got %s", expected, got)
+	}
+}
+
+func TestSelectorStore_ItReplacesUpdatedSelectors(t *testing.T) {
+	ss := NewSelectorStore()
+	for _, value := range []string{"foo", "bar"} {
+		if err := ss.Register("default", "foo-workers", fakeSelector("app", value)); err != nil {
+			t.Fatalf("unable to register, error %v", err)
+		}
+	}
+
+	if ss.Matches("default", "foo-workers", map[string]string{"app": "foo"}) {
+		t.Error("unexpected previous selector match")
+	}
+	if !ss.Matches("default", "foo-workers", map[string]string{"app": "bar"}) {
+		t.Error("expected updated selector match")
+	}
+}
+
+func TestSelectorStore_ItReturnsNamespaceMatchingNames(t *testing.T) {
+	ss := NewSelectorStore()
+	for _, name := range []string{"foo", "bar"} {
+		if err := ss.Register("default", name, fakeSelector("app", "foo")); err != nil {
+			t.Fatalf("unable to register, error %v", err)
+		}
+	}
+	if err := ss.Register("other", "zoom", fakeSelector("app", "foo")); err != nil {
+		t.Fatalf("unable to register, error %v", err)
+	}
+
+	if expected, got := []string{"bar", "foo"}, ss.Matching("default", map[string]string{"app": "foo"}); !reflect.DeepEqual(expected, got) {
+		t.Errorf("matching names do not match, expected %v got %v", expected, got)
+	}
+}